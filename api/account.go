@@ -1,18 +1,17 @@
 package api
 
 import (
-	"database/sql"
 	"errors"
 	"net/http"
 
 	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
 	"bitbucket.org/jessyw/go_simplebank/token"
 	"github.com/gin-gonic/gin"
-	"github.com/lib/pq"
 )
 
 type createAccountRequest struct {
 	Currency string `json:"currency" binding:"required,currency"`
+	Product  string `json:"product" binding:"omitempty,oneof=checking savings"`
 }
 
 // CreateAccount - create an account
@@ -23,21 +22,24 @@ func (server *Server) CreateAccount(ctx *gin.Context) {
 		return
 	}
 
+	if req.Product == "" {
+		req.Product = db.AccountProductChecking
+	}
+
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 	arg := db.CreateAccountParams{
 		Owner:    authPayload.Username,
 		Currency: req.Currency,
 		Balance:  0,
+		Product:  req.Product,
 	}
 
 	account, err := server.store.CreateAccount(ctx, arg)
 	if err != nil {
-		if pqError, ok := err.(*pq.Error); ok {
-			switch pqError.Code.Name() {
-			case "foreign_key_violation", "unique_violation":
-				ctx.JSON(http.StatusForbidden, errorResponse(err))
-				return
-			}
+		switch db.ErrorCode(err) {
+		case db.ForeignKeyViolation, db.UniqueViolation:
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
 		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
@@ -60,7 +62,7 @@ func (server *Server) FindAccountById(ctx *gin.Context) {
 
 	account, err := server.store.GetAccount(ctx, req.ID)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, db.ErrRecordNotFound) {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
 			return
 		}
@@ -68,10 +70,7 @@ func (server *Server) FindAccountById(ctx *gin.Context) {
 		return
 	}
 
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if account.Owner != authPayload.Username {
-		err := errors.New("account doesn't belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	if !server.authorizeAccount(ctx, account, db.AccountPermissionView) {
 		return
 	}
 
@@ -100,7 +99,7 @@ func (server *Server) GetAccounts(ctx *gin.Context) {
 
 	accounts, err := server.store.ListAccounts(ctx, arg)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, db.ErrRecordNotFound) {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
 			return
 		}
@@ -112,33 +111,35 @@ func (server *Server) GetAccounts(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, accounts)
 }
 
-// DeleteAccount - delete an account by his id
-func (server *Server) DeleteAccount(ctx *gin.Context) {
+// CloseAccount - close an account with a zero balance, for the users allowed to manage it.
+// The account is kept with its entries and transfers, no money can move from or to it anymore.
+func (server *Server) CloseAccount(ctx *gin.Context) {
 	var req findAccountByIdRequest
 	if err := ctx.ShouldBindUri(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
-	// TODO add role admin to delete account
-	// authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	// if authPayload.Username != "admin" {
-	// 	err := errors.New("account doesn't belong to the authenticated user")
-	// 	ctx.JSON(http.StatusUnauthorized, errorResponse(err))
-	// 	return
-	// }
+	if _, ok := server.authorizedAccount(ctx, req.ID, db.AccountPermissionManage); !ok {
+		return
+	}
 
-	err := server.store.DeleteAccount(ctx, req.ID)
+	account, err := server.store.CloseAccountTx(ctx, db.CloseAccountTxParams{AccountID: req.ID})
 	if err != nil {
-		if err == sql.ErrNoRows {
+		switch {
+		case errors.Is(err, db.ErrRecordNotFound):
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
+		case errors.Is(err, db.ErrAccountClosed):
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		case errors.Is(err, db.ErrAccountHasBalance):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		}
-
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
-	ctx.JSON(http.StatusOK, nil)
+
+	ctx.JSON(http.StatusOK, account)
 }
 
 // Todo UpdateAccount
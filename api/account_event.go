@@ -0,0 +1,63 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/event"
+	"github.com/gin-gonic/gin"
+)
+
+// WatchAccountEvents - stream the balance, new entries and balance changes of an account as Server-Sent Events
+func (server *Server) WatchAccountEvents(ctx *gin.Context) {
+	var req findAccountByIdRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// subscribe before reading the balance snapshot so no change is missed in between
+	events, cancel := server.broker.Subscribe(req.ID)
+	defer cancel()
+
+	account, err := server.store.GetAccount(ctx, req.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !server.authorizeAccount(ctx, account, db.AccountPermissionView) {
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.SSEvent(event.TypeBalance, &event.AccountEvent{
+		Type:      event.TypeBalance,
+		AccountID: account.ID,
+		Balance:   account.Balance,
+		CreatedAt: time.Now(),
+	})
+	ctx.Writer.Flush()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-server.shutdown:
+			return
+		case accountEvent, ok := <-events:
+			if !ok {
+				return
+			}
+			ctx.SSEvent(accountEvent.Type, accountEvent)
+			ctx.Writer.Flush()
+		}
+	}
+}
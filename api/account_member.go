@@ -0,0 +1,196 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/token"
+	"github.com/gin-gonic/gin"
+)
+
+type inviteAccountMemberRequest struct {
+	Username   string `json:"username" binding:"required,alphanum"`
+	Permission string `json:"permission" binding:"required,oneof=view transact manage"`
+}
+
+// InviteAccountMember - invite a user to share an account, the membership starts once the user accepts
+func (server *Server) InviteAccountMember(ctx *gin.Context) {
+	var uri findAccountByIdRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req inviteAccountMemberRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.authorizedAccount(ctx, uri.ID, db.AccountPermissionManage)
+	if !valid {
+		return
+	}
+
+	if req.Username == account.Owner {
+		err := errors.New("the owner already has full access to the account")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	arg := db.CreateAccountMemberParams{
+		AccountID:  account.ID,
+		Username:   req.Username,
+		Permission: req.Permission,
+		InvitedBy:  authPayload.Username,
+	}
+
+	member, err := server.store.CreateAccountMember(ctx, arg)
+	if err != nil {
+		switch db.ErrorCode(err) {
+		case db.ForeignKeyViolation:
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		case db.UniqueViolation:
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, member)
+}
+
+// ListAccountMembers - list the users an account is shared with, invitations included
+func (server *Server) ListAccountMembers(ctx *gin.Context) {
+	var uri findAccountByIdRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, valid := server.authorizedAccount(ctx, uri.ID, db.AccountPermissionView); !valid {
+		return
+	}
+
+	members, err := server.store.ListAccountMembers(ctx, uri.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, members)
+}
+
+type accountMemberUri struct {
+	AccountID int64  `uri:"id" binding:"required,min=1"`
+	Username  string `uri:"username" binding:"required,alphanum"`
+}
+
+type updateAccountMemberRequest struct {
+	Permission string `json:"permission" binding:"required,oneof=view transact manage"`
+}
+
+// UpdateAccountMember - change the permission of a member
+func (server *Server) UpdateAccountMember(ctx *gin.Context) {
+	var uri accountMemberUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateAccountMemberRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, valid := server.authorizedAccount(ctx, uri.AccountID, db.AccountPermissionManage); !valid {
+		return
+	}
+
+	member, err := server.store.UpdateAccountMemberPermission(ctx, db.UpdateAccountMemberPermissionParams{
+		AccountID:  uri.AccountID,
+		Username:   uri.Username,
+		Permission: req.Permission,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, member)
+}
+
+// RemoveAccountMember - stop sharing an account with a user, members can also remove themselves
+func (server *Server) RemoveAccountMember(ctx *gin.Context) {
+	var uri accountMemberUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if uri.Username != authPayload.Username {
+		if _, valid := server.authorizedAccount(ctx, uri.AccountID, db.AccountPermissionManage); !valid {
+			return
+		}
+	}
+
+	err := server.store.DeleteAccountMember(ctx, db.DeleteAccountMemberParams{
+		AccountID: uri.AccountID,
+		Username:  uri.Username,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "member removed"})
+}
+
+// ListAccountInvitations - list the accounts the authenticated user is invited to share
+func (server *Server) ListAccountInvitations(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	invitations, err := server.store.ListAccountInvitations(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, invitations)
+}
+
+// AcceptAccountInvitation - accept the invitation to share an account
+func (server *Server) AcceptAccountInvitation(ctx *gin.Context) {
+	var uri findAccountByIdRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	member, err := server.store.AcceptAccountMember(ctx, db.AcceptAccountMemberParams{
+		AccountID: uri.ID,
+		Username:  authPayload.Username,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err := errors.New("no pending invitation for this account")
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, member)
+}
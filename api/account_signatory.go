@@ -0,0 +1,136 @@
+package api
+
+import (
+	"net/http"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+type accountSignatoryUri struct {
+	AccountID int64  `uri:"id" binding:"required,min=1"`
+	Username  string `uri:"username" binding:"required,alphanum"`
+}
+
+type addAccountSignatoryRequest struct {
+	Username string `json:"username" binding:"required,alphanum"`
+}
+
+// AddAccountSignatory - allow a user to approve the transfers of an account
+func (server *Server) AddAccountSignatory(ctx *gin.Context) {
+	var uri findAccountByIdRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req addAccountSignatoryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, valid := server.authorizedAccount(ctx, uri.ID, db.AccountPermissionManage); !valid {
+		return
+	}
+
+	signatory, err := server.store.CreateAccountSignatory(ctx, db.CreateAccountSignatoryParams{
+		AccountID: uri.ID,
+		Username:  req.Username,
+	})
+	if err != nil {
+		switch db.ErrorCode(err) {
+		case db.ForeignKeyViolation:
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		case db.UniqueViolation:
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, signatory)
+}
+
+// ListAccountSignatories - list the users allowed to approve the transfers of an account
+func (server *Server) ListAccountSignatories(ctx *gin.Context) {
+	var uri findAccountByIdRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, valid := server.authorizedAccount(ctx, uri.ID, db.AccountPermissionManage); !valid {
+		return
+	}
+
+	signatories, err := server.store.ListAccountSignatories(ctx, uri.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, signatories)
+}
+
+// RemoveAccountSignatory - stop a user from approving the transfers of an account
+func (server *Server) RemoveAccountSignatory(ctx *gin.Context) {
+	var uri accountSignatoryUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, valid := server.authorizedAccount(ctx, uri.AccountID, db.AccountPermissionManage); !valid {
+		return
+	}
+
+	err := server.store.DeleteAccountSignatory(ctx, db.DeleteAccountSignatoryParams{
+		AccountID: uri.AccountID,
+		Username:  uri.Username,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "signatory removed"})
+}
+
+type setApprovalPolicyRequest struct {
+	Threshold         int64 `json:"threshold" binding:"min=0"`
+	RequiredApprovals int32 `json:"required_approvals" binding:"required,min=1"`
+}
+
+// SetApprovalPolicy - require approvals from signatories for the transfers of an account above a threshold
+func (server *Server) SetApprovalPolicy(ctx *gin.Context) {
+	var uri findAccountByIdRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req setApprovalPolicyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, valid := server.authorizedAccount(ctx, uri.ID, db.AccountPermissionManage); !valid {
+		return
+	}
+
+	policy, err := server.store.UpsertApprovalPolicy(ctx, db.UpsertApprovalPolicyParams{
+		AccountID:         uri.ID,
+		Threshold:         req.Threshold,
+		RequiredApprovals: req.RequiredApprovals,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, policy)
+}
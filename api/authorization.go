@@ -0,0 +1,59 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"bitbucket.org/jessyw/go_simplebank/authz"
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/token"
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/gin-gonic/gin"
+)
+
+// authorizeAccount checks that the authenticated user can act on the account with the given permission,
+// as its owner or as one of its members. It writes the error response and returns false otherwise.
+func (server *Server) authorizeAccount(ctx *gin.Context, account db.Account, permission string) bool {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	err := authz.AuthorizeAccount(ctx, server.store, account, authPayload.Username, permission)
+	if err != nil {
+		if errors.Is(err, authz.ErrAccessDenied) {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	return true
+}
+
+// authorizedAccount finds an account the authenticated user can act on with the given permission
+func (server *Server) authorizedAccount(ctx *gin.Context, accountID int64, permission string) (db.Account, bool) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return account, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return account, false
+	}
+
+	return account, server.authorizeAccount(ctx, account, permission)
+}
+
+// authorizeAccountView checks that the authenticated user can read the account, as a banker or with the view permission.
+// It writes the error response and returns false otherwise.
+func (server *Server) authorizeAccountView(ctx *gin.Context, accountID int64) bool {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Role == util.BankerRole {
+		return true
+	}
+
+	_, ok := server.authorizedAccount(ctx, accountID, db.AccountPermissionView)
+	return ok
+}
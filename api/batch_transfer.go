@@ -0,0 +1,160 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/risk"
+	"bitbucket.org/jessyw/go_simplebank/token"
+	"github.com/gin-gonic/gin"
+)
+
+type batchTransferItemRequest struct {
+	ToAccountID int64 `json:"to_account_id" binding:"required,min=1"`
+	Amount      int64 `json:"amount" binding:"required,gt=0"`
+}
+
+type batchTransferRequest struct {
+	FromAccountID int64                      `json:"from_account_id" binding:"required,min=1"`
+	Currency      string                     `json:"currency" binding:"required,currency"`
+	AllOrNothing  bool                       `json:"all_or_nothing"`
+	Items         []batchTransferItemRequest `json:"items" binding:"required,min=1,max=100,dive"`
+	// MFACode is a two-factor authentication code, needed when the total is above the step-up amount
+	MFACode string `json:"mfa_code"`
+}
+
+// batchItemRejection explains why an item keeps the whole batch from being executed
+type batchItemRejection struct {
+	Index   int      `json:"index"`
+	Reasons []string `json:"reasons"`
+}
+
+// CreateBatchTransfer - send money from one account to many accounts at once, e.g. to pay salaries
+func (server *Server) CreateBatchTransfer(ctx *gin.Context) {
+	var req batchTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	if !server.authorizeAccount(ctx, fromAccount, db.AccountPermissionTransact) {
+		return
+	}
+
+	arg := db.BatchTransferTxParams{
+		FromAccountID: req.FromAccountID,
+		Items:         make([]db.BatchTransferItem, len(req.Items)),
+		AllOrNothing:  req.AllOrNothing,
+	}
+	for i, item := range req.Items {
+		arg.Items[i] = db.BatchTransferItem{
+			ToAccountID: item.ToAccountID,
+			Amount:      item.Amount,
+		}
+	}
+
+	if !server.verifyStepUp(ctx, arg.Total(), req.MFACode) {
+		return
+	}
+
+	if !server.screenBatchTransfer(ctx, fromAccount, arg) {
+		return
+	}
+
+	result, err := server.store.BatchTransferTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrBatchTransferFailed) {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error": err.Error(),
+				"items": result.Items,
+			})
+			return
+		}
+		if errors.Is(err, db.ErrAccountClosed) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// screenBatchTransfer runs every item through the risk screening and the approval policy of the source account
+// before anything is executed. Batches can't be held for review or approval as a whole, so the batch is refused
+// when an item would be, and those items have to be sent as individual transfers.
+// It writes the error response and returns false when the batch is refused.
+func (server *Server) screenBatchTransfer(ctx *gin.Context, fromAccount db.Account, arg db.BatchTransferTxParams) bool {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	policy, hasPolicy, err := db.FindApprovalPolicy(ctx, server.store, fromAccount.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	var denied, held []batchItemRejection
+	var recipients []int64
+	for i, item := range arg.Items {
+		if hasPolicy && policy.RequiresApproval(item.Amount) {
+			held = append(held, batchItemRejection{
+				Index:   i,
+				Reasons: []string{fmt.Sprintf("amount above the approval threshold of %d", policy.Threshold)},
+			})
+		}
+
+		screening, err := server.screener.Screen(ctx, risk.Transfer{
+			FromAccount: fromAccount,
+			// the recipient is validated by the batch transaction, rules only need its ID
+			ToAccount: db.Account{ID: item.ToAccountID},
+			Amount:    item.Amount,
+			Username:  authPayload.Username,
+			ClientIP:  clientIP(ctx),
+			UserAgent: ctx.Request.UserAgent(),
+			// the fan out counts the new recipients of the whole batch
+			BatchRecipients: slices.Clip(recipients),
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return false
+		}
+
+		switch screening.Decision {
+		case risk.DecisionDeny:
+			denied = append(denied, batchItemRejection{Index: i, Reasons: screening.Reasons})
+		case risk.DecisionReview:
+			held = append(held, batchItemRejection{Index: i, Reasons: screening.Reasons})
+		}
+
+		if !slices.Contains(recipients, item.ToAccountID) {
+			recipients = append(recipients, item.ToAccountID)
+		}
+	}
+
+	if len(denied) > 0 {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error": "batch denied by risk screening",
+			"items": denied,
+		})
+		return false
+	}
+
+	if len(held) > 0 {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "some items need a review or an approval, send them as individual transfers",
+			"items": held,
+		})
+		return false
+	}
+
+	return true
+}
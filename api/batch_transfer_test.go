@@ -0,0 +1,253 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "bitbucket.org/jessyw/go_simplebank/db/mock"
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/risk"
+	mockrisk "bitbucket.org/jessyw/go_simplebank/risk/mock"
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchTransferAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account1.Currency = util.USD
+
+	body := gin.H{
+		"from_account_id": account1.ID,
+		"currency":        util.USD,
+		"all_or_nothing":  true,
+		"items": []gin.H{
+			{"to_account_id": account1.ID + 1, "amount": 100},
+			{"to_account_id": account1.ID + 2, "amount": 200},
+		},
+	}
+	arg := db.BatchTransferTxParams{
+		FromAccountID: account1.ID,
+		Items: []db.BatchTransferItem{
+			{ToAccountID: account1.ID + 1, Amount: 100},
+			{ToAccountID: account1.ID + 2, Amount: 200},
+		},
+		AllOrNothing: true,
+	}
+	allowed := risk.Result{Decision: risk.DecisionAllow}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		username      string
+		buildStubs    func(store *mockdb.MockStore, screener *mockrisk.MockScreener)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			body:     body,
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore, screener *mockrisk.MockScreener) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(db.ApprovalPolicy{}, db.ErrRecordNotFound)
+				// each item is screened along with the recipients of the items before it
+				gomock.InOrder(
+					screener.EXPECT().Screen(gomock.Any(), gomock.Any()).Times(1).
+						DoAndReturn(func(_ context.Context, transfer risk.Transfer) (risk.Result, error) {
+							require.Equal(t, account1.ID+1, transfer.ToAccount.ID)
+							require.Empty(t, transfer.BatchRecipients)
+							return allowed, nil
+						}),
+					screener.EXPECT().Screen(gomock.Any(), gomock.Any()).Times(1).
+						DoAndReturn(func(_ context.Context, transfer risk.Transfer) (risk.Result, error) {
+							require.Equal(t, account1.ID+2, transfer.ToAccount.ID)
+							require.Equal(t, []int64{account1.ID + 1}, transfer.BatchRecipients)
+							return allowed, nil
+						}),
+				)
+
+				result := db.BatchTransferTxResult{
+					FromAccount: account1,
+					Items: []db.BatchTransferItemResult{
+						{ToAccountID: account1.ID + 1, Amount: 100, Status: db.BatchItemStatusCompleted},
+						{ToAccountID: account1.ID + 2, Amount: 200, Status: db.BatchItemStatusCompleted},
+					},
+					Completed: 2,
+				}
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp db.BatchTransferTxResult
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, 2, rsp.Completed)
+				require.Len(t, rsp.Items, 2)
+			},
+		},
+		{
+			name:     "ItemFailed",
+			body:     body,
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore, screener *mockrisk.MockScreener) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(db.ApprovalPolicy{}, db.ErrRecordNotFound)
+				screener.EXPECT().Screen(gomock.Any(), gomock.Any()).Times(2).Return(allowed, nil)
+
+				result := db.BatchTransferTxResult{
+					Items: []db.BatchTransferItemResult{
+						{ToAccountID: account1.ID + 1, Amount: 100, Status: db.BatchItemStatusCancelled},
+						{ToAccountID: account1.ID + 2, Amount: 200, Status: db.BatchItemStatusFailed, Error: "account not found"},
+					},
+					Failed: 1,
+				}
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, db.ErrBatchTransferFailed)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				require.Contains(t, recorder.Body.String(), db.BatchItemStatusCancelled)
+				require.Contains(t, recorder.Body.String(), "account not found")
+			},
+		},
+		{
+			name:     "AboveApprovalThreshold",
+			body:     body,
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore, screener *mockrisk.MockScreener) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Eq(account1.ID)).Times(1).
+					Return(db.ApprovalPolicy{AccountID: account1.ID, Threshold: 150, RequiredApprovals: 1}, nil)
+				screener.EXPECT().Screen(gomock.Any(), gomock.Any()).Times(2).Return(allowed, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+
+				var rsp struct {
+					Items []batchItemRejection `json:"items"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Len(t, rsp.Items, 1)
+				require.Equal(t, 1, rsp.Items[0].Index)
+			},
+		},
+		{
+			name:     "Denied",
+			body:     body,
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore, screener *mockrisk.MockScreener) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(db.ApprovalPolicy{}, db.ErrRecordNotFound)
+				screener.EXPECT().Screen(gomock.Any(), gomock.Any()).Times(1).Return(allowed, nil)
+				screener.EXPECT().Screen(gomock.Any(), gomock.Any()).Times(1).
+					Return(risk.Result{Decision: risk.DecisionDeny, Reasons: []string{"fan out"}}, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "fan out")
+			},
+		},
+		{
+			name:     "UnauthorizedUser",
+			body:     body,
+			username: user2.Username,
+			buildStubs: func(store *mockdb.MockStore, screener *mockrisk.MockScreener) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: account1.ID, Username: user2.Username})).
+					Times(1).
+					Return(db.AccountMember{}, db.ErrRecordNotFound)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "InternalError",
+			body:     body,
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore, screener *mockrisk.MockScreener) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(db.ApprovalPolicy{}, db.ErrRecordNotFound)
+				screener.EXPECT().Screen(gomock.Any(), gomock.Any()).Times(2).Return(allowed, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.BatchTransferTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "NoItems",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"currency":        util.USD,
+				"items":           []gin.H{},
+			},
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore, screener *mockrisk.MockScreener) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidItem",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"currency":        util.USD,
+				"items":           []gin.H{{"to_account_id": account1.ID + 1, "amount": -1}},
+			},
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore, screener *mockrisk.MockScreener) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			screener := mockrisk.NewMockScreener(ctrl)
+			tc.buildStubs(store, screener)
+
+			server := newTestServer(t, store)
+			server.screener = screener
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers/batch", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -1,12 +1,10 @@
 package api
 
 import (
-	"database/sql"
 	"errors"
 	"net/http"
 
 	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
-	"bitbucket.org/jessyw/go_simplebank/token"
 	"github.com/gin-gonic/gin"
 )
 
@@ -24,7 +22,7 @@ func (server *Server) CreateEntry(ctx *gin.Context) {
 
 	account, err := server.store.GetAccount(ctx, req.AccountID)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, db.ErrRecordNotFound) {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
 			return
 		}
@@ -32,10 +30,7 @@ func (server *Server) CreateEntry(ctx *gin.Context) {
 		return
 	}
 
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if account.Owner != authPayload.Username {
-		err := errors.New("account doesn't belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	if !server.authorizeAccount(ctx, account, db.AccountPermissionTransact) {
 		return
 	}
 
@@ -57,7 +52,7 @@ type findEntryByAccountIDEntryRequest struct {
 	ID int64 `uri:"id" binding:"required,min=1"`
 }
 
-// FindEntryByAccountID - find one entry from an account ID
+// FindEntryByAccountID - find one entry by its ID, for the bankers and the users who can view its account
 func (server *Server) FindEntryByAccountID(ctx *gin.Context) {
 	var req findEntryByAccountIDEntryRequest
 
@@ -68,7 +63,7 @@ func (server *Server) FindEntryByAccountID(ctx *gin.Context) {
 
 	entry, err := server.store.GetEntry(ctx, req.ID)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, db.ErrRecordNotFound) {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
 			return
 		}
@@ -76,10 +71,7 @@ func (server *Server) FindEntryByAccountID(ctx *gin.Context) {
 		return
 	}
 
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if authPayload.Username != "admin" {
-		err := errors.New("account doesn't belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	if !server.authorizeAccountView(ctx, entry.AccountID) {
 		return
 	}
 
@@ -92,7 +84,7 @@ type getListEntriesByIdRequest struct {
 	Limit  int32 `form:"limit" binding:"required,min=5,max=10"`
 }
 
-// GetEntriesListById - get a list of entries from an account ID
+// GetEntriesListById - get a list of entries from an account ID, for the bankers and the users who can view the account
 func (server *Server) GetEntriesListById(ctx *gin.Context) {
 	var req getListEntriesByIdRequest
 
@@ -101,10 +93,7 @@ func (server *Server) GetEntriesListById(ctx *gin.Context) {
 		return
 	}
 
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if authPayload.Username != "admin" {
-		err := errors.New("account doesn't belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	if !server.authorizeAccountView(ctx, req.ID) {
 		return
 	}
 
@@ -116,7 +105,7 @@ func (server *Server) GetEntriesListById(ctx *gin.Context) {
 
 	entries, err := server.store.ListEntries(ctx, arg)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, db.ErrRecordNotFound) {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
 			return
 		}
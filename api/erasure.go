@@ -0,0 +1,121 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/token"
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/gin-gonic/gin"
+)
+
+type eraseUserResponse struct {
+	User            userResponse     `json:"user"`
+	ClosedAccounts  []db.Account     `json:"closed_accounts"`
+	BlockedSessions int64            `json:"blocked_sessions"`
+	AuditLog        auditLogResponse `json:"audit_log"`
+}
+
+type auditLogResponse struct {
+	ID        int64           `json:"id"`
+	Action    string          `json:"action"`
+	Actor     string          `json:"actor"`
+	Subject   string          `json:"subject"`
+	Details   json.RawMessage `json:"details"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+func newAuditLogResponse(auditLog db.AuditLog) auditLogResponse {
+	return auditLogResponse{
+		ID:        auditLog.ID,
+		Action:    auditLog.Action,
+		Actor:     auditLog.Actor,
+		Subject:   auditLog.Subject,
+		Details:   auditLog.Details,
+		CreatedAt: auditLog.CreatedAt,
+	}
+}
+
+// EraseUser - erase the personal data of a user, for the user or a banker.
+// The accounts of the user must all have a zero balance, they are closed but their ledger is kept.
+func (server *Server) EraseUser(ctx *gin.Context) {
+	var req findUserByNameRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if req.Name != authPayload.Username && authPayload.Role != util.BankerRole {
+		err := errors.New("user doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.EraseUserTx(ctx, db.EraseUserTxParams{
+		Username: req.Name,
+		ErasedBy: authPayload.Username,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrRecordNotFound):
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		case errors.Is(err, db.ErrUserErased):
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		case errors.Is(err, db.ErrAccountHasBalance):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	rsp := eraseUserResponse{
+		User:            newUserResponse(result.User),
+		ClosedAccounts:  result.ClosedAccounts,
+		BlockedSessions: result.BlockedSessions,
+		AuditLog:        newAuditLogResponse(result.AuditLog),
+	}
+	if rsp.ClosedAccounts == nil {
+		rsp.ClosedAccounts = []db.Account{}
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type listAuditLogsRequest struct {
+	Subject string `form:"subject" binding:"required"`
+	Offset  int32  `form:"offset" binding:"required,min=1"`
+	Limit   int32  `form:"limit" binding:"required,min=5,max=10"`
+}
+
+// ListAuditLogs - list the audit trail of a subject, such as the erasure of a user, oldest first
+func (server *Server) ListAuditLogs(ctx *gin.Context) {
+	var req listAuditLogsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.ListAuditLogsParams{
+		Subject: req.Subject,
+		Limit:   req.Limit,
+		Offset:  (req.Offset - 1) * req.Limit,
+	}
+
+	auditLogs, err := server.store.ListAuditLogs(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]auditLogResponse, len(auditLogs))
+	for i, auditLog := range auditLogs {
+		rsp[i] = newAuditLogResponse(auditLog)
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/token"
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/gin-gonic/gin"
+)
+
+// ExportUserData - download a ZIP archive of everything the bank holds about a user, for the user or a banker.
+// The archive is streamed, an error once it started leaves it incomplete.
+func (server *Server) ExportUserData(ctx *gin.Context) {
+	var req findUserByNameRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if req.Name != authPayload.Username && authPayload.Role != util.BankerRole {
+		err := errors.New("user doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	header := ctx.Writer.Header()
+	header.Set("Content-Type", "application/zip")
+	header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, req.Name))
+
+	err := server.exporter.WriteZip(ctx, req.Name, ctx.Writer)
+	if err == nil {
+		return
+	}
+
+	if ctx.Writer.Written() {
+		slog.ErrorContext(ctx, "cannot export user data", slog.String("username", req.Name), slog.Any("error", err))
+		ctx.Abort()
+		return
+	}
+
+	header.Del("Content-Type")
+	header.Del("Content-Disposition")
+	if errors.Is(err, db.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "bitbucket.org/jessyw/go_simplebank/db/mock"
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/token"
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportUserDataAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	banker, _ := randomUser(t)
+
+	buildExportStubs := func(store *mockdb.MockStore) {
+		expectReadOnlyTx(store)
+		store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+		store.EXPECT().ExportSessions(gomock.Any(), gomock.Any()).Times(1).Return([]db.Session{}, nil)
+		store.EXPECT().ExportAccounts(gomock.Any(), gomock.Any()).Times(1).Return([]db.Account{randomAccount(user.Username)}, nil)
+		store.EXPECT().ExportAccountMembers(gomock.Any(), gomock.Any()).Times(1).Return([]db.AccountMember{}, nil)
+		store.EXPECT().ExportAccountSignatories(gomock.Any(), gomock.Any()).Times(1).Return([]db.AccountSignatory{}, nil)
+		store.EXPECT().ExportEntries(gomock.Any(), gomock.Any()).Times(1).Return([]db.Entry{}, nil)
+		store.EXPECT().ExportTransfers(gomock.Any(), gomock.Any()).Times(1).Return([]db.Transfer{}, nil)
+		store.EXPECT().ExportTransferRequests(gomock.Any(), gomock.Any()).Times(1).Return([]db.TransferRequest{}, nil)
+		store.EXPECT().ExportTransferApprovals(gomock.Any(), gomock.Any()).Times(1).Return([]db.TransferApproval{}, nil)
+		store.EXPECT().ExportTransferReviews(gomock.Any(), gomock.Any()).Times(1).Return([]db.TransferReview{}, nil)
+		store.EXPECT().ExportAuditLogs(gomock.Any(), gomock.Any()).Times(1).Return([]db.AuditLog{}, nil)
+		store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.TotpSecret{}, db.ErrRecordNotFound)
+		store.EXPECT().ExportPasswordResets(gomock.Any(), gomock.Any()).Times(1).Return([]db.PasswordReset{}, nil)
+	}
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: buildExportStubs,
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "application/zip", recorder.Header().Get("Content-Type"))
+				require.Contains(t, recorder.Header().Get("Content-Disposition"), user.Username+".zip")
+
+				archive, err := zip.NewReader(bytes.NewReader(recorder.Body.Bytes()), int64(recorder.Body.Len()))
+				require.NoError(t, err)
+
+				var names []string
+				for _, file := range archive.File {
+					names = append(names, file.Name)
+				}
+				require.Equal(t, []string{
+					"user.json", "sessions.json", "accounts.json", "memberships.json", "signatories.json",
+					"entries.json", "transfers.json", "transfer_requests.json", "transfer_approvals.json",
+					"transfer_reviews.json", "audit_logs.json", "mfa.json", "password_resets.json",
+				}, names)
+			},
+		},
+		{
+			name: "Banker",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, util.BankerRole, time.Minute)
+			},
+			buildStubs: buildExportStubs,
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ReadOnlyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectReadOnlyTx(store)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.User{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				require.Contains(t, recorder.Header().Get("Content-Type"), "application/json")
+				require.Empty(t, recorder.Header().Get("Content-Disposition"))
+			},
+		},
+		{
+			name: "InternalError",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectReadOnlyTx(store)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.User{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/users/%s/export", user.Username)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+// expectReadOnlyTx runs the function given to ReadOnlyTx with the store itself
+func expectReadOnlyTx(store *mockdb.MockStore) {
+	store.EXPECT().
+		ReadOnlyTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, fn func(db.Querier) error) error {
+			return fn(store)
+		})
+}
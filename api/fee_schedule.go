@@ -0,0 +1,183 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type transferFeeResponse struct {
+	Amount   int64  `json:"amount"`
+	Fee      int64  `json:"fee"`
+	Total    int64  `json:"total"`
+	Currency string `json:"currency"`
+}
+
+// PreviewTransferFee - return the fee a transfer would be charged, without executing it
+func (server *Server) PreviewTransferFee(ctx *gin.Context) {
+	var req transferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	if !server.authorizeAccount(ctx, fromAccount, db.AccountPermissionTransact) {
+		return
+	}
+
+	if _, valid := server.validAccount(ctx, req.ToAccountID, req.Currency); !valid {
+		return
+	}
+
+	fee, err := db.TransferFee(ctx, server.store, req.Currency, req.Amount)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transferFeeResponse{
+		Amount:   req.Amount,
+		Fee:      fee,
+		Total:    req.Amount + fee,
+		Currency: req.Currency,
+	})
+}
+
+// ListFeeSchedules - list the fee schedule of every currency that charges transfer fees
+func (server *Server) ListFeeSchedules(ctx *gin.Context) {
+	schedules, err := server.store.ListFeeSchedules(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, schedules)
+}
+
+type feeScheduleUri struct {
+	Currency string `uri:"currency" binding:"required,currency"`
+}
+
+type feeTierRequest struct {
+	MinAmount int64 `json:"min_amount" binding:"min=0"`
+	FlatFee   int64 `json:"flat_fee" binding:"min=0"`
+	RateBps   int64 `json:"rate_bps" binding:"min=0,max=10000"`
+}
+
+type setFeeScheduleRequest struct {
+	Kind    string           `json:"kind" binding:"required,oneof=flat percentage tiered"`
+	FlatFee int64            `json:"flat_fee" binding:"min=0"`
+	RateBps int64            `json:"rate_bps" binding:"min=0,max=10000"`
+	MinFee  int64            `json:"min_fee" binding:"min=0"`
+	MaxFee  *int64           `json:"max_fee"`
+	Tiers   []feeTierRequest `json:"tiers" binding:"dive"`
+}
+
+// SetFeeSchedule - create or replace the transfer fee schedule of a currency
+func (server *Server) SetFeeSchedule(ctx *gin.Context) {
+	var uri feeScheduleUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req setFeeScheduleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := validateFeeSchedule(req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.SetFeeScheduleTxParams{
+		UpsertFeeScheduleParams: db.UpsertFeeScheduleParams{
+			Currency: uri.Currency,
+			Kind:     req.Kind,
+			FlatFee:  req.FlatFee,
+			RateBps:  req.RateBps,
+			MinFee:   req.MinFee,
+		},
+		Tiers: []db.CreateFeeTierParams{},
+	}
+	if req.MaxFee != nil {
+		arg.MaxFee = pgtype.Int8{Int64: *req.MaxFee, Valid: true}
+	}
+	for _, tier := range req.Tiers {
+		arg.Tiers = append(arg.Tiers, db.CreateFeeTierParams{
+			MinAmount: tier.MinAmount,
+			FlatFee:   tier.FlatFee,
+			RateBps:   tier.RateBps,
+		})
+	}
+
+	result, err := server.store.SetFeeScheduleTx(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+func validateFeeSchedule(req setFeeScheduleRequest) error {
+	if req.MaxFee != nil && *req.MaxFee < req.MinFee {
+		return errors.New("max_fee must be greater than or equal to min_fee")
+	}
+
+	switch req.Kind {
+	case db.FeeKindFlat:
+		if req.RateBps != 0 {
+			return errors.New("rate_bps is only used by percentage schedules")
+		}
+	case db.FeeKindTiered:
+		if len(req.Tiers) == 0 {
+			return errors.New("tiered schedules need at least one tier")
+		}
+		if req.FlatFee != 0 || req.RateBps != 0 {
+			return errors.New("tiered schedules set flat_fee and rate_bps on their tiers")
+		}
+
+		minAmounts := make(map[int64]bool, len(req.Tiers))
+		for _, tier := range req.Tiers {
+			if minAmounts[tier.MinAmount] {
+				return fmt.Errorf("more than one tier starts at %d", tier.MinAmount)
+			}
+			minAmounts[tier.MinAmount] = true
+		}
+		return nil
+	}
+
+	if len(req.Tiers) > 0 {
+		return errors.New("tiers are only used by tiered schedules")
+	}
+	return nil
+}
+
+// DeleteFeeSchedule - stop charging fees on the transfers of a currency
+func (server *Server) DeleteFeeSchedule(ctx *gin.Context) {
+	var uri feeScheduleUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	err := server.store.DeleteFeeSchedule(ctx, uri.Currency)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, nil)
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/importer"
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportFileSize bounds the size of an uploaded statement
+const maxImportFileSize = 10 << 20
+
+type importEntriesRequest struct {
+	// Format defaults to the extension of the file name
+	Format string `form:"format" binding:"omitempty,oneof=csv ofx"`
+}
+
+// ImportEntries - record the transactions of an uploaded CSV or OFX statement on an account.
+// The upload is a multipart form with the statement in its file field, the response reports every row.
+func (server *Server) ImportEntries(ctx *gin.Context) {
+	var uri findAccountByIdRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportFileSize)
+
+	var req importEntriesRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	format := req.Format
+	if format == "" {
+		format = strings.ToLower(strings.TrimPrefix(filepath.Ext(fileHeader.Filename), "."))
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	defer file.Close()
+
+	report, err := server.importer.Import(ctx, uri.ID, format, file)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrRecordNotFound):
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		case errors.Is(err, importer.ErrUnknownFormat),
+			errors.Is(err, importer.ErrInvalidFile),
+			errors.Is(err, importer.ErrCurrencyMismatch),
+			errors.Is(err, db.ErrAccountClosed):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+// ListAccountProducts - list the account products and their annual interest rate
+func (server *Server) ListAccountProducts(ctx *gin.Context) {
+	products, err := server.store.ListAccountProducts(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, products)
+}
+
+type listInterestPostingsRequest struct {
+	Offset int32 `form:"offset" binding:"required,min=1"`
+	Limit  int32 `form:"limit" binding:"required,min=5,max=10"`
+}
+
+// ListInterestPostings - list the monthly interest paid to an account, most recent first
+func (server *Server) ListInterestPostings(ctx *gin.Context) {
+	var uri findAccountByIdRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req listInterestPostingsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, ok := server.authorizedAccount(ctx, uri.ID, db.AccountPermissionView); !ok {
+		return
+	}
+
+	arg := db.ListInterestPostingsParams{
+		AccountID: uri.ID,
+		Limit:     req.Limit,
+		Offset:    (req.Offset - 1) * req.Limit,
+	}
+
+	postings, err := server.store.ListInterestPostings(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, postings)
+}
+
+type runInterestRequest struct {
+	Date string `json:"date" binding:"required,datetime=2006-01-02"`
+}
+
+// RunInterest - accrue the interest of a day again, and post the month if it is the last day of a month.
+// Nothing is accrued or posted twice, it only catches up on a day the scheduled run missed.
+func (server *Server) RunInterest(ctx *gin.Context) {
+	var req runInterestRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the date binding already checked the layout
+	day, _ := time.Parse(time.DateOnly, req.Date)
+
+	result, err := server.interestJob.Run(ctx, day)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
@@ -5,9 +5,14 @@ import (
 	"testing"
 	"time"
 
+	mockdb "bitbucket.org/jessyw/go_simplebank/db/mock"
 	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/event"
+	"bitbucket.org/jessyw/go_simplebank/mail"
+	"bitbucket.org/jessyw/go_simplebank/risk"
 	"bitbucket.org/jessyw/go_simplebank/util"
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -16,11 +21,23 @@ func newTestServer(
 	store db.Store,
 ) *Server {
 	config := util.Config{
-		TokenSymmetricKey:   util.RandomString(32),
-		AccessTokenDuration: time.Minute,
+		TokenSymmetricKey:    util.RandomString(32),
+		AccessTokenDuration:  time.Minute,
+		LoginMaxFailures:     5,
+		LoginIPMaxFailures:   20,
+		LoginLockoutDuration: 15 * time.Minute,
+		LoginBaseDelay:       time.Second,
+
+		PasswordMinLength:           8,
+		PasswordMinCharacterClasses: 3,
+	}
+
+	// the users of the tests are not erased, unless a test expects otherwise first
+	if mockStore, ok := store.(*mockdb.MockStore); ok {
+		mockStore.EXPECT().IsUserErased(gomock.Any(), gomock.Any()).AnyTimes().Return(false, nil)
 	}
 
-	server, err := NewServer(config, store)
+	server, err := NewServer(config, store, event.NewMemoryBroker(), risk.NewNopScreener(), mail.NewMemoryMailer())
 	require.NoError(t, err)
 
 	return server
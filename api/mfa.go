@@ -0,0 +1,215 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/lockout"
+	"bitbucket.org/jessyw/go_simplebank/mfa"
+	"bitbucket.org/jessyw/go_simplebank/token"
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/gin-gonic/gin"
+)
+
+// EnrollMFA - start the enrollment of the authenticated user in two-factor authentication.
+// The secret and its provisioning URI are returned, the enrollment is confirmed with EnableMFA.
+func (server *Server) EnrollMFA(ctx *gin.Context) {
+	var req findUserByNameRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !authorizeSelf(ctx, req.Name) {
+		return
+	}
+
+	enrollment, err := server.authenticator.Enroll(ctx, req.Name)
+	if err != nil {
+		if errors.Is(err, mfa.ErrAlreadyEnabled) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, enrollment)
+}
+
+type mfaCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type enableMFAResponse struct {
+	// RecoveryCodes can each be used once instead of a code from the authenticator app, they aren't shown again
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// EnableMFA - confirm the enrollment of the authenticated user with a first code from their authenticator app
+func (server *Server) EnableMFA(ctx *gin.Context) {
+	var uri findUserByNameRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req mfaCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !authorizeSelf(ctx, uri.Name) {
+		return
+	}
+
+	codes, err := server.authenticator.Enable(ctx, uri.Name, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrMFANotPending), errors.Is(err, mfa.ErrAlreadyEnabled):
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		case errors.Is(err, mfa.ErrInvalidCode):
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, enableMFAResponse{RecoveryCodes: codes})
+}
+
+// DisableMFA - turn off two-factor authentication. Users give a code to turn off their own,
+// bankers can turn off the one of a user who lost their authenticator app and recovery codes.
+func (server *Server) DisableMFA(ctx *gin.Context) {
+	var uri findUserByNameRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	switch {
+	case uri.Name == authPayload.Username:
+		var req mfaCodeRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+
+		err := server.authenticator.Verify(ctx, uri.Name, req.Code)
+		if err != nil {
+			switch {
+			case errors.Is(err, mfa.ErrNotEnabled):
+				ctx.JSON(http.StatusConflict, errorResponse(err))
+			case errors.Is(err, mfa.ErrInvalidCode):
+				ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			default:
+				ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			}
+			return
+		}
+	case authPayload.Role == util.BankerRole:
+		enabled, err := server.authenticator.Enabled(ctx, uri.Name)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if !enabled {
+			ctx.JSON(http.StatusConflict, errorResponse(mfa.ErrNotEnabled))
+			return
+		}
+	default:
+		err := errors.New("user doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	err := server.authenticator.Disable(ctx, uri.Name, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "two-factor authentication is disabled"})
+}
+
+// verifyStepUp asks the authenticated user for a two-factor authentication code when the amount is above the
+// step-up amount. Users who didn't enable two-factor authentication can't move such amounts.
+// Wrong codes count as failed logins, so they are throttled and locked out like passwords.
+// It writes the error response and returns false when the code is missing or wrong.
+func (server *Server) verifyStepUp(ctx *gin.Context, amount int64, code string) bool {
+	if server.config.MFAStepUpAmount <= 0 || amount <= server.config.MFAStepUpAmount {
+		return true
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	err := server.guard.Check(ctx, authPayload.Username, clientIP(ctx))
+	if err != nil {
+		var throttled *lockout.ThrottledError
+		if errors.As(err, &throttled) {
+			ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+			ctx.JSON(http.StatusTooManyRequests, errorResponse(err))
+			return false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	err = server.authenticator.Verify(ctx, authPayload.Username, code)
+	if err != nil {
+		switch {
+		case errors.Is(err, mfa.ErrNotEnabled):
+			if !server.releaseStepUp(ctx, authPayload.Username) {
+				return false
+			}
+			err = fmt.Errorf("transfers above %d need two-factor authentication: %w", server.config.MFAStepUpAmount, err)
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+		case errors.Is(err, mfa.ErrInvalidCode):
+			// a missing code only tells the client that one is needed, a wrong one keeps the attempt reserved by Check
+			if code == "" && !server.releaseStepUp(ctx, authPayload.Username) {
+				return false
+			}
+			err = fmt.Errorf("transfers above %d need a two-factor authentication code: %w", server.config.MFAStepUpAmount, err)
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return false
+	}
+
+	err = server.guard.Succeed(ctx, authPayload.Username, clientIP(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	return true
+}
+
+// releaseStepUp gives back the attempt reserved for a step-up when no code was checked.
+// It writes the error response and returns false when it can't.
+func (server *Server) releaseStepUp(ctx *gin.Context, username string) bool {
+	err := server.guard.Release(ctx, username, clientIP(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	return true
+}
+
+// authorizeSelf writes the error response and returns false when username isn't the authenticated user
+func authorizeSelf(ctx *gin.Context, username string) bool {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if username != authPayload.Username {
+		err := errors.New("user doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return false
+	}
+	return true
+}
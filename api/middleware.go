@@ -3,13 +3,29 @@ package api
 import (
 	"errors"
 	"fmt"
+	"log/slog"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/logging"
+	"bitbucket.org/jessyw/go_simplebank/metrics"
+	"bitbucket.org/jessyw/go_simplebank/ratelimit"
 	"bitbucket.org/jessyw/go_simplebank/token"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const tracerName = "bitbucket.org/jessyw/go_simplebank/api"
+
 const (
 	authorizationHeaderKey  = "authorization"
 	authorizationTypeBearer = "bearer"
@@ -17,7 +33,7 @@ const (
 )
 
 // AuthMidleware create a gin middleware for authorization
-func AuthMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+func AuthMiddleware(tokenMaker token.Maker, store db.Store) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
 		if len(authorizationHeader) == 0 || authorizationHeader == "" {
@@ -47,7 +63,172 @@ func AuthMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 			return
 		}
 
+		// the access tokens of an erased user stay valid until they expire, the user is checked instead
+		erased, err := store.IsUserErased(ctx, payload.Username)
+		if err != nil {
+			if errors.Is(err, db.ErrRecordNotFound) {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if erased {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(db.ErrUserErased))
+			return
+		}
+
 		ctx.Set(authorizationPayloadKey, payload)
+		logging.SetUser(ctx, payload.Username)
+		ctx.Next()
+	}
+}
+
+// RequestLoggerMiddleware create a gin middleware giving each request an ID and logging it once served.
+// The ID given by the caller in the x-request-id header is kept, and sent back in the response.
+func RequestLoggerMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		startTime := time.Now()
+		requestID := logging.NewRequestID(ctx.GetHeader(logging.RequestIDHeader))
+		ctx.Request = ctx.Request.WithContext(logging.WithRequest(ctx.Request.Context(), requestID))
+		ctx.Header(logging.RequestIDHeader, requestID)
+
+		ctx.Next()
+
+		statusCode := ctx.Writer.Status()
+		level := slog.LevelInfo
+		if statusCode >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		attrs := []slog.Attr{
+			slog.String("protocol", "http"),
+			slog.String("method", ctx.Request.Method),
+			slog.String("path", ctx.Request.URL.Path),
+			slog.Int("status_code", statusCode),
+			slog.Duration("duration", time.Since(startTime)),
+			slog.String("client_ip", ctx.ClientIP()),
+		}
+		if len(ctx.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", ctx.Errors.String()))
+		}
+
+		slog.LogAttrs(ctx.Request.Context(), level, "received an HTTP request", attrs...)
+	}
+}
+
+// MetricsMiddleware create a gin middleware counting the requests and observing their latency, by route
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		startTime := time.Now()
+		ctx.Next()
+
+		method := ctx.Request.Method + " " + routeOf(ctx)
+		metrics.ObserveRequest("http", method, strconv.Itoa(ctx.Writer.Status()), time.Since(startTime))
+	}
+}
+
+// routeOf returns the route pattern of the request, which keeps the number of metric series bounded, unlike the path
+func routeOf(ctx *gin.Context) string {
+	if route := ctx.FullPath(); route != "" {
+		return route
+	}
+	return "unmatched"
+}
+
+// TracingMiddleware create a gin middleware recording a span for each request,
+// in the trace of the traceparent header when the caller sent one
+func TracingMiddleware() gin.HandlerFunc {
+	tracer := otel.Tracer(tracerName)
+	return func(ctx *gin.Context) {
+		route := routeOf(ctx)
+		parent := otel.GetTextMapPropagator().Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+		spanCtx, span := tracer.Start(parent, ctx.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(ctx.Request.Method),
+				semconv.HTTPRoute(route),
+				semconv.ClientAddress(ctx.ClientIP()),
+			),
+		)
+		defer span.End()
+
+		ctx.Request = ctx.Request.WithContext(spanCtx)
 		ctx.Next()
+
+		statusCode := ctx.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(statusCode))
+		if statusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(statusCode))
+		}
+	}
+}
+
+// RoleMiddleware create a gin middleware only letting through users with one of the given roles.
+// It must run after AuthMiddleware.
+func RoleMiddleware(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+		for _, role := range roles {
+			if authPayload.Role == role {
+				ctx.Next()
+				return
+			}
+		}
+
+		err := fmt.Errorf("role %s is not allowed to access this resource", authPayload.Role)
+		ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+	}
+}
+
+// RateLimitMiddleware create a gin middleware taking each request from the rate limit budget chosen by budgetOf.
+// Requests are counted by user when AuthMiddleware ran before, and by the host of the peer address otherwise.
+func RateLimitMiddleware(limiter *ratelimit.Limiter, budgetOf func(ctx *gin.Context) string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ratelimit.IPKey(clientIP(ctx))
+		if payload, ok := ctx.Get(authorizationPayloadKey); ok {
+			key = ratelimit.UserKey(payload.(*token.Payload).Username)
+		}
+
+		err := limiter.Allow(ctx, budgetOf(ctx), key)
+		if err != nil {
+			var limited *ratelimit.LimitedError
+			if errors.As(err, &limited) {
+				ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+				ctx.AbortWithStatusJSON(http.StatusTooManyRequests, errorResponse(err))
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// budget always chooses the same rate limit budget
+func budget(name string) func(ctx *gin.Context) string {
+	return func(ctx *gin.Context) string {
+		return name
+	}
+}
+
+// readOrWriteBudget chooses the read budget for the requests that only read data, and the write budget for the others
+func readOrWriteBudget(ctx *gin.Context) string {
+	switch ctx.Request.Method {
+	case http.MethodGet, http.MethodHead:
+		return ratelimit.BudgetRead
+	default:
+		return ratelimit.BudgetWrite
+	}
+}
+
+// clientIP returns the host of the peer that sent the request. The X-Forwarded-For and X-Real-IP headers come from the
+// client itself, they are never trusted to key the failed logins and the budgets or to recognize a device.
+func clientIP(ctx *gin.Context) string {
+	host, _, err := net.SplitHostPort(ctx.Request.RemoteAddr)
+	if err != nil {
+		return ctx.Request.RemoteAddr
 	}
+	return host
 }
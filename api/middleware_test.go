@@ -7,13 +7,25 @@ import (
 	"testing"
 	"time"
 
+	mockdb "bitbucket.org/jessyw/go_simplebank/db/mock"
+	"bitbucket.org/jessyw/go_simplebank/logging"
+	"bitbucket.org/jessyw/go_simplebank/metrics"
+	"bitbucket.org/jessyw/go_simplebank/ratelimit"
 	"bitbucket.org/jessyw/go_simplebank/token"
+	"bitbucket.org/jessyw/go_simplebank/util"
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
-func addAuthorization(t *testing.T, request *http.Request, tokenMaker token.Maker, authorizationType string, username string, duration time.Duration) {
-	token, payload, err := tokenMaker.CreateToken(username, duration)
+func addAuthorization(t *testing.T, request *http.Request, tokenMaker token.Maker, authorizationType string, username string, role string, duration time.Duration) {
+	token, payload, err := tokenMaker.CreateToken(username, role, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, payload)
 
@@ -25,12 +37,13 @@ func TestAuthMiddleware(t *testing.T) {
 	testsCases := []struct {
 		name          string
 		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
 			name: "OK",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", util.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -47,7 +60,7 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			name: "UnsupportedAuthorization",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, "unsupported", "user", time.Minute)
+				addAuthorization(t, request, tokenMaker, "unsupported", "user", util.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -56,7 +69,19 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			name: "InvalidAuthorizationFormat",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, "", "user", time.Minute)
+				addAuthorization(t, request, tokenMaker, "", "user", util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ErasedUser",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().IsUserErased(gomock.Any(), gomock.Eq("user")).Times(1).Return(true, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -65,7 +90,7 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			name: "ExpiredToken",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", -time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", util.DepositorRole, -time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -77,12 +102,18 @@ func TestAuthMiddleware(t *testing.T) {
 		tc := testsCases[i]
 
 		t.Run(tc.name, func(t *testing.T) {
-			server := newTestServer(t, nil)
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			if tc.buildStubs != nil {
+				tc.buildStubs(store)
+			}
+
+			server := newTestServer(t, store)
 
 			authPath := "/auth"
 			server.router.GET(
 				authPath,
-				AuthMiddleware(server.tokenMaker),
+				AuthMiddleware(server.tokenMaker, server.store),
 				func(ctx *gin.Context) {
 					ctx.JSON(http.StatusOK, gin.H{})
 				},
@@ -98,3 +129,161 @@ func TestAuthMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestRateLimitMiddleware(t *testing.T) {
+	server := newTestServer(t, mockdb.NewMockStore(gomock.NewController(t)))
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), map[string]ratelimit.Limit{
+		ratelimit.BudgetRead: {Requests: 2, Period: time.Minute},
+	})
+
+	limitedPath := "/limited"
+	server.router.GET(
+		limitedPath,
+		AuthMiddleware(server.tokenMaker, server.store),
+		RateLimitMiddleware(limiter, readOrWriteBudget),
+		func(ctx *gin.Context) {
+			ctx.JSON(http.StatusOK, gin.H{})
+		},
+	)
+
+	get := func(username string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, limitedPath, nil)
+		require.NoError(t, err)
+
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, username, util.DepositorRole, time.Minute)
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	require.Equal(t, http.StatusOK, get("user").Code)
+	require.Equal(t, http.StatusOK, get("user").Code)
+
+	recorder := get("user")
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Equal(t, "30", recorder.Header().Get("Retry-After"))
+
+	// every user has their own budget
+	require.Equal(t, http.StatusOK, get("other").Code)
+}
+
+func TestRateLimitMiddlewareClientIP(t *testing.T) {
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), map[string]ratelimit.Limit{
+		ratelimit.BudgetLogin: {Requests: 1, Period: time.Minute},
+	})
+
+	// a bare engine trusts every proxy, the middleware must not depend on the router settings
+	router := gin.New()
+	limitedPath := "/limited"
+	router.POST(limitedPath, RateLimitMiddleware(limiter, budget(ratelimit.BudgetLogin)), func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{})
+	})
+
+	post := func(remoteAddr string, forwardedFor string) int {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodPost, limitedPath, nil)
+		require.NoError(t, err)
+
+		request.RemoteAddr = remoteAddr
+		request.Header.Set("X-Forwarded-For", forwardedFor)
+		request.Header.Set("X-Real-IP", forwardedFor)
+		router.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	require.Equal(t, http.StatusOK, post("10.0.0.1:1234", "203.0.113.1"))
+
+	// a new forwarded address and a new port don't give a new budget
+	require.Equal(t, http.StatusTooManyRequests, post("10.0.0.1:1235", "203.0.113.2"))
+
+	require.Equal(t, http.StatusOK, post("10.0.0.2:1234", "203.0.113.2"))
+}
+
+func TestRequestLoggerMiddleware(t *testing.T) {
+	server := newTestServer(t, mockdb.NewMockStore(gomock.NewController(t)))
+
+	var requestID, user string
+	loggedPath := "/logged"
+	server.router.GET(
+		loggedPath,
+		AuthMiddleware(server.tokenMaker, server.store),
+		func(ctx *gin.Context) {
+			requestID = logging.RequestID(ctx)
+			user = logging.User(ctx)
+			ctx.JSON(http.StatusOK, gin.H{})
+		},
+	)
+
+	get := func(givenID string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, loggedPath, nil)
+		require.NoError(t, err)
+		if givenID != "" {
+			request.Header.Set(logging.RequestIDHeader, givenID)
+		}
+
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "user", util.DepositorRole, time.Minute)
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// the request ID given by the caller is kept
+	recorder := get("given-id")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "given-id", recorder.Header().Get(logging.RequestIDHeader))
+	require.Equal(t, "given-id", requestID)
+	require.Equal(t, "user", user)
+
+	// a new one is generated otherwise
+	recorder = get("")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NotEmpty(t, requestID)
+	require.NotEqual(t, "given-id", requestID)
+	require.Equal(t, requestID, recorder.Header().Get(logging.RequestIDHeader))
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/users/someone", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, "/metrics", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	// the metrics are only served by the metrics server, not by the public HTTP API
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// requests are counted by route, not by path
+	require.Contains(t, recorder.Body.String(), `simplebank_requests_total{code="401",method="GET /users/:name",protocol="http"}`)
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	server := newTestServer(t, nil)
+
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	request, err := http.NewRequest(http.MethodGet, "/users/someone", nil)
+	require.NoError(t, err)
+	request.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	server.router.ServeHTTP(httptest.NewRecorder(), request)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "GET /users/:name", spans[0].Name())
+	require.Equal(t, traceID, spans[0].SpanContext().TraceID().String())
+	require.Contains(t, spans[0].Attributes(), semconv.HTTPResponseStatusCode(http.StatusUnauthorized))
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/validator"
+	"github.com/gin-gonic/gin"
+)
+
+type requestPasswordResetRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// RequestPasswordReset - email a single-use token to reset the password of the user with this email.
+// The response is the same whether the email is registered or not.
+func (server *Server) RequestPasswordReset(ctx *gin.Context) {
+	var req requestPasswordResetRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	err := server.recoverer.RequestPasswordReset(ctx, req.Email)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message": "if the email is registered, a password reset token was sent to it",
+	})
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// ResetPassword - set a new password with a token sent by RequestPasswordReset, every session of the user is blocked
+func (server *Server) ResetPassword(ctx *gin.Context) {
+	var req resetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	result, err := server.recoverer.ResetPassword(ctx, req.Token, req.Password)
+	if err != nil {
+		if errors.Is(err, db.ErrPasswordResetInvalid) {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+		var passwordErr *validator.PasswordError
+		if errors.As(err, &passwordErr) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("invalid password: %w", err)))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(result.User))
+}
@@ -2,33 +2,84 @@ package api
 
 import (
 	"fmt"
+	"net/http"
+	"sync"
 
 	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/event"
+	"bitbucket.org/jessyw/go_simplebank/export"
+	"bitbucket.org/jessyw/go_simplebank/importer"
+	"bitbucket.org/jessyw/go_simplebank/interest"
+	"bitbucket.org/jessyw/go_simplebank/lockout"
+	"bitbucket.org/jessyw/go_simplebank/mail"
+	"bitbucket.org/jessyw/go_simplebank/mfa"
+	"bitbucket.org/jessyw/go_simplebank/ratelimit"
+	"bitbucket.org/jessyw/go_simplebank/recovery"
+	"bitbucket.org/jessyw/go_simplebank/risk"
 	"bitbucket.org/jessyw/go_simplebank/token"
 	"bitbucket.org/jessyw/go_simplebank/util"
+	bankvalidator "bitbucket.org/jessyw/go_simplebank/validator"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 )
 
 type Server struct {
-	config     util.Config
-	store      db.Store
-	tokenMaker token.Maker
-	router     *gin.Engine
+	config         util.Config
+	store          db.Store
+	tokenMaker     token.Maker
+	broker         event.Broker
+	screener       risk.Screener
+	interestJob    *interest.Job
+	importer       *importer.Importer
+	exporter       *export.Exporter
+	recoverer      *recovery.Recoverer
+	authenticator  *mfa.Authenticator
+	guard          *lockout.Guard
+	passwordPolicy bankvalidator.PasswordPolicy
+	limiter        *ratelimit.Limiter
+	router         *gin.Engine
+	shutdown       chan struct{}
+	shutdownOnce   sync.Once
 }
 
 // NewServer create a new HTTP server an setup routing.
-func NewServer(config util.Config, store db.Store) (*Server, error) {
+func NewServer(config util.Config, store db.Store, broker event.Broker, screener risk.Screener, mailer mail.Mailer) (*Server, error) {
 	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token maker: %w", err)
 	}
 
+	passwordPolicy, err := bankvalidator.NewPasswordPolicy(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create password policy: %w", err)
+	}
+
+	limits, err := ratelimit.LoadLimits(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load rate limits: %w", err)
+	}
+
 	server := &Server{
-		config:     config,
-		store:      store,
-		tokenMaker: tokenMaker,
+		config:        config,
+		store:         store,
+		tokenMaker:    tokenMaker,
+		broker:        broker,
+		screener:      screener,
+		interestJob:   interest.NewJob(store),
+		importer:      importer.NewImporter(store),
+		exporter:      export.NewExporter(store),
+		recoverer:     recovery.NewRecoverer(store, mailer, config.PasswordResetTokenDuration, passwordPolicy),
+		authenticator: mfa.NewAuthenticator(store, config.MFAChallengeDuration),
+		guard: lockout.NewGuard(store, lockout.Policy{
+			MaxFailures:   config.LoginMaxFailures,
+			IPMaxFailures: config.LoginIPMaxFailures,
+			Duration:      config.LoginLockoutDuration,
+			BaseDelay:     config.LoginBaseDelay,
+		}),
+		passwordPolicy: passwordPolicy,
+		limiter:        ratelimit.NewLimiter(ratelimit.NewMemoryStore(), limits),
+		shutdown:       make(chan struct{}),
 	}
 
 	server.setupRouter()
@@ -36,28 +87,83 @@ func NewServer(config util.Config, store db.Store) (*Server, error) {
 }
 
 func (server *Server) setupRouter() {
-	router := gin.Default()
+	router := gin.New()
+	// the clients reach gin directly, the forwarding headers they send must not change ctx.ClientIP
+	router.SetTrustedProxies(nil)
+	router.Use(TracingMiddleware(), RequestLoggerMiddleware(), MetricsMiddleware(), gin.Recovery())
+	// the handlers pass the gin context to the store, which then finds the request ID of the logs
+	router.ContextWithFallback = true
 
 	binding.Validator.Engine().(*validator.Validate).RegisterValidation("currency", validCurrency)
 
-	router.POST("/login", server.LoginUser)
-	router.POST("/tokens/renew_access", server.RenewAccessToken)
-	router.POST("/users", server.CreateUser)
+	publicRoutes := router.Group("/").Use(RateLimitMiddleware(server.limiter, budget(ratelimit.BudgetLogin)))
 
-	authRoutes := router.Group("/").Use(AuthMiddleware(server.tokenMaker))
+	publicRoutes.POST("/login", server.LoginUser)
+	publicRoutes.POST("/login/mfa", server.VerifyLoginMFA)
+	publicRoutes.POST("/tokens/renew_access", server.RenewAccessToken)
+	publicRoutes.POST("/users", server.CreateUser)
+	publicRoutes.POST("/password_resets", server.RequestPasswordReset)
+	publicRoutes.POST("/password_resets/confirm", server.ResetPassword)
+
+	authRoutes := router.Group("/").Use(AuthMiddleware(server.tokenMaker, server.store), RateLimitMiddleware(server.limiter, readOrWriteBudget))
 
 	authRoutes.GET("/users/:name", server.FindUserByName)
+	authRoutes.GET("/users/:name/export", server.ExportUserData)
+	authRoutes.PATCH("/users/:name", server.UpdateUser)
+	authRoutes.DELETE("/users/:name", server.EraseUser)
+	authRoutes.POST("/users/:name/mfa", server.EnrollMFA)
+	authRoutes.POST("/users/:name/mfa/enable", server.EnableMFA)
+	authRoutes.DELETE("/users/:name/mfa", server.DisableMFA)
+
+	authRoutes.GET("/account_products", server.ListAccountProducts)
 
 	authRoutes.POST("/accounts", server.CreateAccount)
 	authRoutes.GET("/accounts/:id", server.FindAccountById)
 	authRoutes.GET("/accounts", server.GetAccounts)
-	authRoutes.DELETE("/accounts/:id", server.DeleteAccount)
+	authRoutes.DELETE("/accounts/:id", server.CloseAccount)
+	authRoutes.GET("/accounts/:id/events", server.WatchAccountEvents)
+	authRoutes.POST("/accounts/:id/members", server.InviteAccountMember)
+	authRoutes.GET("/accounts/:id/members", server.ListAccountMembers)
+	authRoutes.PATCH("/accounts/:id/members/:username", server.UpdateAccountMember)
+	authRoutes.DELETE("/accounts/:id/members/:username", server.RemoveAccountMember)
+	authRoutes.GET("/invitations", server.ListAccountInvitations)
+	authRoutes.POST("/invitations/:id/accept", server.AcceptAccountInvitation)
+	authRoutes.POST("/accounts/:id/signatories", server.AddAccountSignatory)
+	authRoutes.GET("/accounts/:id/signatories", server.ListAccountSignatories)
+	authRoutes.DELETE("/accounts/:id/signatories/:username", server.RemoveAccountSignatory)
+	authRoutes.PUT("/accounts/:id/approval_policy", server.SetApprovalPolicy)
+	authRoutes.GET("/accounts/:id/transfer_requests", server.ListTransferRequests)
+	authRoutes.GET("/accounts/:id/interest_postings", server.ListInterestPostings)
 
 	authRoutes.POST("/entries", server.CreateEntry)
 	authRoutes.GET("/entries/:id", server.FindEntryByAccountID)
 	authRoutes.GET("/entries", server.GetEntriesListById)
 
-	authRoutes.POST("/transfers", server.CreateTransfert)
+	authRoutes.POST("/transfers/preview", server.PreviewTransferFee)
+	authRoutes.GET("/transfers/:id/reversals", server.ListTransferReversals)
+	authRoutes.POST("/transfer_requests/:id/reject", server.RejectTransferRequest)
+
+	transferRoutes := router.Group("/").Use(AuthMiddleware(server.tokenMaker, server.store), RateLimitMiddleware(server.limiter, budget(ratelimit.BudgetTransfer)))
+
+	transferRoutes.POST("/transfers", server.CreateTransfert)
+	transferRoutes.POST("/transfers/batch", server.CreateBatchTransfer)
+	transferRoutes.POST("/transfers/:id/reversals", server.ReverseTransfer)
+	transferRoutes.POST("/transfer_requests/:id/approve", server.ApproveTransferRequest)
+
+	bankerRoutes := router.Group("/").Use(AuthMiddleware(server.tokenMaker, server.store), RoleMiddleware(util.BankerRole), RateLimitMiddleware(server.limiter, readOrWriteBudget))
+
+	bankerRoutes.GET("/transfer_reviews", server.ListTransferReviews)
+	bankerRoutes.POST("/transfer_reviews/:id/approve", server.ApproveTransferReview)
+	bankerRoutes.POST("/transfer_reviews/:id/reject", server.RejectTransferReview)
+	bankerRoutes.POST("/interest/runs", server.RunInterest)
+	bankerRoutes.POST("/accounts/:id/imports", server.ImportEntries)
+	bankerRoutes.GET("/fee_schedules", server.ListFeeSchedules)
+	bankerRoutes.PUT("/fee_schedules/:currency", server.SetFeeSchedule)
+	bankerRoutes.DELETE("/fee_schedules/:currency", server.DeleteFeeSchedule)
+	bankerRoutes.POST("/transfer_limits", server.CreateTransferLimit)
+	bankerRoutes.DELETE("/transfer_limits/:id", server.DeleteTransferLimit)
+	bankerRoutes.GET("/audit_logs", server.ListAuditLogs)
+	bankerRoutes.POST("/users/:name/unlock", server.UnlockUser)
 
 	server.router = router
 }
@@ -67,6 +173,18 @@ func (server *Server) Start(address string) error {
 	return server.router.Run(address)
 }
 
+// Handler returns the router, to serve it next to other handlers
+func (server *Server) Handler() http.Handler {
+	return server.router
+}
+
+// Shutdown ends the account event streams, which would otherwise keep a graceful stop of the HTTP server waiting
+func (server *Server) Shutdown() {
+	server.shutdownOnce.Do(func() {
+		close(server.shutdown)
+	})
+}
+
 func errorResponse(err error) gin.H {
 	return gin.H{"error": err.Error()}
 }
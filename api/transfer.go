@@ -1,12 +1,12 @@
 package api
 
 import (
-	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
 
 	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/risk"
 	"bitbucket.org/jessyw/go_simplebank/token"
 	"github.com/gin-gonic/gin"
 )
@@ -16,6 +16,8 @@ type transferRequest struct {
 	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
 	Amount        int64  `json:"amount" binding:"required,gt=0"`
 	Currency      string `json:"currency" binding:"required,currency"`
+	// MFACode is a two-factor authentication code, needed when the amount is above the step-up amount
+	MFACode string `json:"mfa_code"`
 }
 
 // CreateAccount - create an account
@@ -31,27 +33,78 @@ func (server *Server) CreateTransfert(ctx *gin.Context) {
 		return
 	}
 
-	// TODO add role admin to create transfer
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if fromAccount.Owner != authPayload.Username {
-		err := errors.New("account doesn't belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	if !server.authorizeAccount(ctx, fromAccount, db.AccountPermissionTransact) {
+		return
+	}
+
+	if !server.verifyStepUp(ctx, req.Amount, req.MFACode) {
 		return
 	}
 
-	_, valid = server.validAccount(ctx, req.ToAccountID, req.Currency)
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	toAccount, valid := server.validAccount(ctx, req.ToAccountID, req.Currency)
 	if !valid {
 		return
 	}
 
+	screening, err := server.screener.Screen(ctx, risk.Transfer{
+		FromAccount: fromAccount,
+		ToAccount:   toAccount,
+		Amount:      req.Amount,
+		Username:    authPayload.Username,
+		ClientIP:    clientIP(ctx),
+		UserAgent:   ctx.Request.UserAgent(),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	switch screening.Decision {
+	case risk.DecisionDeny:
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error":   "transfer denied by risk screening",
+			"reasons": screening.Reasons,
+		})
+		return
+	case risk.DecisionReview:
+		review, err := server.store.CreateTransferReview(ctx, db.CreateTransferReviewParams{
+			FromAccountID: req.FromAccountID,
+			ToAccountID:   req.ToAccountID,
+			Amount:        req.Amount,
+			RequestedBy:   authPayload.Username,
+			Reasons:       screening.Reasons,
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusAccepted, review)
+		return
+	}
+
 	arg := db.TransferTxParams{
 		FromAccountID: req.FromAccountID,
 		ToAccountID:   req.ToAccountID,
 		Amount:        req.Amount,
 	}
 
+	if server.requestTransferApproval(ctx, arg, authPayload.Username) {
+		return
+	}
+
 	result, err := server.store.TransferTx(ctx, arg)
 	if err != nil {
+		var limitErr *db.TransferLimitError
+		if errors.As(err, &limitErr) {
+			ctx.JSON(http.StatusUnprocessableEntity, transferLimitErrorResponse(limitErr))
+			return
+		}
+		if errors.Is(err, db.ErrAccountClosed) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
@@ -62,7 +115,7 @@ func (server *Server) CreateTransfert(ctx *gin.Context) {
 func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
 	account, err := server.store.GetAccount(ctx, accountID)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, db.ErrRecordNotFound) {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
 			return account, false
 		}
@@ -77,5 +130,23 @@ func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency s
 		return account, false
 	}
 
+	if account.ClosedAt.Valid {
+		err := fmt.Errorf("%w: account [%d]", db.ErrAccountClosed, accountID)
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return account, false
+	}
+
 	return account, true
 }
+
+func transferLimitErrorResponse(limitErr *db.TransferLimitError) gin.H {
+	return gin.H{
+		"error":     limitErr.Error(),
+		"reason":    limitErr.Reason(),
+		"scope":     limitErr.Scope(),
+		"limit_id":  limitErr.Limit.ID,
+		"kind":      limitErr.Limit.Kind,
+		"max_value": limitErr.Limit.MaxValue,
+		"current":   limitErr.Current,
+	}
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type createTransferLimitRequest struct {
+	// Username limits every account of the user, AccountID a single account, neither every user
+	Username  string `json:"username" binding:"omitempty,alphanum"`
+	AccountID int64  `json:"account_id" binding:"min=0"`
+	Currency  string `json:"currency" binding:"omitempty,currency"`
+	Kind      string `json:"kind" binding:"required,oneof=amount count"`
+	MaxValue  int64  `json:"max_value" binding:"min=0"`
+	// Period is the rolling window of the limit, such as 24h, empty for a per-transaction maximum
+	Period string `json:"period"`
+}
+
+// CreateTransferLimit - limit the amount or the number of transfers sent by a user, an account or everyone
+func (server *Server) CreateTransferLimit(ctx *gin.Context) {
+	var req createTransferLimitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg, err := newCreateTransferLimitParams(req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	limit, err := server.store.CreateTransferLimit(ctx, arg)
+	if err != nil {
+		if db.ErrorCode(err) == db.ForeignKeyViolation {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, limit)
+}
+
+func newCreateTransferLimitParams(req createTransferLimitRequest) (db.CreateTransferLimitParams, error) {
+	arg := db.CreateTransferLimitParams{
+		Username:  pgtype.Text{String: req.Username, Valid: req.Username != ""},
+		AccountID: pgtype.Int8{Int64: req.AccountID, Valid: req.AccountID != 0},
+		Currency:  pgtype.Text{String: req.Currency, Valid: req.Currency != ""},
+		Kind:      req.Kind,
+		MaxValue:  req.MaxValue,
+	}
+
+	if req.Period != "" {
+		period, err := time.ParseDuration(req.Period)
+		if err != nil {
+			return db.CreateTransferLimitParams{}, err
+		}
+		if period <= 0 {
+			return db.CreateTransferLimitParams{}, errors.New("period must be positive")
+		}
+
+		// whole days are kept as days so that the limit reads as such
+		day := 24 * time.Hour
+		arg.Period = pgtype.Interval{
+			Days:         int32(period / day),
+			Microseconds: (period % day).Microseconds(),
+			Valid:        true,
+		}
+	}
+
+	switch req.Kind {
+	case db.TransferLimitKindAmount:
+		if req.Currency == "" {
+			return db.CreateTransferLimitParams{}, errors.New("amount limits need a currency")
+		}
+	case db.TransferLimitKindCount:
+		if req.Period == "" {
+			return db.CreateTransferLimitParams{}, errors.New("count limits need a period")
+		}
+	}
+
+	return arg, nil
+}
+
+type transferLimitUri struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// DeleteTransferLimit - lift a transfer limit
+func (server *Server) DeleteTransferLimit(ctx *gin.Context) {
+	var uri transferLimitUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	err := server.store.DeleteTransferLimit(ctx, uri.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, nil)
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"bitbucket.org/jessyw/go_simplebank/authz"
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/token"
+	"github.com/gin-gonic/gin"
+)
+
+type listTransferRequestsRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending approved rejected expired"`
+	Offset int32  `form:"offset" binding:"required,min=1"`
+	Limit  int32  `form:"limit" binding:"required,min=5,max=10"`
+}
+
+// ListTransferRequests - list the transfers of an account waiting for approvals, for its owner and signatories
+func (server *Server) ListTransferRequests(ctx *gin.Context) {
+	var uri findAccountByIdRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req listTransferRequestsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.Status == "" {
+		req.Status = db.TransferRequestStatusPending
+	}
+
+	account, err := server.store.GetAccount(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// signatories see the transfers they have to approve even without being members
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	err = authz.AuthorizeAccount(ctx, server.store, account, authPayload.Username, db.AccountPermissionView)
+	if errors.Is(err, authz.ErrAccessDenied) {
+		_, err = server.store.GetAccountSignatory(ctx, db.GetAccountSignatoryParams{
+			AccountID: account.ID,
+			Username:  authPayload.Username,
+		})
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(authz.ErrAccessDenied))
+			return
+		}
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	arg := db.ListTransferRequestsParams{
+		FromAccountID: account.ID,
+		Status:        req.Status,
+		Limit:         req.Limit,
+		Offset:        (req.Offset - 1) * req.Limit,
+	}
+
+	requests, err := server.store.ListTransferRequests(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, requests)
+}
+
+type transferRequestUri struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// ApproveTransferRequest - approve a transfer as a signatory of its source account,
+// the transfer is executed once it reaches its required approvals
+func (server *Server) ApproveTransferRequest(ctx *gin.Context) {
+	var uri transferRequestUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	arg := db.ApproveTransferRequestTxParams{
+		ID:       uri.ID,
+		Username: authPayload.Username,
+	}
+
+	result, err := server.store.ApproveTransferRequestTx(ctx, arg)
+	if err != nil {
+		server.transferRequestError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// RejectTransferRequest - reject a transfer as a signatory of its source account, or cancel it as its requester
+func (server *Server) RejectTransferRequest(ctx *gin.Context) {
+	var uri transferRequestUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	arg := db.RejectTransferRequestTxParams{
+		ID:       uri.ID,
+		Username: authPayload.Username,
+	}
+
+	request, err := server.store.RejectTransferRequestTx(ctx, arg)
+	if err != nil {
+		server.transferRequestError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, request)
+}
+
+func (server *Server) transferRequestError(ctx *gin.Context, err error) {
+	var limitErr *db.TransferLimitError
+	switch {
+	case errors.Is(err, db.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, errorResponse(err))
+	case errors.Is(err, db.ErrNotSignatory), errors.Is(err, db.ErrSelfApproval):
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+	case errors.Is(err, db.ErrTransferRequestClosed), errors.Is(err, db.ErrAlreadyApproved):
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+	case errors.Is(err, db.ErrTransferRequestExpired):
+		ctx.JSON(http.StatusGone, errorResponse(err))
+	case errors.As(err, &limitErr):
+		ctx.JSON(http.StatusUnprocessableEntity, transferLimitErrorResponse(limitErr))
+	case errors.Is(err, db.ErrAccountClosed):
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+	default:
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	}
+}
+
+// requestTransferApproval holds the transfer for approvals when the source account has an approval policy
+// and the amount is above its threshold. It returns false if the transfer can be executed right away.
+func (server *Server) requestTransferApproval(ctx *gin.Context, arg db.TransferTxParams, requestedBy string) bool {
+	request, requested, err := db.RequestTransferApproval(ctx, server.store, db.RequestTransferApprovalParams{
+		TransferTxParams: arg,
+		RequestedBy:      requestedBy,
+		ExpiresAt:        time.Now().Add(server.config.TransferRequestDuration),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return true
+	}
+	if !requested {
+		return false
+	}
+
+	ctx.JSON(http.StatusAccepted, request)
+	return true
+}
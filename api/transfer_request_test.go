@@ -0,0 +1,190 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "bitbucket.org/jessyw/go_simplebank/db/mock"
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApproveTransferRequestAPI(t *testing.T) {
+	signatory, _ := randomUser(t)
+	requestID := util.RandomInt(1, 1000)
+
+	testCases := []struct {
+		name          string
+		requestID     int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			requestID: requestID,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ApproveTransferRequestTxParams{
+					ID:       requestID,
+					Username: signatory.Username,
+				}
+				store.EXPECT().ApproveTransferRequestTx(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.ApproveTransferRequestTxResult{Approvals: 1}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "NotFound",
+			requestID: requestID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ApproveTransferRequestTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.ApproveTransferRequestTxResult{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "NotSignatory",
+			requestID: requestID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ApproveTransferRequestTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.ApproveTransferRequestTxResult{}, db.ErrNotSignatory)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "SelfApproval",
+			requestID: requestID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ApproveTransferRequestTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.ApproveTransferRequestTxResult{}, db.ErrSelfApproval)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "AlreadyApproved",
+			requestID: requestID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ApproveTransferRequestTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.ApproveTransferRequestTxResult{}, db.ErrAlreadyApproved)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:      "Expired",
+			requestID: requestID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ApproveTransferRequestTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.ApproveTransferRequestTxResult{}, db.ErrTransferRequestExpired)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusGone, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidID",
+			requestID: 0,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ApproveTransferRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfer_requests/%d/approve", tc.requestID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, signatory.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestRejectTransferRequestAPI(t *testing.T) {
+	signatory, _ := randomUser(t)
+	requestID := util.RandomInt(1, 1000)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.RejectTransferRequestTxParams{
+					ID:       requestID,
+					Username: signatory.Username,
+				}
+				store.EXPECT().RejectTransferRequestTx(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.TransferRequest{ID: requestID, Status: db.TransferRequestStatusRejected}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Closed",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RejectTransferRequestTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.TransferRequest{}, db.ErrTransferRequestClosed)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfer_requests/%d/reject", requestID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, signatory.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"bitbucket.org/jessyw/go_simplebank/authz"
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/token"
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/gin-gonic/gin"
+)
+
+type transferUri struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type reverseTransferRequest struct {
+	// Amount is the amount to refund, everything that hasn't been refunded yet when omitted
+	Amount int64  `json:"amount" binding:"min=0"`
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+// ReverseTransfer - refund all or part of a transfer, only bankers and the receiving account can do it
+func (server *Server) ReverseTransfer(ctx *gin.Context) {
+	var uri transferUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req reverseTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	transfer, valid := server.findTransfer(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Role != util.BankerRole {
+		if _, valid := server.authorizedAccount(ctx, transfer.ToAccountID, db.AccountPermissionTransact); !valid {
+			return
+		}
+	}
+
+	result, err := server.store.ReverseTransferTx(ctx, db.ReverseTransferTxParams{
+		TransferID: transfer.ID,
+		Amount:     req.Amount,
+		Reason:     req.Reason,
+		ReversedBy: authPayload.Username,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrReversalExceedsTransfer) || errors.Is(err, db.ErrReverseReversal) ||
+			errors.Is(err, db.ErrAccountClosed) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// ListTransferReversals - list the refunds of a transfer, for bankers and both parties of the transfer
+func (server *Server) ListTransferReversals(ctx *gin.Context) {
+	var uri transferUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	transfer, valid := server.findTransfer(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Role != util.BankerRole && !server.authorizeTransferParty(ctx, transfer, authPayload.Username) {
+		return
+	}
+
+	reversals, err := server.store.ListTransferReversals(ctx, transfer.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, reversals)
+}
+
+func (server *Server) findTransfer(ctx *gin.Context, transferID int64) (db.Transfer, bool) {
+	transfer, err := server.store.GetTransfer(ctx, transferID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return transfer, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return transfer, false
+	}
+
+	return transfer, true
+}
+
+// authorizeTransferParty checks that the user can view the sending or the receiving account of the transfer.
+// It writes the error response and returns false otherwise.
+func (server *Server) authorizeTransferParty(ctx *gin.Context, transfer db.Transfer, username string) bool {
+	for _, accountID := range []int64{transfer.FromAccountID, transfer.ToAccountID} {
+		account, err := server.store.GetAccount(ctx, accountID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return false
+		}
+
+		err = authz.AuthorizeAccount(ctx, server.store, account, username, db.AccountPermissionView)
+		if err == nil {
+			return true
+		}
+		if !errors.Is(err, authz.ErrAccessDenied) {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return false
+		}
+	}
+
+	ctx.JSON(http.StatusUnauthorized, errorResponse(authz.ErrAccessDenied))
+	return false
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/token"
+	"github.com/gin-gonic/gin"
+)
+
+type listTransferReviewsRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending_review approved rejected"`
+	Offset int32  `form:"offset" binding:"required,min=1"`
+	Limit  int32  `form:"limit" binding:"required,min=5,max=10"`
+}
+
+// ListTransferReviews - list the transfers held by the risk screening, pending ones by default
+func (server *Server) ListTransferReviews(ctx *gin.Context) {
+	var req listTransferReviewsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.Status == "" {
+		req.Status = db.TransferReviewStatusPending
+	}
+
+	arg := db.ListTransferReviewsParams{
+		Status: req.Status,
+		Limit:  req.Limit,
+		Offset: (req.Offset - 1) * req.Limit,
+	}
+
+	reviews, err := server.store.ListTransferReviews(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, reviews)
+}
+
+type reviewTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// ApproveTransferReview - execute a transfer held by the risk screening
+func (server *Server) ApproveTransferReview(ctx *gin.Context) {
+	server.reviewTransfer(ctx, true)
+}
+
+// RejectTransferReview - close a transfer held by the risk screening without executing it
+func (server *Server) RejectTransferReview(ctx *gin.Context) {
+	server.reviewTransfer(ctx, false)
+}
+
+func (server *Server) reviewTransfer(ctx *gin.Context, approved bool) {
+	var req reviewTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	arg := db.ReviewTransferTxParams{
+		ID:               req.ID,
+		ReviewedBy:       authPayload.Username,
+		Approved:         approved,
+		RequestExpiresAt: time.Now().Add(server.config.TransferRequestDuration),
+	}
+
+	result, err := server.store.ReviewTransferTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		if errors.Is(err, db.ErrTransferReviewClosed) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+
+		var limitErr *db.TransferLimitError
+		if errors.As(err, &limitErr) {
+			ctx.JSON(http.StatusUnprocessableEntity, transferLimitErrorResponse(limitErr))
+			return
+		}
+
+		if errors.Is(err, db.ErrAccountClosed) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if result.TransferRequest != nil {
+		// the transfer now waits for the signatories of the account
+		ctx.JSON(http.StatusAccepted, result)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
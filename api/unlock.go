@@ -0,0 +1,43 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/token"
+	"github.com/gin-gonic/gin"
+)
+
+type unlockUserResponse struct {
+	Failures int32            `json:"failures"`
+	AuditLog auditLogResponse `json:"audit_log"`
+}
+
+// UnlockUser - forget the failed logins of a user, for a banker, so that a user who was locked out can log in again
+func (server *Server) UnlockUser(ctx *gin.Context) {
+	var req findUserByNameRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	result, err := server.store.UnlockUserTx(ctx, db.UnlockUserTxParams{
+		Username:   req.Name,
+		UnlockedBy: authPayload.Username,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, unlockUserResponse{
+		Failures: result.Failures,
+		AuditLog: newAuditLogResponse(result.AuditLog),
+	})
+}
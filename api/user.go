@@ -1,16 +1,23 @@
 package api
 
 import (
-	"database/sql"
+	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/lockout"
+	"bitbucket.org/jessyw/go_simplebank/mfa"
 	"bitbucket.org/jessyw/go_simplebank/token"
 	"bitbucket.org/jessyw/go_simplebank/util"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/lib/pq"
 )
 
@@ -18,7 +25,7 @@ type createUserRequest struct {
 	Username string `json:"username" binding:"required,alphanum"`
 	FullName string `json:"full_name" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"`
 }
 
 type userResponse struct {
@@ -47,6 +54,10 @@ func (server *Server) CreateUser(ctx *gin.Context) {
 		return
 	}
 
+	if !server.validatePassword(ctx, req.Password, req.Username, req.Email) {
+		return
+	}
+
 	hashedPassword, err := util.HashPassword(req.Password)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
@@ -92,7 +103,7 @@ func (server *Server) FindUserByName(ctx *gin.Context) {
 
 	user, err := server.store.GetUser(ctx, req.Name)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, db.ErrRecordNotFound) {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
 			return
 		}
@@ -111,6 +122,145 @@ func (server *Server) FindUserByName(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, resp)
 }
 
+type updateUserRequest struct {
+	FullName        *string `json:"full_name" binding:"omitempty,min=1"`
+	Email           *string `json:"email" binding:"omitempty,email"`
+	Password        *string `json:"password" binding:"omitempty,min=1"`
+	CurrentPassword string  `json:"current_password"`
+}
+
+// UpdateUser - update the full name, email or password of a user, for the user or a banker.
+// Only the given fields are changed, a new password must follow the password policy.
+// Users changing their own email or password must give their current password, so that a stolen access token
+// isn't enough to take over the account. A new password blocks every session, and each change is audited.
+func (server *Server) UpdateUser(ctx *gin.Context) {
+	var uri findUserByNameRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if uri.Name != authPayload.Username && authPayload.Role != util.BankerRole {
+		err := errors.New("user doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUser(ctx, uri.Name)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.Username == authPayload.Username && (req.Email != nil || req.Password != nil) {
+		if !server.verifyCurrentPassword(ctx, user, req.CurrentPassword) {
+			return
+		}
+	}
+
+	arg := db.UpdateUserParams{
+		Username: user.Username,
+	}
+	if req.FullName != nil {
+		arg.FullName = pgtype.Text{String: *req.FullName, Valid: true}
+	}
+	if req.Email != nil {
+		arg.Email = pgtype.Text{String: *req.Email, Valid: true}
+		user.Email = *req.Email
+	}
+	if req.Password != nil {
+		if !server.validatePassword(ctx, *req.Password, user.Username, user.Email) {
+			return
+		}
+
+		hashedPassword, err := util.HashPassword(*req.Password)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		arg.HashedPassword = pgtype.Text{String: hashedPassword, Valid: true}
+	}
+
+	result, err := server.store.UpdateUserTx(ctx, db.UpdateUserTxParams{
+		UpdateUserParams: arg,
+		UpdatedBy:        authPayload.Username,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrRecordNotFound):
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		case db.ErrorCode(err) == db.UniqueViolation:
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(result.User))
+}
+
+// errCurrentPasswordRequired is returned when users change their own email or password without their current password
+var errCurrentPasswordRequired = errors.New("the current password is required to change the email or the password")
+
+// verifyCurrentPassword checks the current password of the authenticated user before a sensitive change.
+// Wrong passwords count as failed logins, so that a stolen access token can't be used to guess it.
+// It writes the error response and returns false when the password is missing or wrong.
+func (server *Server) verifyCurrentPassword(ctx *gin.Context, user db.User, password string) bool {
+	if password == "" {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errCurrentPasswordRequired))
+		return false
+	}
+
+	err := server.guard.Check(ctx, user.Username, clientIP(ctx))
+	if err != nil {
+		var throttled *lockout.ThrottledError
+		if errors.As(err, &throttled) {
+			ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+			ctx.JSON(http.StatusTooManyRequests, errorResponse(err))
+			return false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	// the attempt reserved by Check stays counted as a failed login when the password is wrong
+	_, err = util.CheckPassword(password, user.HashedPassword)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return false
+	}
+
+	err = server.guard.Succeed(ctx, user.Username, clientIP(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	return true
+}
+
+// validatePassword checks that a new password follows the password policy, and responds with a bad request when it doesn't
+func (server *Server) validatePassword(ctx *gin.Context, password string, username string, email string) bool {
+	err := server.passwordPolicy.Validate(password, username, email)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("invalid password: %w", err)))
+		return false
+	}
+	return true
+}
+
 type loginUserRequest struct {
 	Username string `json:"username" binding:"required,alphanum"`
 	Password string `json:"password" binding:"required,min=6"`
@@ -125,7 +275,18 @@ type loginUserResponse struct {
 	User                  userResponse `json:"user"`
 }
 
-// LoginUser - login a user
+// loginMFAResponse is returned instead of the tokens when the user has to give their second factor
+type loginMFAResponse struct {
+	MFARequired        bool      `json:"mfa_required"`
+	ChallengeToken     string    `json:"challenge_token"`
+	ChallengeExpiresAt time.Time `json:"challenge_expires_at"`
+}
+
+// errInvalidCredentials is the error of every failed login, it doesn't tell whether the username exists
+var errInvalidCredentials = errors.New("incorrect username or password")
+
+// LoginUser - login a user. Failed logins are tracked by username and by client IP: attempts that come too fast
+// after a failure are refused, and usernames or IPs with too many failures in a row are locked out for a while.
 func (server *Server) LoginUser(ctx *gin.Context) {
 	var req loginUserRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -133,24 +294,138 @@ func (server *Server) LoginUser(ctx *gin.Context) {
 		return
 	}
 
+	err := server.guard.Check(ctx, req.Username, clientIP(ctx))
+	if err != nil {
+		var throttled *lockout.ThrottledError
+		if errors.As(err, &throttled) {
+			ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+			ctx.JSON(http.StatusTooManyRequests, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	needsRehash := false
 	user, err := server.store.GetUser(ctx, req.Username)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		if !errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 			return
 		}
+		err = util.CheckPasswordOfUnknownUser(req.Password)
+	} else {
+		needsRehash, err = util.CheckPassword(req.Password, user.HashedPassword)
+	}
+	if err != nil {
+		// the attempt reserved by Check stays counted as a failed login
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+
+	if needsRehash {
+		server.rehashPassword(ctx, user, req.Password)
+	}
+
+	mfaEnabled, err := server.authenticator.Enabled(ctx, user.Username)
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	err = util.CheckPassword(req.Password, user.HashedPassword)
+	if mfaEnabled {
+		// the attempt reserved by Check is only given back once the code is checked too, wrong codes count as failed logins
+		challengeToken, expiresAt, err := server.authenticator.Challenge(ctx, user.Username)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, loginMFAResponse{
+			MFARequired:        true,
+			ChallengeToken:     challengeToken,
+			ChallengeExpiresAt: expiresAt,
+		})
+		return
+	}
+
+	err = server.guard.Succeed(ctx, user.Username, clientIP(ctx))
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
+	server.createSession(ctx, user)
+}
+
+// rehashPassword hashes again a password whose hash was made with an older algorithm or weaker parameters.
+// The login doesn't depend on it: a failure is only logged, the password will be rehashed at the next login.
+func (server *Server) rehashPassword(ctx context.Context, user db.User, password string) {
+	hashedPassword, err := util.HashPassword(password)
+	if err == nil {
+		err = server.store.RehashUserPassword(ctx, db.RehashUserPasswordParams{
+			HashedPassword:         hashedPassword,
+			Username:               user.Username,
+			PreviousHashedPassword: user.HashedPassword,
+		})
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "cannot rehash password", slog.String("username", user.Username), slog.Any("error", err))
+	}
+}
+
+type loginMFARequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+// VerifyLoginMFA - finish the login of a user with two-factor authentication, exchanging the challenge token
+// returned by LoginUser and a code from their authenticator app, or a recovery code, for tokens.
+// A wrong code counts as a failed login of the user.
+func (server *Server) VerifyLoginMFA(ctx *gin.Context) {
+	var req loginMFARequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	username, err := server.authenticator.VerifyChallenge(ctx, req.ChallengeToken, req.Code)
+	if err != nil {
+		if errors.Is(err, mfa.ErrInvalidCode) {
+			failErr := server.guard.Fail(ctx, username, clientIP(ctx))
+			if failErr != nil {
+				ctx.JSON(http.StatusInternalServerError, errorResponse(failErr))
+				return
+			}
+		}
+		if errors.Is(err, mfa.ErrChallengeInvalid) || errors.Is(err, mfa.ErrInvalidCode) {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.guard.Succeed(ctx, username, clientIP(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUser(ctx, username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.createSession(ctx, user)
+}
+
+// createSession issues the access and refresh tokens of a user who is logged in and writes them as the response
+func (server *Server) createSession(ctx *gin.Context, user db.User) {
 	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
 		user.Username,
+		user.Role,
 		server.config.AccessTokenDuration,
 	)
 	if err != nil {
@@ -160,6 +435,7 @@ func (server *Server) LoginUser(ctx *gin.Context) {
 
 	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(
 		user.Username,
+		user.Role,
 		server.config.RefreshTokenDuration,
 	)
 
@@ -173,7 +449,7 @@ func (server *Server) LoginUser(ctx *gin.Context) {
 		RefreshToken: refreshToken,
 		Username:     user.Username,
 		UserAgent:    ctx.Request.UserAgent(),
-		ClientIp:     ctx.ClientIP(),
+		ClientIp:     clientIP(ctx),
 		IsBlocked:    false,
 		ExpiresAt:    refreshPayload.ExpiredAt,
 	})
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -9,6 +10,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -18,7 +20,9 @@ import (
 	"bitbucket.org/jessyw/go_simplebank/util"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type eqCreateUserParamsMatcher struct {
@@ -32,7 +36,7 @@ func (e eqCreateUserParamsMatcher) Matches(x interface{}) bool {
 		return false
 	}
 
-	err := util.CheckPassword(e.password, arg.HashedPassword)
+	_, err := util.CheckPassword(e.password, arg.HashedPassword)
 	if err != nil {
 		return false
 	}
@@ -152,6 +156,59 @@ func TestCreateUser(t *testing.T) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
+		{
+			name: "PasswordWithoutEnoughClasses",
+			body: gin.H{
+				"username":  user.Username,
+				"password":  util.RandomString(12),
+				"full_name": user.FullName,
+				"email":     user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "PasswordContainsUsername",
+			body: gin.H{
+				"username":  user.Username,
+				"password":  "Aa1!" + user.Username,
+				"full_name": user.FullName,
+				"email":     user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "must not contain the username")
+			},
+		},
+		{
+			name: "BreachedPassword",
+			body: gin.H{
+				"username":  user.Username,
+				"password":  "P@ssw0rd!",
+				"full_name": user.FullName,
+				"email":     user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "data breach")
+			},
+		},
 	}
 
 	for i := range testCases {
@@ -196,7 +253,7 @@ func TestGetUserAPI(t *testing.T) {
 			name:     "OK",
 			userName: user.Username,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				// build stubs
@@ -229,14 +286,14 @@ func TestGetUserAPI(t *testing.T) {
 			name:     "NotFound",
 			userName: user.Username,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				// build stubs
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Eq(user.Username)).
 					Times(1).
-					Return(db.User{}, sql.ErrNoRows)
+					Return(db.User{}, db.ErrRecordNotFound)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusNotFound, recorder.Code)
@@ -246,7 +303,7 @@ func TestGetUserAPI(t *testing.T) {
 			name:     "InternalError",
 			userName: user.Username,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				// build stubs
@@ -288,9 +345,15 @@ func TestGetUserAPI(t *testing.T) {
 func TestLoginUserAPI(t *testing.T) {
 	user, password := randomUser(t)
 
+	bcryptHash, err := util.BcryptHasher{Cost: bcrypt.MinCost}.Hash(password)
+	require.NoError(t, err)
+	bcryptUser := user
+	bcryptUser.HashedPassword = bcryptHash
+
 	testCases := []struct {
 		name          string
 		body          gin.H
+		forwardedFor  string
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(recoder *httptest.ResponseRecorder)
 	}{
@@ -301,10 +364,34 @@ func TestLoginUserAPI(t *testing.T) {
 				"password": password,
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.LoginThrottle{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					CreateLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(2)
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Eq(user.Username)).
 					Times(1).
 					Return(user, nil)
+				store.EXPECT().
+					DeleteLoginThrottle(gomock.Any(), gomock.Eq(db.DeleteLoginThrottleParams{
+						Kind: db.LoginThrottleKindUsername,
+						Key:  user.Username,
+					})).
+					Times(1)
+				// the attempt reserved for the client IP is given back, its other failures are kept
+				store.EXPECT().
+					ReleaseLoginAttempt(gomock.Any(), gomock.Eq(db.ReleaseLoginAttemptParams{
+						Kind: db.LoginThrottleKindIP,
+						Key:  "10.0.0.1",
+					})).
+					Times(1)
+				store.EXPECT().
+					GetTotpSecret(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.TotpSecret{}, db.ErrRecordNotFound)
 				store.EXPECT().
 					CreateSession(gomock.Any(), gomock.Any()).
 					Times(1)
@@ -314,6 +401,107 @@ func TestLoginUserAPI(t *testing.T) {
 				require.Equal(t, http.StatusOK, recorder.Code)
 			},
 		},
+		{
+			name: "RehashOutdatedPassword",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.LoginThrottle{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					CreateLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(2)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(bcryptUser, nil)
+				store.EXPECT().
+					DeleteLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(1)
+				store.EXPECT().
+					ReleaseLoginAttempt(gomock.Any(), gomock.Any()).
+					Times(1)
+				store.EXPECT().
+					RehashUserPassword(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.RehashUserPasswordParams) error {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, bcryptHash, arg.PreviousHashedPassword)
+						require.True(t, strings.HasPrefix(arg.HashedPassword, "$argon2id$"))
+						needsRehash, err := util.CheckPassword(password, arg.HashedPassword)
+						require.NoError(t, err)
+						require.False(t, needsRehash)
+						return nil
+					})
+				store.EXPECT().
+					GetTotpSecret(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.TotpSecret{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "MFARequired",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.LoginThrottle{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					CreateLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(2)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				// the attempt stays reserved until the code is checked
+				store.EXPECT().
+					DeleteLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					ReleaseLoginAttempt(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					GetTotpSecret(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.TotpSecret{
+						Username:  user.Username,
+						EnabledAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+					}, nil)
+				store.EXPECT().
+					CreateLoginChallenge(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateLoginChallengeParams) (db.LoginChallenge, error) {
+						return db.LoginChallenge{ID: 1, Username: arg.Username, TokenHash: arg.TokenHash, ExpiresAt: arg.ExpiresAt}, nil
+					})
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp map[string]any
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, true, rsp["mfa_required"])
+				require.NotEmpty(t, rsp["challenge_token"])
+				require.NotContains(t, rsp, "access_token")
+			},
+		},
 		{
 			name: "UserNotFound",
 			body: gin.H{
@@ -321,13 +509,29 @@ func TestLoginUserAPI(t *testing.T) {
 				"password": password,
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.LoginThrottle{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					CreateLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(2)
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(db.User{}, sql.ErrNoRows)
+					Return(db.User{}, db.ErrRecordNotFound)
+				// the attempt reserved by Check is the failed login, nothing else is recorded
+				store.EXPECT().
+					RecordLoginFailure(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					ReleaseLoginAttempt(gomock.Any(), gomock.Any()).
+					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusNotFound, recorder.Code)
+				// same response as for a wrong password, it doesn't tell that the username doesn't exist
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyError(t, recorder.Body, errInvalidCredentials)
 			},
 		},
 		{
@@ -337,13 +541,154 @@ func TestLoginUserAPI(t *testing.T) {
 				"password": "incorrect",
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.LoginThrottle{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					CreateLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(2)
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Eq(user.Username)).
 					Times(1).
 					Return(user, nil)
+				// the attempt reserved by Check is the failed login, nothing else is recorded
+				store.EXPECT().
+					RecordLoginFailure(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					ReleaseLoginAttempt(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					DeleteLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyError(t, recorder.Body, errInvalidCredentials)
+			},
+		},
+		{
+			name: "SpoofedForwardedFor",
+			body: gin.H{
+				"username": user.Username,
+				"password": "incorrect",
+			},
+			forwardedFor: "203.0.113.7",
+			buildStubs: func(store *mockdb.MockStore) {
+				// the failures are still counted against the peer address, whatever the client claims
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Eq(db.GetLoginThrottleParams{Kind: db.LoginThrottleKindUsername, Key: user.Username})).
+					Times(1).
+					Return(db.LoginThrottle{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Eq(db.GetLoginThrottleParams{Kind: db.LoginThrottleKindIP, Key: "10.0.0.1"})).
+					Times(1).
+					Return(db.LoginThrottle{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateLoginThrottle(gomock.Any(), gomock.Eq(db.CreateLoginThrottleParams{Kind: db.LoginThrottleKindUsername, Key: user.Username})).
+					Times(1)
+				store.EXPECT().
+					CreateLoginThrottle(gomock.Any(), gomock.Eq(db.CreateLoginThrottleParams{Kind: db.LoginThrottleKindIP, Key: "10.0.0.1"})).
+					Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "SessionOfSpoofedForwardedFor",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			forwardedFor: "203.0.113.7",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.LoginThrottle{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					CreateLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(2)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					DeleteLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(1)
+				store.EXPECT().
+					ReleaseLoginAttempt(gomock.Any(), gomock.Any()).
+					Times(1)
+				store.EXPECT().
+					GetTotpSecret(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.TotpSecret{}, db.ErrRecordNotFound)
+				// the session records the peer address, which the new device rule compares later
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.CreateSessionParams) (db.Session, error) {
+						require.Equal(t, "10.0.0.1", arg.ClientIp)
+						return db.Session{ID: arg.ID, Username: arg.Username}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Delayed",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Eq(db.GetLoginThrottleParams{Kind: db.LoginThrottleKindUsername, Key: user.Username})).
+					Times(1).
+					Return(db.LoginThrottle{Failures: 3, LastFailedAt: time.Now()}, nil)
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Eq(db.GetLoginThrottleParams{Kind: db.LoginThrottleKindIP, Key: "10.0.0.1"})).
+					Times(1).
+					Return(db.LoginThrottle{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+				require.Equal(t, "2", recorder.Header().Get("Retry-After"))
+			},
+		},
+		{
+			name: "Locked",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Eq(db.GetLoginThrottleParams{Kind: db.LoginThrottleKindUsername, Key: user.Username})).
+					Times(1).
+					Return(db.LoginThrottle{Failures: 5, LastFailedAt: time.Now().Add(-time.Minute)}, nil)
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Eq(db.GetLoginThrottleParams{Kind: db.LoginThrottleKindIP, Key: "10.0.0.1"})).
+					Times(1).
+					Return(db.LoginThrottle{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				// even the right password is refused until the lockout ends
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+				require.Equal(t, "840", recorder.Header().Get("Retry-After"))
 			},
 		},
 		{
@@ -353,6 +698,13 @@ func TestLoginUserAPI(t *testing.T) {
 				"password": password,
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.LoginThrottle{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					CreateLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(2)
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Any()).
 					Times(1).
@@ -369,6 +721,9 @@ func TestLoginUserAPI(t *testing.T) {
 				"password": password,
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(0)
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Any()).
 					Times(0)
@@ -399,6 +754,11 @@ func TestLoginUserAPI(t *testing.T) {
 			url := "/login"
 			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
 			require.NoError(t, err)
+			request.RemoteAddr = "10.0.0.1:1234"
+			if tc.forwardedFor != "" {
+				request.Header.Set("X-Forwarded-For", tc.forwardedFor)
+				request.Header.Set("X-Real-IP", tc.forwardedFor)
+			}
 
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(recorder)
@@ -406,8 +766,331 @@ func TestLoginUserAPI(t *testing.T) {
 	}
 }
 
+func TestUpdateUserAPI(t *testing.T) {
+	user, password := randomUser(t)
+	banker, _ := randomUser(t)
+	newFullName := util.RandomOwner()
+	newEmail := util.RandomEmail()
+	newPassword := util.RandomPassword()
+
+	// expectCurrentPassword expects the right current password of the user, checked like a login
+	expectCurrentPassword := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetLoginThrottle(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(db.LoginThrottle{}, db.ErrRecordNotFound)
+		store.EXPECT().
+			CreateLoginThrottle(gomock.Any(), gomock.Any()).
+			Times(1)
+		store.EXPECT().
+			DeleteLoginThrottle(gomock.Any(), gomock.Eq(db.DeleteLoginThrottleParams{
+				Kind: db.LoginThrottleKindUsername,
+				Key:  user.Username,
+			})).
+			Times(1)
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			body:     gin.H{"full_name": newFullName, "email": newEmail, "current_password": password},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				updated := user
+				updated.FullName = newFullName
+				updated.Email = newEmail
+
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				expectCurrentPassword(store)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Eq(db.UpdateUserTxParams{
+						UpdateUserParams: db.UpdateUserParams{
+							Username: user.Username,
+							FullName: pgtype.Text{String: newFullName, Valid: true},
+							Email:    pgtype.Text{String: newEmail, Valid: true},
+						},
+						UpdatedBy: user.Username,
+					})).
+					Times(1).
+					Return(db.UpdateUserTxResult{User: updated}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), newEmail)
+				require.NotContains(t, recorder.Body.String(), "hashed_password")
+			},
+		},
+		{
+			name:     "NewPassword",
+			username: user.Username,
+			body:     gin.H{"password": newPassword, "current_password": password},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				expectCurrentPassword(store)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+						require.False(t, arg.FullName.Valid)
+						require.False(t, arg.Email.Valid)
+						require.True(t, arg.HashedPassword.Valid)
+						_, err := util.CheckPassword(newPassword, arg.HashedPassword.String)
+						require.NoError(t, err)
+						return db.UpdateUserTxResult{User: user, BlockedSessions: 1}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "Banker",
+			username: user.Username,
+			body:     gin.H{"full_name": newFullName},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Eq(db.UpdateUserTxParams{
+						UpdateUserParams: db.UpdateUserParams{
+							Username: user.Username,
+							FullName: pgtype.Text{String: newFullName, Valid: true},
+						},
+						UpdatedBy: banker.Username,
+					})).
+					Times(1).
+					Return(db.UpdateUserTxResult{User: user}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "BankerNewPassword",
+			username: user.Username,
+			body:     gin.H{"password": newPassword},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				// bankers don't know the password of the user, the change is audited under their name instead
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetLoginThrottle(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+						require.Equal(t, banker.Username, arg.UpdatedBy)
+						require.True(t, arg.HashedPassword.Valid)
+						return db.UpdateUserTxResult{User: user, BlockedSessions: 1}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "FullNameWithoutCurrentPassword",
+			username: user.Username,
+			body:     gin.H{"full_name": newFullName},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetLoginThrottle(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(1).Return(db.UpdateUserTxResult{User: user}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "MissingCurrentPassword",
+			username: user.Username,
+			body:     gin.H{"email": newEmail},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyError(t, recorder.Body, errCurrentPasswordRequired)
+			},
+		},
+		{
+			name:     "WrongCurrentPassword",
+			username: user.Username,
+			body:     gin.H{"password": newPassword, "current_password": "incorrect"},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.LoginThrottle{}, db.ErrRecordNotFound)
+				// a wrong current password counts as a failed login, reserved before the password is checked
+				store.EXPECT().
+					CreateLoginThrottle(gomock.Any(), gomock.Eq(db.CreateLoginThrottleParams{Kind: db.LoginThrottleKindUsername, Key: user.Username})).
+					Times(1)
+				store.EXPECT().
+					DeleteLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyError(t, recorder.Body, errInvalidCredentials)
+			},
+		},
+		{
+			name:     "LockedOut",
+			username: user.Username,
+			body:     gin.H{"email": newEmail, "current_password": password},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.LoginThrottle{Failures: 5, LastFailedAt: time.Now()}, nil)
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+			},
+		},
+		{
+			name:     "OtherUser",
+			username: banker.Username,
+			body:     gin.H{"full_name": newFullName},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "PasswordContainsNewEmail",
+			username: user.Username,
+			body:     gin.H{"email": "walrus42@example.com", "password": "Walrus42-Secret", "current_password": password},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				expectCurrentPassword(store)
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "must not contain the email")
+			},
+		},
+		{
+			name:     "InvalidEmail",
+			username: user.Username,
+			body:     gin.H{"email": "invalid-email"},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "UserNotFound",
+			username: user.Username,
+			body:     gin.H{"full_name": newFullName},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, db.ErrRecordNotFound)
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "EmailTaken",
+			username: user.Username,
+			body:     gin.H{"email": newEmail, "current_password": password},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+				expectCurrentPassword(store)
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(1).Return(db.UpdateUserTxResult{}, db.ErrUniqueViolation)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/users/%s", tc.username)
+			request, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
 func randomUser(t *testing.T) (user db.User, password string) {
-	password = util.RandomString(6)
+	password = util.RandomPassword()
 	hashedPassword, err := util.HashPassword(password)
 	require.NoError(t, err)
 
@@ -433,3 +1116,9 @@ func requireBodyMatchUser(t *testing.T, body *bytes.Buffer, user db.User) {
 	require.Equal(t, user.Email, gotUser.Email)
 	require.Empty(t, gotUser.HashedPassword)
 }
+
+func requireBodyError(t *testing.T, body *bytes.Buffer, err error) {
+	var rsp gin.H
+	require.NoError(t, json.Unmarshal(body.Bytes(), &rsp))
+	require.Equal(t, err.Error(), rsp["error"])
+}
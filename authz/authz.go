@@ -0,0 +1,46 @@
+// Package authz decides who can act on an account: its owner, or the members it is shared with.
+package authz
+
+import (
+	"context"
+	"errors"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+)
+
+// ErrAccessDenied is returned when a user isn't allowed to act on an account
+var ErrAccessDenied = errors.New("account doesn't belong to the authenticated user")
+
+// permissionLevels orders permissions, each one includes the ones below it
+var permissionLevels = map[string]int{
+	db.AccountPermissionView:     1,
+	db.AccountPermissionTransact: 2,
+	db.AccountPermissionManage:   3,
+}
+
+// AuthorizeAccount returns nil if the user can act on the account with the given permission.
+// The owner can do anything, other users need an accepted membership granting at least that permission.
+// It returns ErrAccessDenied otherwise.
+func AuthorizeAccount(ctx context.Context, store db.Querier, account db.Account, username string, permission string) error {
+	if account.Owner == username {
+		return nil
+	}
+
+	member, err := store.GetAccountMember(ctx, db.GetAccountMemberParams{
+		AccountID: account.ID,
+		Username:  username,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return ErrAccessDenied
+		}
+		return err
+	}
+
+	if member.Status != db.AccountMemberStatusActive ||
+		permissionLevels[member.Permission] < permissionLevels[permission] {
+		return ErrAccessDenied
+	}
+
+	return nil
+}
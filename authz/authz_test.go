@@ -0,0 +1,117 @@
+package authz
+
+import (
+	"context"
+	"testing"
+
+	mockdb "bitbucket.org/jessyw/go_simplebank/db/mock"
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizeAccount(t *testing.T) {
+	account := db.Account{
+		ID:       util.RandomInt(1, 1000),
+		Owner:    util.RandomOwner(),
+		Currency: util.USD,
+	}
+	username := util.RandomOwner()
+
+	testCases := []struct {
+		name       string
+		username   string
+		permission string
+		buildStubs func(store *mockdb.MockStore)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name:       "Owner",
+			username:   account.Owner,
+			permission: db.AccountPermissionManage,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:       "MemberWithHigherPermission",
+			username:   username,
+			permission: db.AccountPermissionView,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.GetAccountMemberParams{AccountID: account.ID, Username: username}
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.AccountMember{Permission: db.AccountPermissionTransact, Status: db.AccountMemberStatusActive}, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:       "MemberWithLowerPermission",
+			username:   username,
+			permission: db.AccountPermissionTransact,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.AccountMember{Permission: db.AccountPermissionView, Status: db.AccountMemberStatusActive}, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrAccessDenied)
+			},
+		},
+		{
+			name:       "PendingInvitation",
+			username:   username,
+			permission: db.AccountPermissionView,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.AccountMember{Permission: db.AccountPermissionManage, Status: db.AccountMemberStatusInvited}, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrAccessDenied)
+			},
+		},
+		{
+			name:       "NotMember",
+			username:   username,
+			permission: db.AccountPermissionView,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.AccountMember{}, db.ErrRecordNotFound)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrAccessDenied)
+			},
+		},
+		{
+			name:       "InternalError",
+			username:   username,
+			permission: db.AccountPermissionView,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountMember(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.AccountMember{}, context.DeadlineExceeded)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, context.DeadlineExceeded)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			err := AuthorizeAccount(context.Background(), store, account, tc.username, tc.permission)
+			tc.checkError(t, err)
+		})
+	}
+}
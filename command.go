@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"bitbucket.org/jessyw/go_simplebank/db/migration"
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/export"
+	"bitbucket.org/jessyw/go_simplebank/importer"
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/cobra"
+)
+
+// newRootCommand creates the command line of the application. Without a command, it runs the servers,
+// the other commands are one-off tasks of the operators, e.g. `simplebank import --account 1 statement.csv`
+func newRootCommand(config util.Config, connPool *pgxpool.Pool, store db.Store) *cobra.Command {
+	serveCmd := newServeCommand(config, connPool, store)
+
+	rootCmd := &cobra.Command{
+		Use:           "simplebank",
+		Short:         "Simple Bank servers and operator commands",
+		Args:          cobra.NoArgs,
+		RunE:          serveCmd.RunE,
+		SilenceErrors: true,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			// the arguments are valid, the usage doesn't help with the errors from now on
+			cmd.SilenceUsage = true
+		},
+	}
+
+	rootCmd.AddCommand(
+		serveCmd,
+		newMigrateCommand(config),
+		newSeedCommand(config, store),
+		newUserCommand(config, store),
+		newAccountCommand(store),
+		newTransferCommand(store),
+		newReconcileCommand(store),
+		newTokenCommand(config, store),
+		newImportCommand(store),
+		newExportCommand(store),
+	)
+
+	return rootCmd
+}
+
+// newServeCommand runs the servers and the background workers until the process is asked to stop
+func newServeCommand(config util.Config, connPool *pgxpool.Pool, store db.Store) *cobra.Command {
+	return &cobra.Command{
+		Use:   "serve",
+		Short: "Run the HTTP and gRPC servers, the default command",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if config.MigrateOnStartup {
+				err := migrateUp(config.DBSource)
+				if err != nil {
+					return fmt.Errorf("cannot migrate db: %w", err)
+				}
+			}
+
+			err := runServers(cmd.Context(), config, connPool, store)
+			connPool.Close()
+			slog.Info("database connections are closed")
+			return err
+		},
+	}
+}
+
+// newMigrateCommand applies or reverts the embedded migrations, or prints the version of the schema
+func newMigrateCommand(config util.Config) *cobra.Command {
+	migrateCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply or revert the database migrations",
+	}
+
+	upCmd := &cobra.Command{
+		Use:   "up",
+		Short: "Apply the migrations not applied yet",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMigration(cmd.OutOrStdout(), config, (*migration.Migrator).Up)
+		},
+	}
+
+	var all bool
+	downCmd := &cobra.Command{
+		Use:   "down [--all | <steps>]",
+		Short: "Revert the last migrations",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if all {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			steps := 0
+			if !all {
+				var err error
+				steps, err = strconv.Atoi(args[0])
+				if err != nil || steps <= 0 {
+					return fmt.Errorf("invalid number of steps %q", args[0])
+				}
+			}
+
+			return runMigration(cmd.OutOrStdout(), config, func(migrator *migration.Migrator) error {
+				return migrator.Down(steps)
+			})
+		},
+	}
+	downCmd.Flags().BoolVar(&all, "all", false, "revert all the migrations")
+
+	versionCmd := &cobra.Command{
+		Use:   "version",
+		Short: "Print the version of the database schema",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMigration(cmd.OutOrStdout(), config, nil)
+		},
+	}
+
+	migrateCmd.AddCommand(upCmd, downCmd, versionCmd)
+	return migrateCmd
+}
+
+// runMigration runs migrate, when it isn't nil, then prints the version of the schema
+func runMigration(w io.Writer, config util.Config, migrate func(*migration.Migrator) error) error {
+	migrator, err := migration.NewMigrator(config.DBSource)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	if migrate != nil {
+		err = migrate(migrator)
+		if err != nil {
+			return err
+		}
+	}
+
+	version, dirty, err := migrator.Version()
+	if err != nil {
+		return err
+	}
+	if dirty {
+		fmt.Fprintf(w, "%d (dirty)\n", version)
+		return nil
+	}
+	fmt.Fprintln(w, version)
+	return nil
+}
+
+// newImportCommand records the transactions of CSV or OFX statements on an account, one database transaction
+// per file, and prints the report of each file.
+func newImportCommand(store db.Store) *cobra.Command {
+	var accountID int64
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "import --account <id> [--format csv|ofx] <file>...",
+		Short: "Import the transactions of CSV or OFX statements into an account",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if accountID <= 0 {
+				return errors.New("an account is required")
+			}
+
+			fileImporter := importer.NewImporter(store)
+			for _, path := range args {
+				fileFormat := format
+				if fileFormat == "" {
+					fileFormat = strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+				}
+
+				report, err := importFile(cmd.Context(), fileImporter, accountID, fileFormat, path)
+				if err != nil {
+					return fmt.Errorf("cannot import %s: %w", path, err)
+				}
+
+				err = printJSON(cmd.OutOrStdout(), report)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+	cmd.Flags().Int64Var(&accountID, "account", 0, "ID of the account to record the transactions on")
+	cmd.Flags().StringVar(&format, "format", "", "csv or ofx, defaults to the extension of each file")
+	cmd.MarkFlagRequired("account")
+
+	return cmd
+}
+
+func importFile(ctx context.Context, fileImporter *importer.Importer, accountID int64, format string, path string) (importer.Report, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return importer.Report{}, err
+	}
+	defer file.Close()
+
+	return fileImporter.Import(ctx, accountID, format, file)
+}
+
+// newExportCommand writes the data of a user to a ZIP archive, e.g. to answer a data access request
+func newExportCommand(store db.Store) *cobra.Command {
+	var username string
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "export --user <username> [--output <file>]",
+		Short: "Export the data of a user as a ZIP archive",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := output
+			if path == "" {
+				path = username + ".zip"
+			}
+
+			file, err := os.Create(path)
+			if err != nil {
+				return err
+			}
+
+			err = export.NewExporter(store).WriteZip(cmd.Context(), username, file)
+			if err != nil {
+				file.Close()
+				os.Remove(path)
+				return fmt.Errorf("cannot export %s: %w", username, err)
+			}
+
+			err = file.Close()
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), path)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&username, "user", "", "username of the user to export")
+	cmd.Flags().StringVar(&output, "output", "", "path of the ZIP archive, defaults to <user>.zip")
+	cmd.MarkFlagRequired("user")
+
+	return cmd
+}
+
+// printJSON writes v as indented JSON, the output of the commands
+func printJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,453 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	osuser "os/user"
+	"strings"
+	"time"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/token"
+	"bitbucket.org/jessyw/go_simplebank/util"
+	bankvalidator "bitbucket.org/jessyw/go_simplebank/validator"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+// seedDeposit is the external reference of the deposit funding the accounts of the seed users,
+// importing it again on an account does nothing
+const seedDeposit = "seed"
+
+// seedUsers are the users created by the seed command, with a USD checking account each
+var seedUsers = []struct {
+	username string
+	role     string
+}{
+	{"alice", util.DepositorRole},
+	{"bob", util.DepositorRole},
+	{"banker", util.BankerRole},
+}
+
+// seedResult is what the seed command prints
+type seedResult struct {
+	Users    []string     `json:"users"`
+	Accounts []db.Account `json:"accounts"`
+}
+
+// newSeedCommand fills a development database with a few users and funded accounts. Running it again keeps
+// what already exists, so it can be run after every reset of the database.
+func newSeedCommand(config util.Config, store db.Store) *cobra.Command {
+	var password string
+	var deposit int64
+
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Create users and funded accounts for development",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if config.Environment == "production" {
+				return errors.New("the seed data is for development, not for production")
+			}
+
+			hashedPassword, err := util.HashPassword(password)
+			if err != nil {
+				return err
+			}
+
+			ctx := cmd.Context()
+			var result seedResult
+			for _, seedUser := range seedUsers {
+				_, err := store.CreateUserTx(ctx, db.CreateUserTxParams{
+					CreateUserParams: db.CreateUserParams{
+						Username:       seedUser.username,
+						HashedPassword: hashedPassword,
+						FullName:       strings.ToUpper(seedUser.username[:1]) + seedUser.username[1:],
+						Email:          seedUser.username + "@simplebank.local",
+					},
+					Role: seedUser.role,
+				})
+				if err != nil && db.ErrorCode(err) != db.UniqueViolation {
+					return fmt.Errorf("cannot create user %s: %w", seedUser.username, err)
+				}
+				result.Users = append(result.Users, seedUser.username)
+
+				account, err := seedAccount(ctx, store, seedUser.username, deposit)
+				if err != nil {
+					return fmt.Errorf("cannot create the account of %s: %w", seedUser.username, err)
+				}
+				result.Accounts = append(result.Accounts, account)
+			}
+
+			return printJSON(cmd.OutOrStdout(), result)
+		},
+	}
+	cmd.Flags().StringVar(&password, "password", "Secret123!", "password of the seed users")
+	cmd.Flags().Int64Var(&deposit, "deposit", 100_000, "amount deposited on each account, in minor units")
+
+	return cmd
+}
+
+// seedAccount creates the USD checking account of a seed user, unless it exists, and deposits the amount on it once
+func seedAccount(ctx context.Context, store db.Store, owner string, deposit int64) (db.Account, error) {
+	_, err := store.CreateAccount(ctx, db.CreateAccountParams{
+		Owner:    owner,
+		Currency: util.USD,
+		Product:  db.AccountProductChecking,
+	})
+	if err != nil && db.ErrorCode(err) != db.UniqueViolation {
+		return db.Account{}, err
+	}
+
+	accounts, err := store.ListOwnedAccounts(ctx, owner)
+	if err != nil {
+		return db.Account{}, err
+	}
+
+	for _, account := range accounts {
+		if account.Currency != util.USD || account.Product != db.AccountProductChecking {
+			continue
+		}
+
+		result, err := store.ImportEntriesTx(ctx, db.ImportEntriesTxParams{
+			AccountID: account.ID,
+			Entries: []db.ImportEntry{{
+				ExternalRef: seedDeposit,
+				Amount:      deposit,
+				PostedAt:    time.Now(),
+			}},
+		})
+		return result.Account, err
+	}
+
+	return db.Account{}, db.ErrRecordNotFound
+}
+
+// newUserCommand groups the commands managing users
+func newUserCommand(config util.Config, store db.Store) *cobra.Command {
+	userCmd := &cobra.Command{
+		Use:   "user",
+		Short: "Manage users",
+	}
+	userCmd.AddCommand(newUserCreateCommand(config, store))
+	return userCmd
+}
+
+// newUserCreateCommand creates a user, bankers included, which can't sign up by themselves.
+// The password is read from the standard input when it isn't given, to keep it out of the shell history.
+func newUserCreateCommand(config util.Config, store db.Store) *cobra.Command {
+	var arg db.CreateUserTxParams
+	var password string
+
+	cmd := &cobra.Command{
+		Use:   "create --username <username> --full-name <name> --email <email> [--role depositor|banker]",
+		Short: "Create a user",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if arg.Role != util.DepositorRole && arg.Role != util.BankerRole {
+				return fmt.Errorf("invalid role %q", arg.Role)
+			}
+
+			err := errors.Join(
+				bankvalidator.ValidateUsername(arg.Username),
+				bankvalidator.ValidateFullName(arg.FullName),
+				bankvalidator.ValidateEmail(arg.Email),
+			)
+			if err != nil {
+				return err
+			}
+
+			if !cmd.Flags().Changed("password") {
+				password, err = readPassword(cmd.InOrStdin())
+				if err != nil {
+					return err
+				}
+			}
+
+			passwordPolicy, err := bankvalidator.NewPasswordPolicy(config)
+			if err != nil {
+				return err
+			}
+
+			err = passwordPolicy.Validate(password, arg.Username, arg.Email)
+			if err != nil {
+				return fmt.Errorf("invalid password: %w", err)
+			}
+
+			arg.HashedPassword, err = util.HashPassword(password)
+			if err != nil {
+				return err
+			}
+
+			result, err := store.CreateUserTx(cmd.Context(), arg)
+			if err != nil {
+				if db.ErrorCode(err) == db.UniqueViolation {
+					return fmt.Errorf("user %s or email %s already exists", arg.Username, arg.Email)
+				}
+				return err
+			}
+
+			result.User.HashedPassword = ""
+			return printJSON(cmd.OutOrStdout(), result.User)
+		},
+	}
+	cmd.Flags().StringVar(&arg.Username, "username", "", "username of the user")
+	cmd.Flags().StringVar(&arg.FullName, "full-name", "", "full name of the user")
+	cmd.Flags().StringVar(&arg.Email, "email", "", "email of the user")
+	cmd.Flags().StringVar(&arg.Role, "role", util.DepositorRole, "depositor or banker")
+	cmd.Flags().StringVar(&password, "password", "", "password of the user, read from the standard input when missing")
+	cmd.MarkFlagRequired("username")
+	cmd.MarkFlagRequired("full-name")
+	cmd.MarkFlagRequired("email")
+
+	return cmd
+}
+
+// readPassword reads the password on the first line of r
+func readPassword(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("a password is required, on the standard input or with --password")
+	}
+	return password, nil
+}
+
+// newAccountCommand groups the commands managing accounts
+func newAccountCommand(store db.Store) *cobra.Command {
+	accountCmd := &cobra.Command{
+		Use:   "account",
+		Short: "Manage accounts",
+	}
+	accountCmd.AddCommand(newAccountCreateCommand(store))
+	return accountCmd
+}
+
+// newAccountCreateCommand opens an account for a user, with a zero balance
+func newAccountCreateCommand(store db.Store) *cobra.Command {
+	arg := db.CreateAccountParams{Balance: 0}
+
+	cmd := &cobra.Command{
+		Use:   "create --owner <username> --currency <currency> [--product checking|savings]",
+		Short: "Create an account",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := bankvalidator.ValidateCurrency(arg.Currency)
+			if err != nil {
+				return err
+			}
+			if arg.Product != db.AccountProductChecking && arg.Product != db.AccountProductSavings {
+				return fmt.Errorf("invalid product %q", arg.Product)
+			}
+
+			account, err := store.CreateAccount(cmd.Context(), arg)
+			if err != nil {
+				switch db.ErrorCode(err) {
+				case db.ForeignKeyViolation:
+					return fmt.Errorf("user %s doesn't exist", arg.Owner)
+				case db.UniqueViolation:
+					return fmt.Errorf("%s already has a %s %s account", arg.Owner, arg.Currency, arg.Product)
+				}
+				return err
+			}
+
+			return printJSON(cmd.OutOrStdout(), account)
+		},
+	}
+	cmd.Flags().StringVar(&arg.Owner, "owner", "", "username of the owner")
+	cmd.Flags().StringVar(&arg.Currency, "currency", "", "currency of the account")
+	cmd.Flags().StringVar(&arg.Product, "product", db.AccountProductChecking, "checking or savings")
+	cmd.MarkFlagRequired("owner")
+	cmd.MarkFlagRequired("currency")
+
+	return cmd
+}
+
+// newTransferCommand moves money between two accounts of the same currency, e.g. to correct a mistake.
+// The transfer limits and the fees apply, the risk screening and the approvals of the API don't.
+func newTransferCommand(store db.Store) *cobra.Command {
+	var arg db.TransferTxParams
+
+	cmd := &cobra.Command{
+		Use:   "transfer --from <id> --to <id> --amount <amount>",
+		Short: "Transfer money between two accounts",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := errors.Join(
+				bankvalidator.ValidateAccountID(arg.FromAccountID),
+				bankvalidator.ValidateAccountID(arg.ToAccountID),
+				bankvalidator.ValidateAmount(arg.Amount),
+			)
+			if err != nil {
+				return err
+			}
+
+			ctx := cmd.Context()
+			fromAccount, err := store.GetAccount(ctx, arg.FromAccountID)
+			if err != nil {
+				return fmt.Errorf("cannot get account %d: %w", arg.FromAccountID, err)
+			}
+			toAccount, err := store.GetAccount(ctx, arg.ToAccountID)
+			if err != nil {
+				return fmt.Errorf("cannot get account %d: %w", arg.ToAccountID, err)
+			}
+			if fromAccount.Currency != toAccount.Currency {
+				return fmt.Errorf("account %d is in %s, account %d is in %s", fromAccount.ID, fromAccount.Currency, toAccount.ID, toAccount.Currency)
+			}
+
+			result, err := store.TransferTx(ctx, arg)
+			if err != nil {
+				return err
+			}
+
+			return printJSON(cmd.OutOrStdout(), result)
+		},
+	}
+	cmd.Flags().Int64Var(&arg.FromAccountID, "from", 0, "ID of the account sending the money")
+	cmd.Flags().Int64Var(&arg.ToAccountID, "to", 0, "ID of the account receiving the money")
+	cmd.Flags().Int64Var(&arg.Amount, "amount", 0, "amount to transfer, in minor units")
+	cmd.MarkFlagRequired("from")
+	cmd.MarkFlagRequired("to")
+	cmd.MarkFlagRequired("amount")
+
+	return cmd
+}
+
+// newReconcileCommand checks that the balance of every account is the sum of its entries,
+// and prints the accounts whose balance is off. It fails when there is one.
+func newReconcileCommand(store db.Store) *cobra.Command {
+	return &cobra.Command{
+		Use:   "reconcile",
+		Short: "Check the balances of the accounts against their entries",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			accounts, err := store.ListUnbalancedAccounts(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			err = printJSON(cmd.OutOrStdout(), accounts)
+			if err != nil {
+				return err
+			}
+
+			if len(accounts) > 0 {
+				return fmt.Errorf("the balance of %d accounts doesn't match their entries", len(accounts))
+			}
+			return nil
+		},
+	}
+}
+
+// tokenResponse is what the token issue command prints
+type tokenResponse struct {
+	AccessToken          string    `json:"access_token"`
+	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+}
+
+// newTokenCommand groups the commands managing tokens
+func newTokenCommand(config util.Config, store db.Store) *cobra.Command {
+	tokenCmd := &cobra.Command{
+		Use:   "token",
+		Short: "Manage access tokens",
+	}
+	tokenCmd.AddCommand(newTokenIssueCommand(config, store))
+	return tokenCmd
+}
+
+// newTokenIssueCommand issues an access token for a user with their role, without their password,
+// e.g. to call the API from scripts or while investigating an issue
+// maxIssuedTokenDuration is the longest an access token issued from the command line is valid,
+// since it skips the password and the second factor of the user
+const maxIssuedTokenDuration = time.Hour
+
+// tokenIssueDetails is what the audit log keeps about an issued token, but not the token itself
+type tokenIssueDetails struct {
+	TokenID   uuid.UUID `json:"token_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// currentOperator returns the name of the system user running the command, or an empty string when unknown
+func currentOperator() string {
+	current, err := osuser.Current()
+	if err != nil {
+		return ""
+	}
+	return current.Username
+}
+
+func newTokenIssueCommand(config util.Config, store db.Store) *cobra.Command {
+	var username string
+	var duration time.Duration
+	var operator string
+
+	cmd := &cobra.Command{
+		Use:   "issue --user <username> [--duration <duration>] [--operator <name>]",
+		Short: "Issue an access token for a user, recorded in the audit log of the user",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if duration <= 0 || duration > maxIssuedTokenDuration {
+				return fmt.Errorf("invalid duration %s, it must be positive and at most %s", duration, maxIssuedTokenDuration)
+			}
+			if operator == "" {
+				return errors.New("the operator issuing the token is unknown, set it with --operator")
+			}
+
+			user, err := store.GetUser(cmd.Context(), username)
+			if err != nil {
+				return fmt.Errorf("cannot get user %s: %w", username, err)
+			}
+			if user.ErasedAt.Valid {
+				return fmt.Errorf("user %s is erased", username)
+			}
+
+			tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+			if err != nil {
+				return err
+			}
+
+			accessToken, accessPayload, err := tokenMaker.CreateToken(user.Username, user.Role, duration)
+			if err != nil {
+				return err
+			}
+
+			// the token is only printed once its issue is recorded
+			data, err := json.Marshal(tokenIssueDetails{
+				TokenID:   accessPayload.ID,
+				ExpiresAt: accessPayload.ExpiredAt,
+			})
+			if err != nil {
+				return err
+			}
+
+			_, err = store.CreateAuditLog(cmd.Context(), db.CreateAuditLogParams{
+				Action:  db.AuditActionTokenIssued,
+				Actor:   operator,
+				Subject: user.Username,
+				Details: data,
+			})
+			if err != nil {
+				return fmt.Errorf("cannot record the issued token: %w", err)
+			}
+
+			return printJSON(cmd.OutOrStdout(), tokenResponse{
+				AccessToken:          accessToken,
+				AccessTokenExpiresAt: accessPayload.ExpiredAt,
+			})
+		},
+	}
+	cmd.Flags().StringVar(&username, "user", "", "username of the user")
+	cmd.Flags().DurationVar(&duration, "duration", config.AccessTokenDuration, fmt.Sprintf("how long the token is valid, at most %s", maxIssuedTokenDuration))
+	cmd.Flags().StringVar(&operator, "operator", currentOperator(), "name of the operator issuing the token, recorded in the audit log")
+	cmd.MarkFlagRequired("user")
+
+	return cmd
+}
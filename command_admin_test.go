@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "bitbucket.org/jessyw/go_simplebank/db/mock"
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/token"
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestConfig() util.Config {
+	return util.Config{
+		Environment:                 "development",
+		TokenSymmetricKey:           util.RandomString(32),
+		AccessTokenDuration:         time.Minute,
+		PasswordMinLength:           8,
+		PasswordMinCharacterClasses: 3,
+	}
+}
+
+// runTestCommand runs the command line with args, and returns what it printed
+func runTestCommand(config util.Config, store db.Store, stdin string, args ...string) (string, error) {
+	var stdout bytes.Buffer
+	cmd := newRootCommand(config, nil, store)
+	cmd.SetArgs(args)
+	cmd.SetIn(strings.NewReader(stdin))
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&bytes.Buffer{})
+
+	err := cmd.ExecuteContext(context.Background())
+	return stdout.String(), err
+}
+
+func TestUserCreateCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	password := "Xk7#" + util.RandomString(8)
+	store.EXPECT().
+		CreateUserTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, arg db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+			require.Equal(t, "banker1", arg.Username)
+			require.Equal(t, util.BankerRole, arg.Role)
+			_, err := util.CheckPassword(password, arg.HashedPassword)
+			require.NoError(t, err)
+
+			return db.CreateUserTxResult{User: db.User{
+				Username:       arg.Username,
+				HashedPassword: arg.HashedPassword,
+				FullName:       arg.FullName,
+				Email:          arg.Email,
+				Role:           arg.Role,
+			}}, nil
+		})
+
+	// the password is read from the standard input
+	output, err := runTestCommand(newTestConfig(), store, password+"\n",
+		"user", "create", "--username", "banker1", "--full-name", "Bank Er", "--email", "banker1@example.com", "--role", "banker")
+	require.NoError(t, err)
+
+	var user db.User
+	require.NoError(t, json.Unmarshal([]byte(output), &user))
+	require.Equal(t, "banker1", user.Username)
+	require.Equal(t, util.BankerRole, user.Role)
+	require.Empty(t, user.HashedPassword)
+}
+
+func TestUserCreateCommandInvalid(t *testing.T) {
+	testCases := []struct {
+		name string
+		args []string
+	}{
+		{
+			name: "InvalidRole",
+			args: []string{"--role", "admin", "--password", "Xk7#abcdefgh"},
+		},
+		{
+			name: "WeakPassword",
+			args: []string{"--password", "secret"},
+		},
+		{
+			name: "MissingPassword",
+			args: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(0)
+
+			args := append([]string{"user", "create", "--username", "user1", "--full-name", "User", "--email", "user1@example.com"}, tc.args...)
+			_, err := runTestCommand(newTestConfig(), store, "", args...)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestTransferCommand(t *testing.T) {
+	account1 := db.Account{ID: 1, Owner: "alice", Currency: util.USD, Balance: 1000}
+	account2 := db.Account{ID: 2, Owner: "bob", Currency: util.USD}
+	account3 := db.Account{ID: 3, Owner: "bob", Currency: util.EUR}
+
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), account1.ID).AnyTimes().Return(account1, nil)
+	store.EXPECT().GetAccount(gomock.Any(), account2.ID).AnyTimes().Return(account2, nil)
+	store.EXPECT().GetAccount(gomock.Any(), account3.ID).AnyTimes().Return(account3, nil)
+	store.EXPECT().
+		TransferTx(gomock.Any(), gomock.Eq(db.TransferTxParams{FromAccountID: 1, ToAccountID: 2, Amount: 10})).
+		Times(1).
+		Return(db.TransferTxResult{Transfer: db.Transfer{ID: 7, FromAccountID: 1, ToAccountID: 2, Amount: 10}}, nil)
+
+	output, err := runTestCommand(newTestConfig(), store, "", "transfer", "--from", "1", "--to", "2", "--amount", "10")
+	require.NoError(t, err)
+
+	var result db.TransferTxResult
+	require.NoError(t, json.Unmarshal([]byte(output), &result))
+	require.Equal(t, int64(7), result.Transfer.ID)
+
+	// the accounts have different currencies
+	_, err = runTestCommand(newTestConfig(), store, "", "transfer", "--from", "1", "--to", "3", "--amount", "10")
+	require.ErrorContains(t, err, "account 3 is in EUR")
+}
+
+func TestReconcileCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	unbalanced := []db.ListUnbalancedAccountsRow{{ID: 1, Owner: "alice", Currency: util.USD, Balance: 100, EntriesTotal: 90}}
+	gomock.InOrder(
+		store.EXPECT().ListUnbalancedAccounts(gomock.Any()).Return([]db.ListUnbalancedAccountsRow{}, nil),
+		store.EXPECT().ListUnbalancedAccounts(gomock.Any()).Return(unbalanced, nil),
+	)
+
+	_, err := runTestCommand(newTestConfig(), store, "", "reconcile")
+	require.NoError(t, err)
+
+	output, err := runTestCommand(newTestConfig(), store, "", "reconcile")
+	require.ErrorContains(t, err, "1 accounts")
+
+	var rows []db.ListUnbalancedAccountsRow
+	require.NoError(t, json.Unmarshal([]byte(output), &rows))
+	require.Equal(t, unbalanced, rows)
+}
+
+func TestTokenIssueCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	user := db.User{Username: "banker1", Role: util.BankerRole}
+	store.EXPECT().GetUser(gomock.Any(), user.Username).Times(1).Return(user, nil)
+
+	var auditLog db.CreateAuditLogParams
+	store.EXPECT().
+		CreateAuditLog(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.CreateAuditLogParams) (db.AuditLog, error) {
+			auditLog = arg
+			return db.AuditLog{ID: 1, Action: arg.Action, Actor: arg.Actor, Subject: arg.Subject, Details: arg.Details}, nil
+		})
+
+	config := newTestConfig()
+	output, err := runTestCommand(config, store, "", "token", "issue", "--user", user.Username, "--duration", "2m", "--operator", "ops1")
+	require.NoError(t, err)
+
+	var rsp tokenResponse
+	require.NoError(t, json.Unmarshal([]byte(output), &rsp))
+
+	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+	require.NoError(t, err)
+	payload, err := tokenMaker.VerifyToken(rsp.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, user.Username, payload.Username)
+	require.Equal(t, util.BankerRole, payload.Role)
+	require.WithinDuration(t, time.Now().Add(2*time.Minute), payload.ExpiredAt, time.Second)
+
+	// the audit log of the user tells who issued which token, without the token itself
+	require.Equal(t, db.AuditActionTokenIssued, auditLog.Action)
+	require.Equal(t, "ops1", auditLog.Actor)
+	require.Equal(t, user.Username, auditLog.Subject)
+	require.NotContains(t, string(auditLog.Details), rsp.AccessToken)
+
+	var details tokenIssueDetails
+	require.NoError(t, json.Unmarshal(auditLog.Details, &details))
+	require.Equal(t, payload.ID, details.TokenID)
+}
+
+func TestTokenIssueCommandInvalid(t *testing.T) {
+	testCases := []struct {
+		name string
+		args []string
+	}{
+		{
+			name: "DurationTooLong",
+			args: []string{"--duration", "2h", "--operator", "ops1"},
+		},
+		{
+			name: "NegativeDuration",
+			args: []string{"--duration", "-1m", "--operator", "ops1"},
+		},
+		{
+			name: "NoOperator",
+			args: []string{"--operator", ""},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			store.EXPECT().CreateAuditLog(gomock.Any(), gomock.Any()).Times(0)
+
+			args := append([]string{"token", "issue", "--user", "banker1"}, tc.args...)
+			output, err := runTestCommand(newTestConfig(), store, "", args...)
+			require.Error(t, err)
+			require.Empty(t, output)
+		})
+	}
+}
+
+func TestTokenIssueCommandAuditFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	user := db.User{Username: "banker1", Role: util.BankerRole}
+	store.EXPECT().GetUser(gomock.Any(), user.Username).Times(1).Return(user, nil)
+	store.EXPECT().CreateAuditLog(gomock.Any(), gomock.Any()).Times(1).Return(db.AuditLog{}, sql.ErrConnDone)
+
+	// a token whose issue isn't recorded is never printed
+	output, err := runTestCommand(newTestConfig(), store, "", "token", "issue", "--user", user.Username, "--operator", "ops1")
+	require.Error(t, err)
+	require.Empty(t, output)
+}
+
+func TestSeedCommandProduction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	config := newTestConfig()
+	config.Environment = "production"
+	_, err := runTestCommand(config, store, "", "seed")
+	require.Error(t, err)
+}
@@ -0,0 +1,42 @@
+CREATE OR REPLACE FUNCTION notify_entry_created() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify(
+        'account_events',
+        json_build_object(
+            'type', 'entry',
+            'account_id', NEW.account_id,
+            'entry_id', NEW.id,
+            'amount', NEW.amount,
+            'created_at', NEW.created_at
+        )::text
+    );
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION notify_balance_changed() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify(
+        'account_events',
+        json_build_object(
+            'type', 'balance',
+            'account_id', NEW.id,
+            'balance', NEW.balance,
+            'created_at', now()
+        )::text
+    );
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- entries written by TransferTx or by entry creation are published on commit
+CREATE TRIGGER "entries_notify_created"
+AFTER INSERT ON "entries"
+FOR EACH ROW
+EXECUTE FUNCTION notify_entry_created();
+
+CREATE TRIGGER "accounts_notify_balance_changed"
+AFTER UPDATE OF "balance" ON "accounts"
+FOR EACH ROW
+WHEN (OLD.balance IS DISTINCT FROM NEW.balance)
+EXECUTE FUNCTION notify_balance_changed();
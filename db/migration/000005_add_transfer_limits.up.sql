@@ -0,0 +1,36 @@
+CREATE TABLE "transfer_limits" (
+    "id" bigserial PRIMARY KEY,
+    "username" varchar,
+    "account_id" bigint,
+    "currency" varchar,
+    "kind" varchar NOT NULL,
+    "max_value" bigint NOT NULL,
+    "period" interval,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    CONSTRAINT "transfer_limits_kind_check" CHECK ("kind" IN ('amount', 'count')),
+    CONSTRAINT "transfer_limits_amount_currency_check" CHECK ("kind" = 'count' OR "currency" IS NOT NULL),
+    CONSTRAINT "transfer_limits_count_period_check" CHECK ("kind" = 'amount' OR "period" IS NOT NULL),
+    CONSTRAINT "transfer_limits_max_value_check" CHECK ("max_value" >= 0)
+);
+
+ALTER TABLE "transfer_limits"
+ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_limits"
+ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+CREATE INDEX ON "transfer_limits" ("username");
+
+CREATE INDEX ON "transfer_limits" ("account_id");
+
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
+
+COMMENT ON COLUMN "transfer_limits"."username" IS 'limit every account of this user, NULL for all users';
+
+COMMENT ON COLUMN "transfer_limits"."account_id" IS 'limit this account only, NULL for all accounts';
+
+COMMENT ON COLUMN "transfer_limits"."currency" IS 'NULL only for count limits, which apply to every currency';
+
+COMMENT ON COLUMN "transfer_limits"."kind" IS 'amount: total amount sent, count: number of transfers';
+
+COMMENT ON COLUMN "transfer_limits"."period" IS 'rolling window the limit is evaluated on, NULL for a per-transaction maximum';
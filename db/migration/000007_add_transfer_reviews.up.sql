@@ -0,0 +1,35 @@
+CREATE TABLE "transfer_reviews" (
+    "id" bigserial PRIMARY KEY,
+    "from_account_id" bigint NOT NULL,
+    "to_account_id" bigint NOT NULL,
+    "amount" bigint NOT NULL,
+    "requested_by" varchar NOT NULL,
+    "status" varchar NOT NULL DEFAULT 'pending_review',
+    "reasons" text[] NOT NULL,
+    "reviewed_by" varchar,
+    "reviewed_at" timestamptz,
+    "transfer_id" bigint,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    CONSTRAINT "transfer_reviews_status_check" CHECK ("status" IN ('pending_review', 'approved', 'rejected'))
+);
+
+ALTER TABLE "transfer_reviews"
+ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_reviews"
+ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_reviews"
+ADD FOREIGN KEY ("requested_by") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_reviews"
+ADD FOREIGN KEY ("reviewed_by") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_reviews"
+ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "transfer_reviews" ("status", "id");
+
+COMMENT ON COLUMN "transfer_reviews"."reasons" IS 'why the risk screening held the transfer';
+
+COMMENT ON COLUMN "transfer_reviews"."transfer_id" IS 'transfer executed once the review is approved';
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS "transfer_approvals";
+
+DROP TABLE IF EXISTS "transfer_requests";
+
+DROP TABLE IF EXISTS "approval_policies";
+
+DROP TABLE IF EXISTS "account_signatories";
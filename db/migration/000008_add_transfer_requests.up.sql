@@ -0,0 +1,76 @@
+CREATE TABLE "account_signatories" (
+    "account_id" bigint NOT NULL,
+    "username" varchar NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    PRIMARY KEY ("account_id", "username")
+);
+
+CREATE TABLE "approval_policies" (
+    "account_id" bigint PRIMARY KEY,
+    "threshold" bigint NOT NULL,
+    "required_approvals" integer NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    CONSTRAINT "approval_policies_threshold_check" CHECK ("threshold" >= 0),
+    CONSTRAINT "approval_policies_required_approvals_check" CHECK ("required_approvals" >= 1)
+);
+
+CREATE TABLE "transfer_requests" (
+    "id" bigserial PRIMARY KEY,
+    "from_account_id" bigint NOT NULL,
+    "to_account_id" bigint NOT NULL,
+    "amount" bigint NOT NULL,
+    "requested_by" varchar NOT NULL,
+    "status" varchar NOT NULL DEFAULT 'pending',
+    "required_approvals" integer NOT NULL,
+    "transfer_id" bigint,
+    "expires_at" timestamptz NOT NULL,
+    "updated_at" timestamptz NOT NULL DEFAULT (now()),
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    CONSTRAINT "transfer_requests_status_check" CHECK ("status" IN ('pending', 'approved', 'rejected', 'expired'))
+);
+
+CREATE TABLE "transfer_approvals" (
+    "transfer_request_id" bigint NOT NULL,
+    "username" varchar NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    PRIMARY KEY ("transfer_request_id", "username")
+);
+
+ALTER TABLE "account_signatories"
+ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "account_signatories"
+ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "approval_policies"
+ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_requests"
+ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_requests"
+ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_requests"
+ADD FOREIGN KEY ("requested_by") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_requests"
+ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "transfer_approvals"
+ADD FOREIGN KEY ("transfer_request_id") REFERENCES "transfer_requests" ("id");
+
+ALTER TABLE "transfer_approvals"
+ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "account_signatories" ("username");
+
+CREATE INDEX ON "transfer_requests" ("from_account_id", "status");
+
+COMMENT ON COLUMN "account_signatories"."username" IS 'user allowed to approve transfers requested on the account';
+
+COMMENT ON COLUMN "approval_policies"."threshold" IS 'transfers above this amount need approvals';
+
+COMMENT ON COLUMN "transfer_requests"."required_approvals" IS 'copied from the approval policy when the transfer is requested';
+
+COMMENT ON COLUMN "transfer_requests"."transfer_id" IS 'transfer executed once enough approvals are reached';
@@ -0,0 +1,27 @@
+CREATE TABLE "account_members" (
+    "account_id" bigint NOT NULL,
+    "username" varchar NOT NULL,
+    "permission" varchar NOT NULL,
+    "status" varchar NOT NULL DEFAULT 'invited',
+    "invited_by" varchar NOT NULL,
+    "accepted_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    PRIMARY KEY ("account_id", "username"),
+    CONSTRAINT "account_members_permission_check" CHECK ("permission" IN ('view', 'transact', 'manage')),
+    CONSTRAINT "account_members_status_check" CHECK ("status" IN ('invited', 'active'))
+);
+
+ALTER TABLE "account_members"
+ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "account_members"
+ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "account_members"
+ADD FOREIGN KEY ("invited_by") REFERENCES "users" ("username");
+
+CREATE INDEX ON "account_members" ("username", "status");
+
+COMMENT ON COLUMN "account_members"."permission" IS 'view: read the account, transact: also send money, manage: also manage members and settings';
+
+COMMENT ON COLUMN "account_members"."status" IS 'invited until the user accepts the invitation';
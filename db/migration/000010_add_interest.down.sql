@@ -0,0 +1,25 @@
+DROP TABLE IF EXISTS "interest_accruals";
+
+DROP TABLE IF EXISTS "interest_postings";
+
+DELETE FROM "entries"
+WHERE
+    "account_id" IN (
+        SELECT "account_id"
+        FROM "system_accounts"
+    );
+
+DROP TABLE IF EXISTS "system_accounts";
+
+DELETE FROM "accounts" WHERE "owner" = 'simplebank_interest';
+
+DELETE FROM "users" WHERE "username" = 'simplebank_interest';
+
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "owner_currency_product_key";
+
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "product";
+
+ALTER TABLE "accounts"
+ADD CONSTRAINT "owner_currency_key" UNIQUE ("owner", "currency");
+
+DROP TABLE IF EXISTS "account_products";
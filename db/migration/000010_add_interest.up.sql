@@ -0,0 +1,113 @@
+CREATE TABLE "account_products" (
+    "code" varchar PRIMARY KEY,
+    "name" varchar NOT NULL,
+    "annual_rate_bps" bigint NOT NULL DEFAULT 0,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    CONSTRAINT "account_products_annual_rate_bps_check" CHECK ("annual_rate_bps" >= 0)
+);
+
+INSERT INTO
+    "account_products" ("code", "name", "annual_rate_bps")
+VALUES ('checking', 'Checking', 0),
+    ('savings', 'Savings', 150);
+
+ALTER TABLE "accounts"
+ADD COLUMN "product" varchar NOT NULL DEFAULT 'checking';
+
+ALTER TABLE "accounts"
+ADD FOREIGN KEY ("product") REFERENCES "account_products" ("code");
+
+-- a user can hold one account of each product per currency
+ALTER TABLE "accounts" DROP CONSTRAINT "owner_currency_key";
+
+ALTER TABLE "accounts"
+ADD CONSTRAINT "owner_currency_product_key" UNIQUE ("owner", "currency", "product");
+
+CREATE TABLE "system_accounts" (
+    "purpose" varchar NOT NULL,
+    "currency" varchar NOT NULL,
+    "account_id" bigint UNIQUE NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    PRIMARY KEY ("purpose", "currency")
+);
+
+ALTER TABLE "system_accounts"
+ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+-- the bank pays interest from its own accounts, owned by a user nobody can log in as
+INSERT INTO
+    "users" (
+        "username",
+        "hashed_password",
+        "full_name",
+        "email"
+    )
+VALUES (
+        'simplebank_interest',
+        '',
+        'Simple Bank Interest Expense',
+        'interest@simplebank.internal'
+    );
+
+WITH
+    "created" AS (
+        INSERT INTO
+            "accounts" ("owner", "balance", "currency")
+        SELECT 'simplebank_interest', 0, "currency"
+        FROM unnest(ARRAY['USD', 'EUR', 'CAD']) AS "currency"
+        RETURNING
+            "id", "currency"
+    )
+INSERT INTO
+    "system_accounts" ("purpose", "currency", "account_id")
+SELECT 'interest_expense', "currency", "id"
+FROM "created";
+
+CREATE TABLE "interest_postings" (
+    "id" bigserial PRIMARY KEY,
+    "account_id" bigint NOT NULL,
+    "period" date NOT NULL,
+    "accrued_micros" bigint NOT NULL,
+    "amount" bigint NOT NULL,
+    "carry_micros" bigint NOT NULL,
+    "entry_id" bigint,
+    "expense_entry_id" bigint,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    CONSTRAINT "interest_postings_account_period_key" UNIQUE ("account_id", "period")
+);
+
+ALTER TABLE "interest_postings"
+ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "interest_postings"
+ADD FOREIGN KEY ("entry_id") REFERENCES "entries" ("id");
+
+ALTER TABLE "interest_postings"
+ADD FOREIGN KEY ("expense_entry_id") REFERENCES "entries" ("id");
+
+CREATE TABLE "interest_accruals" (
+    "account_id" bigint NOT NULL,
+    "accrual_date" date NOT NULL,
+    "balance" bigint NOT NULL,
+    "annual_rate_bps" bigint NOT NULL,
+    "amount_micros" bigint NOT NULL,
+    "posting_id" bigint,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    PRIMARY KEY ("account_id", "accrual_date")
+);
+
+ALTER TABLE "interest_accruals"
+ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "interest_accruals"
+ADD FOREIGN KEY ("posting_id") REFERENCES "interest_postings" ("id");
+
+COMMENT ON COLUMN "account_products"."annual_rate_bps" IS 'annual interest rate in basis points, 150 is 1.50%';
+
+COMMENT ON COLUMN "interest_postings"."period" IS 'first day of the month the interest was accrued in';
+
+COMMENT ON COLUMN "interest_postings"."accrued_micros" IS 'accruals of the period plus the carry of the previous posting, in millionths of a minor unit';
+
+COMMENT ON COLUMN "interest_postings"."carry_micros" IS 'fraction of a minor unit left over, carried to the next posting';
+
+COMMENT ON COLUMN "interest_accruals"."amount_micros" IS 'interest accrued for the day, in millionths of a minor unit';
@@ -0,0 +1,20 @@
+DELETE FROM "entries"
+WHERE
+    "account_id" IN (
+        SELECT "account_id"
+        FROM "system_accounts"
+        WHERE
+            "purpose" = 'fee_revenue'
+    );
+
+DELETE FROM "system_accounts" WHERE "purpose" = 'fee_revenue';
+
+DELETE FROM "accounts" WHERE "owner" = 'simplebank_fees';
+
+DELETE FROM "users" WHERE "username" = 'simplebank_fees';
+
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "fee";
+
+DROP TABLE IF EXISTS "fee_tiers";
+
+DROP TABLE IF EXISTS "fee_schedules";
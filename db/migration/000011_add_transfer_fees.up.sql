@@ -0,0 +1,75 @@
+CREATE TABLE "fee_schedules" (
+    "currency" varchar PRIMARY KEY,
+    "kind" varchar NOT NULL,
+    "flat_fee" bigint NOT NULL DEFAULT 0,
+    "rate_bps" bigint NOT NULL DEFAULT 0,
+    "min_fee" bigint NOT NULL DEFAULT 0,
+    "max_fee" bigint,
+    "updated_at" timestamptz NOT NULL DEFAULT (now()),
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    CONSTRAINT "fee_schedules_kind_check" CHECK ("kind" IN ('flat', 'percentage', 'tiered')),
+    CONSTRAINT "fee_schedules_fees_check" CHECK (
+        "flat_fee" >= 0
+        AND "rate_bps" >= 0
+        AND "min_fee" >= 0
+        AND (
+            "max_fee" IS NULL
+            OR "max_fee" >= "min_fee"
+        )
+    )
+);
+
+CREATE TABLE "fee_tiers" (
+    "currency" varchar NOT NULL,
+    "min_amount" bigint NOT NULL,
+    "flat_fee" bigint NOT NULL DEFAULT 0,
+    "rate_bps" bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY ("currency", "min_amount"),
+    CONSTRAINT "fee_tiers_fees_check" CHECK (
+        "min_amount" >= 0
+        AND "flat_fee" >= 0
+        AND "rate_bps" >= 0
+    )
+);
+
+ALTER TABLE "fee_tiers"
+ADD FOREIGN KEY ("currency") REFERENCES "fee_schedules" ("currency") ON DELETE CASCADE;
+
+ALTER TABLE "transfers" ADD COLUMN "fee" bigint NOT NULL DEFAULT 0;
+
+-- fees are credited to accounts of the bank, owned by a user nobody can log in as
+INSERT INTO
+    "users" (
+        "username",
+        "hashed_password",
+        "full_name",
+        "email"
+    )
+VALUES (
+        'simplebank_fees',
+        '',
+        'Simple Bank Fee Revenue',
+        'fees@simplebank.internal'
+    );
+
+WITH
+    "created" AS (
+        INSERT INTO
+            "accounts" ("owner", "balance", "currency")
+        SELECT 'simplebank_fees', 0, "currency"
+        FROM unnest(ARRAY['USD', 'EUR', 'CAD']) AS "currency"
+        RETURNING
+            "id", "currency"
+    )
+INSERT INTO
+    "system_accounts" ("purpose", "currency", "account_id")
+SELECT 'fee_revenue', "currency", "id"
+FROM "created";
+
+COMMENT ON COLUMN "fee_schedules"."kind" IS 'flat: flat_fee, percentage: flat_fee plus rate_bps of the amount, tiered: the fee of the tier the amount falls in';
+
+COMMENT ON COLUMN "fee_schedules"."max_fee" IS 'NULL for no maximum';
+
+COMMENT ON COLUMN "fee_tiers"."min_amount" IS 'the tier applies to amounts from min_amount up to the min_amount of the next tier';
+
+COMMENT ON COLUMN "transfers"."fee" IS 'charged to the sender on top of the amount';
@@ -0,0 +1,25 @@
+CREATE TABLE "transfer_reversals" (
+    "id" bigserial PRIMARY KEY,
+    "transfer_id" bigint NOT NULL,
+    "reversal_transfer_id" bigint UNIQUE NOT NULL,
+    "amount" bigint NOT NULL,
+    "reason" varchar NOT NULL DEFAULT '',
+    "reversed_by" varchar NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    CONSTRAINT "transfer_reversals_amount_check" CHECK ("amount" > 0)
+);
+
+ALTER TABLE "transfer_reversals"
+ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "transfer_reversals"
+ADD FOREIGN KEY ("reversal_transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "transfer_reversals"
+ADD FOREIGN KEY ("reversed_by") REFERENCES "users" ("username");
+
+CREATE INDEX ON "transfer_reversals" ("transfer_id");
+
+COMMENT ON COLUMN "transfer_reversals"."transfer_id" IS 'transfer being refunded';
+
+COMMENT ON COLUMN "transfer_reversals"."reversal_transfer_id" IS 'compensating transfer from the receiver back to the sender';
@@ -0,0 +1,5 @@
+ALTER TABLE "entries" ADD COLUMN "external_ref" varchar;
+
+CREATE UNIQUE INDEX ON "entries" ("account_id", "external_ref");
+
+COMMENT ON COLUMN "entries"."external_ref" IS 'reference of the transaction in the file it was imported from, NULL for entries created by the bank';
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "audit_logs";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "erased_at";
+
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "closed_at";
@@ -0,0 +1,22 @@
+ALTER TABLE "accounts" ADD COLUMN "closed_at" timestamptz;
+
+ALTER TABLE "users" ADD COLUMN "erased_at" timestamptz;
+
+CREATE TABLE "audit_logs" (
+    "id" bigserial PRIMARY KEY,
+    "action" varchar NOT NULL,
+    "actor" varchar NOT NULL,
+    "subject" varchar NOT NULL,
+    "details" jsonb NOT NULL DEFAULT '{}',
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "audit_logs" ("subject", "id");
+
+COMMENT ON COLUMN "accounts"."closed_at" IS 'NULL while the account is open, closed accounts keep their ledger but can''t send or receive money';
+
+COMMENT ON COLUMN "users"."erased_at" IS 'set when the personal data of the user is erased, the username is kept for the ledger';
+
+COMMENT ON COLUMN "audit_logs"."actor" IS 'user who performed the action, not a foreign key so that the trail outlives any user';
+
+COMMENT ON COLUMN "audit_logs"."subject" IS 'what the action was performed on, such as the username of an erased user';
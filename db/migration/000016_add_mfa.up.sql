@@ -0,0 +1,48 @@
+CREATE TABLE "totp_secrets" (
+    "username" varchar PRIMARY KEY,
+    "secret" varchar NOT NULL,
+    "enabled_at" timestamptz,
+    "last_used_step" bigint NOT NULL DEFAULT 0,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "recovery_codes" (
+    "id" bigserial PRIMARY KEY,
+    "username" varchar NOT NULL,
+    "code_hash" varchar NOT NULL,
+    "used_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "login_challenges" (
+    "id" bigserial PRIMARY KEY,
+    "username" varchar NOT NULL,
+    "token_hash" varchar UNIQUE NOT NULL,
+    "attempts" int NOT NULL DEFAULT 0,
+    "expires_at" timestamptz NOT NULL,
+    "used_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "totp_secrets"
+ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "recovery_codes"
+ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "login_challenges"
+ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE UNIQUE INDEX ON "recovery_codes" ("username", "code_hash");
+
+CREATE INDEX ON "login_challenges" ("username");
+
+COMMENT ON COLUMN "totp_secrets"."enabled_at" IS 'NULL until the user confirms the enrollment with a first code';
+
+COMMENT ON COLUMN "totp_secrets"."last_used_step" IS 'time step of the last accepted code, older steps are refused so that codes can''t be replayed';
+
+COMMENT ON COLUMN "recovery_codes"."code_hash" IS 'SHA-256 of the recovery code, each code can be used once instead of a TOTP code';
+
+COMMENT ON COLUMN "login_challenges"."token_hash" IS 'SHA-256 of the challenge token returned once the password is checked, the token is never stored';
+
+COMMENT ON COLUMN "login_challenges"."attempts" IS 'number of codes tried, the challenge is refused after too many';
@@ -0,0 +1,13 @@
+CREATE TABLE "login_throttles" (
+    "kind" varchar NOT NULL,
+    "key" varchar NOT NULL,
+    "failures" int NOT NULL DEFAULT 0,
+    "last_failed_at" timestamptz NOT NULL DEFAULT (now()),
+    PRIMARY KEY ("kind", "key")
+);
+
+COMMENT ON COLUMN "login_throttles"."kind" IS 'username or ip';
+
+COMMENT ON COLUMN "login_throttles"."key" IS 'the username or the client IP, usernames that don''t exist are tracked too';
+
+COMMENT ON COLUMN "login_throttles"."failures" IS 'failed logins in a row, counting starts again once the lockout duration passed without failure';
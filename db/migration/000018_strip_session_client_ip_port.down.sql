@@ -0,0 +1 @@
+-- the ports stripped from the client IPs of the sessions are not kept, there is nothing to revert
@@ -0,0 +1,8 @@
+-- gRPC sessions used to record the client address with its port, e.g. '203.0.113.7:52144' or '[2001:db8::1]:52144'
+UPDATE "sessions"
+SET "client_ip" = regexp_replace("client_ip", '^\[(.*)\]:[0-9]+$', '\1')
+WHERE "client_ip" ~ '^\[.*\]:[0-9]+$';
+
+UPDATE "sessions"
+SET "client_ip" = regexp_replace("client_ip", '^([^:]*):[0-9]+$', '\1')
+WHERE "client_ip" ~ '^[^:]*:[0-9]+$';
@@ -0,0 +1,40 @@
+package migration
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strconv"
+)
+
+// FS holds the migrations of the database schema, numbered in the order they are applied
+//
+//go:embed *.sql
+var FS embed.FS
+
+var fileNameRegexp = regexp.MustCompile(`^([0-9]+)_(.+)\.(up|down)\.sql$`)
+
+// LatestVersion returns the version of the last migration, the schema the queries of the application expect
+func LatestVersion() (uint, error) {
+	entries, err := fs.ReadDir(FS, ".")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint
+	for _, entry := range entries {
+		match := fileNameRegexp.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 0)
+		if err != nil {
+			return 0, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+		latest = max(latest, uint(version))
+	}
+
+	return latest, nil
+}
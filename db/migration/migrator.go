@@ -0,0 +1,96 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// lockTimeout is how long a migration waits for the one another replica is running.
+// Migrations take a Postgres advisory lock, so replicas starting together apply them one at a time.
+const lockTimeout = 5 * time.Minute
+
+// Migrator applies the embedded migrations to a database, recording its version in the schema_migrations table
+// like the migrate CLI does
+type Migrator struct {
+	migrate *migrate.Migrate
+}
+
+// NewMigrator creates a migrator of the database of the postgresql:// URL
+func NewMigrator(dbSource string) (*Migrator, error) {
+	source, err := iofs.New(FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("cannot read migrations: %w", err)
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", source, driverURL(dbSource))
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to db: %w", err)
+	}
+	m.LockTimeout = lockTimeout
+	m.Log = logger{}
+
+	return &Migrator{migrate: m}, nil
+}
+
+// driverURL makes the URL of the database select the pgx driver of migrate
+func driverURL(dbSource string) string {
+	for _, scheme := range []string{"postgresql://", "postgres://"} {
+		if rest, ok := strings.CutPrefix(dbSource, scheme); ok {
+			return "pgx5://" + rest
+		}
+	}
+	return dbSource
+}
+
+// Up applies the migrations not applied yet
+func (migrator *Migrator) Up() error {
+	return ignoreNoChange(migrator.migrate.Up())
+}
+
+// Down reverts the last steps migrations, all of them when steps is 0
+func (migrator *Migrator) Down(steps int) error {
+	if steps == 0 {
+		return ignoreNoChange(migrator.migrate.Down())
+	}
+	return ignoreNoChange(migrator.migrate.Steps(-steps))
+}
+
+// Version returns the version of the last migration applied, 0 when none is, and whether it failed halfway
+func (migrator *Migrator) Version() (version uint, dirty bool, err error) {
+	version, dirty, err = migrator.migrate.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// Close closes the connection to the database
+func (migrator *Migrator) Close() error {
+	sourceErr, dbErr := migrator.migrate.Close()
+	return errors.Join(sourceErr, dbErr)
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
+
+// logger writes the logs of migrate to the application logs
+type logger struct{}
+
+func (logger) Printf(format string, v ...any) {
+	slog.Info(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (logger) Verbose() bool {
+	return false
+}
@@ -1,7 +1,12 @@
 -- name: CreateAccount :one
 INSERT INTO
-    accounts (owner, balance, currency)
-VALUES ($1, $2, $3)
+    accounts (
+        owner,
+        balance,
+        currency,
+        product
+    )
+VALUES ($1, $2, $3, $4)
 RETURNING
     *;
 
@@ -16,6 +21,13 @@ SELECT *
 FROM accounts
 WHERE
     owner = $1
+    OR id IN (
+        SELECT account_id
+        FROM account_members
+        WHERE
+            username = $1
+            AND status = 'active'
+    )
 ORDER BY id
 LIMIT $2
 OFFSET
@@ -34,4 +46,32 @@ RETURNING
     *;
 
 -- name: DeleteAccount :exec
-DELETE FROM accounts WHERE id = $1;
\ No newline at end of file
+DELETE FROM accounts WHERE id = $1;
+
+-- name: ListOwnedAccounts :many
+SELECT * FROM accounts WHERE owner = $1 ORDER BY id;
+
+-- name: CloseAccount :one
+UPDATE accounts
+SET
+    closed_at = now()
+WHERE
+    id = $1
+    AND closed_at IS NULL
+RETURNING
+    *;
+
+-- name: ListUnbalancedAccounts :many
+SELECT
+    a.id,
+    a.owner,
+    a.currency,
+    a.balance,
+    COALESCE(SUM(e.amount), 0)::bigint AS entries_total
+FROM accounts a
+    LEFT JOIN entries e ON e.account_id = a.id
+GROUP BY
+    a.id
+HAVING
+    a.balance <> COALESCE(SUM(e.amount), 0)
+ORDER BY a.id;
@@ -0,0 +1,67 @@
+-- name: CreateAccountMember :one
+INSERT INTO
+    account_members (
+        account_id,
+        username,
+        permission,
+        invited_by
+    )
+VALUES ($1, $2, $3, $4)
+RETURNING
+    *;
+
+-- name: GetAccountMember :one
+SELECT *
+FROM account_members
+WHERE
+    account_id = $1
+    AND username = $2
+LIMIT 1;
+
+-- name: ListAccountMembers :many
+SELECT *
+FROM account_members
+WHERE
+    account_id = $1
+ORDER BY username;
+
+-- name: ListAccountInvitations :many
+SELECT *
+FROM account_members
+WHERE
+    username = $1
+    AND status = 'invited'
+ORDER BY created_at;
+
+-- name: AcceptAccountMember :one
+UPDATE account_members
+SET
+    status = 'active',
+    accepted_at = now()
+WHERE
+    account_id = $1
+    AND username = $2
+    AND status = 'invited'
+RETURNING
+    *;
+
+-- name: UpdateAccountMemberPermission :one
+UPDATE account_members
+SET
+    permission = $3
+WHERE
+    account_id = $1
+    AND username = $2
+RETURNING
+    *;
+
+-- name: DeleteAccountMember :exec
+DELETE FROM account_members
+WHERE
+    account_id = $1
+    AND username = $2;
+
+-- name: DeleteUserAccountMembers :execrows
+DELETE FROM account_members
+WHERE
+    username = $1;
@@ -0,0 +1,5 @@
+-- name: GetAccountProduct :one
+SELECT * FROM account_products WHERE code = $1 LIMIT 1;
+
+-- name: ListAccountProducts :many
+SELECT * FROM account_products ORDER BY code;
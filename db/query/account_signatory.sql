@@ -0,0 +1,32 @@
+-- name: CreateAccountSignatory :one
+INSERT INTO
+    account_signatories (account_id, username)
+VALUES ($1, $2)
+RETURNING
+    *;
+
+-- name: GetAccountSignatory :one
+SELECT *
+FROM account_signatories
+WHERE
+    account_id = $1
+    AND username = $2
+LIMIT 1;
+
+-- name: ListAccountSignatories :many
+SELECT *
+FROM account_signatories
+WHERE
+    account_id = $1
+ORDER BY username;
+
+-- name: DeleteAccountSignatory :exec
+DELETE FROM account_signatories
+WHERE
+    account_id = $1
+    AND username = $2;
+
+-- name: DeleteUserAccountSignatories :execrows
+DELETE FROM account_signatories
+WHERE
+    username = $1;
@@ -0,0 +1,21 @@
+-- name: UpsertApprovalPolicy :one
+INSERT INTO
+    approval_policies (
+        account_id,
+        threshold,
+        required_approvals
+    )
+VALUES ($1, $2, $3)
+ON CONFLICT (account_id) DO
+UPDATE
+SET
+    threshold = EXCLUDED.threshold,
+    required_approvals = EXCLUDED.required_approvals
+RETURNING
+    *;
+
+-- name: GetApprovalPolicy :one
+SELECT * FROM approval_policies WHERE account_id = $1 LIMIT 1;
+
+-- name: DeleteApprovalPolicy :exec
+DELETE FROM approval_policies WHERE account_id = $1;
@@ -0,0 +1,21 @@
+-- name: CreateAuditLog :one
+INSERT INTO
+    audit_logs (
+        action,
+        actor,
+        subject,
+        details
+    )
+VALUES ($1, $2, $3, $4)
+RETURNING
+    *;
+
+-- name: ListAuditLogs :many
+SELECT *
+FROM audit_logs
+WHERE
+    subject = $1
+ORDER BY id
+LIMIT $2
+OFFSET
+    $3;
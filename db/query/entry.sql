@@ -16,4 +16,22 @@ WHERE
 ORDER BY id
 LIMIT $2
 OFFSET
-    $3;
\ No newline at end of file
+    $3;
+
+-- name: CreateImportedEntry :one
+INSERT INTO
+    entries (
+        account_id,
+        amount,
+        external_ref,
+        created_at
+    )
+VALUES (
+        sqlc.arg (account_id),
+        sqlc.arg (amount),
+        sqlc.arg (external_ref)::varchar,
+        sqlc.arg (created_at)
+    )
+ON CONFLICT (account_id, external_ref) DO NOTHING
+RETURNING
+    *;
@@ -0,0 +1,130 @@
+-- name: ExportSessions :many
+SELECT *
+FROM sessions
+WHERE
+    username = sqlc.arg (username)
+    AND id > sqlc.arg (after_id)
+ORDER BY id
+LIMIT sqlc.arg (limit);
+
+-- name: ExportAccounts :many
+SELECT *
+FROM accounts
+WHERE
+    owner = sqlc.arg (owner)
+    AND id > sqlc.arg (after_id)
+ORDER BY id
+LIMIT sqlc.arg (limit);
+
+-- name: ExportEntries :many
+SELECT e.*
+FROM entries e
+    JOIN accounts a ON a.id = e.account_id
+WHERE
+    a.owner = sqlc.arg (owner)
+    AND e.id > sqlc.arg (after_id)
+ORDER BY e.id
+LIMIT sqlc.arg (limit);
+
+-- name: ExportTransfers :many
+SELECT t.*
+FROM transfers t
+WHERE (
+        t.from_account_id IN (
+            SELECT id
+            FROM accounts
+            WHERE
+                owner = sqlc.arg (owner)
+        )
+        OR t.to_account_id IN (
+            SELECT id
+            FROM accounts
+            WHERE
+                owner = sqlc.arg (owner)
+        )
+    )
+    AND t.id > sqlc.arg (after_id)
+ORDER BY t.id
+LIMIT sqlc.arg (limit);
+
+-- name: ExportAccountMembers :many
+SELECT *
+FROM account_members
+WHERE
+    username = sqlc.arg (username)
+    AND account_id > sqlc.arg (after_id)
+ORDER BY account_id
+LIMIT sqlc.arg (limit);
+
+-- name: ExportAccountSignatories :many
+SELECT *
+FROM account_signatories
+WHERE
+    username = sqlc.arg (username)
+    AND account_id > sqlc.arg (after_id)
+ORDER BY account_id
+LIMIT sqlc.arg (limit);
+
+-- name: ExportTransferRequests :many
+SELECT *
+FROM transfer_requests
+WHERE (
+        requested_by = sqlc.arg (username)
+        OR from_account_id IN (
+            SELECT id
+            FROM accounts
+            WHERE
+                owner = sqlc.arg (username)
+        )
+    )
+    AND id > sqlc.arg (after_id)
+ORDER BY id
+LIMIT sqlc.arg (limit);
+
+-- name: ExportTransferApprovals :many
+SELECT *
+FROM transfer_approvals
+WHERE
+    username = sqlc.arg (username)
+    AND transfer_request_id > sqlc.arg (after_id)
+ORDER BY transfer_request_id
+LIMIT sqlc.arg (limit);
+
+-- name: ExportTransferReviews :many
+SELECT *
+FROM transfer_reviews
+WHERE (
+        requested_by = sqlc.arg (username)
+        OR from_account_id IN (
+            SELECT id
+            FROM accounts
+            WHERE
+                owner = sqlc.arg (username)
+        )
+    )
+    AND id > sqlc.arg (after_id)
+ORDER BY id
+LIMIT sqlc.arg (limit);
+
+-- name: ExportAuditLogs :many
+SELECT *
+FROM audit_logs
+WHERE (
+        subject = sqlc.arg (username)
+        OR actor = sqlc.arg (username)
+    )
+    AND id > sqlc.arg (after_id)
+ORDER BY id
+LIMIT sqlc.arg (limit);
+
+-- name: ExportPasswordResets :many
+SELECT *
+FROM password_resets
+WHERE
+    username = sqlc.arg (username)
+    AND id > sqlc.arg (after_id)
+ORDER BY id
+LIMIT sqlc.arg (limit);
+
+-- name: ExportRecoveryCodes :many
+SELECT * FROM recovery_codes WHERE username = $1 ORDER BY id;
@@ -0,0 +1,49 @@
+-- name: UpsertFeeSchedule :one
+INSERT INTO
+    fee_schedules (
+        currency,
+        kind,
+        flat_fee,
+        rate_bps,
+        min_fee,
+        max_fee
+    )
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (currency) DO
+UPDATE
+SET
+    kind = EXCLUDED.kind,
+    flat_fee = EXCLUDED.flat_fee,
+    rate_bps = EXCLUDED.rate_bps,
+    min_fee = EXCLUDED.min_fee,
+    max_fee = EXCLUDED.max_fee,
+    updated_at = now()
+RETURNING
+    *;
+
+-- name: GetFeeSchedule :one
+SELECT * FROM fee_schedules WHERE currency = $1 LIMIT 1;
+
+-- name: ListFeeSchedules :many
+SELECT * FROM fee_schedules ORDER BY currency;
+
+-- name: DeleteFeeSchedule :exec
+DELETE FROM fee_schedules WHERE currency = $1;
+
+-- name: CreateFeeTier :one
+INSERT INTO
+    fee_tiers (
+        currency,
+        min_amount,
+        flat_fee,
+        rate_bps
+    )
+VALUES ($1, $2, $3, $4)
+RETURNING
+    *;
+
+-- name: ListFeeTiers :many
+SELECT * FROM fee_tiers WHERE currency = $1 ORDER BY min_amount;
+
+-- name: DeleteFeeTiers :exec
+DELETE FROM fee_tiers WHERE currency = $1;
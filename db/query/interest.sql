@@ -0,0 +1,106 @@
+-- name: ListInterestBearingAccounts :many
+SELECT
+    a.id,
+    a.currency,
+    p.annual_rate_bps,
+    (
+        a.balance - COALESCE(
+            (
+                SELECT SUM(e.amount)
+                FROM entries e
+                WHERE
+                    e.account_id = a.id
+                    AND e.created_at >= sqlc.arg (day_end)
+            ),
+            0
+        )
+    )::bigint AS balance
+FROM accounts a
+    JOIN account_products p ON p.code = a.product
+WHERE
+    p.annual_rate_bps > 0
+    AND a.created_at < sqlc.arg (day_end)
+ORDER BY a.id;
+
+-- name: CreateInterestAccrual :execrows
+INSERT INTO
+    interest_accruals (
+        account_id,
+        accrual_date,
+        balance,
+        annual_rate_bps,
+        amount_micros
+    )
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (account_id, accrual_date) DO NOTHING;
+
+-- name: ListUnpostedInterestAccounts :many
+SELECT DISTINCT
+    account_id
+FROM interest_accruals
+WHERE
+    posting_id IS NULL
+    AND accrual_date >= sqlc.arg (period_start)
+    AND accrual_date < sqlc.arg (period_end)
+ORDER BY account_id;
+
+-- name: SumUnpostedInterestAccruals :one
+SELECT COALESCE(SUM(amount_micros), 0)::bigint
+FROM interest_accruals
+WHERE
+    account_id = sqlc.arg (account_id)
+    AND posting_id IS NULL
+    AND accrual_date >= sqlc.arg (period_start)
+    AND accrual_date < sqlc.arg (period_end);
+
+-- name: MarkInterestAccrualsPosted :exec
+UPDATE interest_accruals
+SET
+    posting_id = sqlc.arg (posting_id)
+WHERE
+    account_id = sqlc.arg (account_id)
+    AND posting_id IS NULL
+    AND accrual_date >= sqlc.arg (period_start)
+    AND accrual_date < sqlc.arg (period_end);
+
+-- name: CreateInterestPosting :one
+INSERT INTO
+    interest_postings (
+        account_id,
+        period,
+        accrued_micros,
+        amount,
+        carry_micros,
+        entry_id,
+        expense_entry_id
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING
+    *;
+
+-- name: GetInterestPosting :one
+SELECT *
+FROM interest_postings
+WHERE
+    account_id = $1
+    AND period = $2
+LIMIT 1;
+
+-- name: GetLastInterestPosting :one
+SELECT *
+FROM interest_postings
+WHERE
+    account_id = $1
+    AND period < $2
+ORDER BY period DESC
+LIMIT 1;
+
+-- name: ListInterestPostings :many
+SELECT *
+FROM interest_postings
+WHERE
+    account_id = $1
+ORDER BY period DESC
+LIMIT $2
+OFFSET
+    $3;
@@ -0,0 +1,73 @@
+-- name: GetLoginThrottle :one
+SELECT *
+FROM login_throttles
+WHERE
+    kind = $1
+    AND key = $2
+LIMIT 1;
+
+-- name: RecordLoginFailure :one
+INSERT INTO
+    login_throttles (
+        kind,
+        key,
+        failures,
+        last_failed_at
+    )
+VALUES (
+        sqlc.arg (kind),
+        sqlc.arg (key),
+        1,
+        now()
+    )
+ON CONFLICT (kind, key) DO
+UPDATE
+SET
+    failures = CASE
+        WHEN login_throttles.last_failed_at < sqlc.arg (reset_before) THEN 1
+        ELSE login_throttles.failures + 1
+    END,
+    last_failed_at = now()
+RETURNING
+    *;
+
+-- name: DeleteLoginThrottle :execrows
+DELETE FROM login_throttles WHERE kind = $1 AND key = $2;
+
+-- name: CreateLoginThrottle :one
+INSERT INTO
+    login_throttles (
+        kind,
+        key,
+        failures,
+        last_failed_at
+    )
+VALUES ($1, $2, 1, now())
+ON CONFLICT (kind, key) DO NOTHING
+RETURNING
+    *;
+
+-- name: ReserveLoginAttempt :one
+UPDATE login_throttles
+SET
+    failures = CASE
+        WHEN last_failed_at < sqlc.arg (reset_before) THEN 1
+        ELSE failures + 1
+    END,
+    last_failed_at = now()
+WHERE
+    kind = sqlc.arg (kind)
+    AND key = sqlc.arg (key)
+    AND failures = sqlc.arg (failures)
+    AND last_failed_at = sqlc.arg (last_failed_at)
+RETURNING
+    *;
+
+-- name: ReleaseLoginAttempt :exec
+UPDATE login_throttles
+SET
+    failures = failures - 1
+WHERE
+    kind = $1
+    AND key = $2
+    AND failures > 0;
@@ -0,0 +1,93 @@
+-- name: CreateTotpSecret :one
+INSERT INTO
+    totp_secrets (username, secret)
+VALUES ($1, $2)
+ON CONFLICT (username) DO
+UPDATE
+SET
+    secret = EXCLUDED.secret,
+    last_used_step = 0,
+    created_at = now()
+WHERE
+    totp_secrets.enabled_at IS NULL
+RETURNING
+    *;
+
+-- name: GetTotpSecret :one
+SELECT * FROM totp_secrets WHERE username = $1 LIMIT 1;
+
+-- name: EnableTotpSecret :one
+UPDATE totp_secrets
+SET
+    enabled_at = now(),
+    last_used_step = sqlc.arg (step)
+WHERE
+    username = sqlc.arg (username)
+    AND enabled_at IS NULL
+    AND last_used_step < sqlc.arg (step)
+RETURNING
+    *;
+
+-- name: UseTotpStep :one
+UPDATE totp_secrets
+SET
+    last_used_step = sqlc.arg (step)
+WHERE
+    username = sqlc.arg (username)
+    AND last_used_step < sqlc.arg (step)
+RETURNING
+    *;
+
+-- name: DeleteTotpSecret :exec
+DELETE FROM totp_secrets WHERE username = $1;
+
+-- name: CreateRecoveryCode :one
+INSERT INTO
+    recovery_codes (username, code_hash)
+VALUES ($1, $2)
+RETURNING
+    *;
+
+-- name: UseRecoveryCode :one
+UPDATE recovery_codes
+SET
+    used_at = now()
+WHERE
+    username = $1
+    AND code_hash = $2
+    AND used_at IS NULL
+RETURNING
+    *;
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes WHERE username = $1;
+
+-- name: CreateLoginChallenge :one
+INSERT INTO
+    login_challenges (
+        username,
+        token_hash,
+        expires_at
+    )
+VALUES ($1, $2, $3)
+RETURNING
+    *;
+
+-- name: AttemptLoginChallenge :one
+UPDATE login_challenges
+SET
+    attempts = attempts + 1
+WHERE
+    token_hash = $1
+RETURNING
+    *;
+
+-- name: UseLoginChallenge :one
+UPDATE login_challenges
+SET
+    used_at = now()
+WHERE
+    id = $1
+    AND used_at IS NULL
+RETURNING
+    *;
@@ -14,4 +14,28 @@ RETURNING
     *;
 
 -- name: GetSession :one
-SELECT * FROM sessions WHERE id = $1 LIMIT 1;
\ No newline at end of file
+SELECT * FROM sessions WHERE id = $1 LIMIT 1;
+
+-- name: GetSessionDeviceStats :one
+SELECT
+    COUNT(*)::bigint AS sessions,
+    (
+        COUNT(*) FILTER (
+            WHERE
+                client_ip = sqlc.arg (client_ip)::varchar
+                AND user_agent = sqlc.arg (user_agent)::varchar
+        )
+    )::bigint AS device_sessions
+FROM sessions
+WHERE
+    username = sqlc.arg (username)
+    AND created_at < sqlc.arg (created_before)::timestamptz;
+
+
+-- name: BlockUserSessions :execrows
+UPDATE sessions
+SET
+    is_blocked = true
+WHERE
+    username = $1
+    AND NOT is_blocked;
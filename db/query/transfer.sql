@@ -3,15 +3,19 @@ INSERT INTO
     transfers (
         from_account_id,
         to_account_id,
-        amount
+        amount,
+        fee
     )
-VALUES ($1, $2, $3)
+VALUES ($1, $2, $3, $4)
 RETURNING
     *;
 
 -- name: GetTransfer :one
 SELECT * FROM transfers WHERE id = $1 LIMIT 1;
 
+-- name: GetTransferForUpdate :one
+SELECT * FROM transfers WHERE id = $1 LIMIT 1 FOR NO KEY UPDATE;
+
 -- name: ListTransfers :many
 SELECT *
 FROM transfers
@@ -21,4 +25,36 @@ WHERE
 ORDER BY id
 LIMIT $3
 OFFSET
-    $4;
\ No newline at end of file
+    $4;
+
+-- name: GetAccountTransferHistory :one
+SELECT
+    COUNT(*)::bigint AS count,
+    COALESCE(AVG(amount), 0)::bigint AS average,
+    COALESCE(MAX(amount), 0)::bigint AS maximum
+FROM transfers
+WHERE
+    from_account_id = sqlc.arg (account_id)
+    AND created_at > sqlc.arg (since)::timestamptz;
+
+-- name: CountTransfersBetween :one
+SELECT COUNT(*)::bigint AS count
+FROM transfers
+WHERE
+    from_account_id = $1
+    AND to_account_id = $2;
+
+-- name: CountNewCounterparties :one
+SELECT COUNT(DISTINCT t.to_account_id)::bigint AS count
+FROM transfers t
+WHERE
+    t.from_account_id = sqlc.arg (from_account_id)
+    AND t.created_at > sqlc.arg (since)::timestamptz
+    AND NOT EXISTS (
+        SELECT 1
+        FROM transfers p
+        WHERE
+            p.from_account_id = t.from_account_id
+            AND p.to_account_id = t.to_account_id
+            AND p.created_at <= sqlc.arg (since)::timestamptz
+    );
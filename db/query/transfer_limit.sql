@@ -0,0 +1,55 @@
+-- name: CreateTransferLimit :one
+INSERT INTO
+    transfer_limits (
+        username,
+        account_id,
+        currency,
+        kind,
+        max_value,
+        period
+    )
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING
+    *;
+
+-- name: ListApplicableTransferLimits :many
+SELECT *
+FROM transfer_limits
+WHERE (
+        account_id IS NULL
+        OR account_id = sqlc.arg (account_id)::bigint
+    )
+    AND (
+        username IS NULL
+        OR username = sqlc.arg (owner)::varchar
+    )
+    AND (
+        currency IS NULL
+        OR currency = sqlc.arg (currency)::varchar
+    )
+ORDER BY id;
+
+-- name: DeleteTransferLimit :exec
+DELETE FROM transfer_limits WHERE id = $1;
+
+-- name: GetAccountTransferStats :one
+SELECT COUNT(*)::bigint AS count, COALESCE(SUM(amount), 0)::bigint AS total
+FROM transfers
+WHERE
+    from_account_id = sqlc.arg (account_id)
+    AND created_at > now() - sqlc.arg (period)::interval;
+
+-- name: GetOwnerTransferStats :one
+SELECT COUNT(*)::bigint AS count, COALESCE(SUM(t.amount), 0)::bigint AS total
+FROM transfers t
+    JOIN accounts a ON a.id = t.from_account_id
+WHERE
+    a.owner = sqlc.arg (owner)
+    AND (
+        sqlc.narg (currency)::varchar IS NULL
+        OR a.currency = sqlc.narg (currency)::varchar
+    )
+    AND t.created_at > now() - sqlc.arg (period)::interval;
+
+-- name: LockOwnerTransfers :exec
+SELECT pg_advisory_xact_lock(hashtext('transfers:' || sqlc.arg (owner)::varchar));
@@ -0,0 +1,56 @@
+-- name: CreateTransferRequest :one
+INSERT INTO
+    transfer_requests (
+        from_account_id,
+        to_account_id,
+        amount,
+        requested_by,
+        required_approvals,
+        expires_at
+    )
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING
+    *;
+
+-- name: GetTransferRequest :one
+SELECT * FROM transfer_requests WHERE id = $1 LIMIT 1;
+
+-- name: GetTransferRequestForUpdate :one
+SELECT * FROM transfer_requests WHERE id = $1 LIMIT 1 FOR NO KEY UPDATE;
+
+-- name: ListTransferRequests :many
+SELECT *
+FROM transfer_requests
+WHERE
+    from_account_id = $1
+    AND status = $2
+    -- the pending requests past their expiry are only marked as expired once acted on
+    AND (
+        status <> 'pending'
+        OR expires_at > now()
+    )
+ORDER BY id
+LIMIT $3
+OFFSET
+    $4;
+
+-- name: UpdateTransferRequestStatus :one
+UPDATE transfer_requests
+SET
+    status = sqlc.arg (status),
+    transfer_id = sqlc.narg (transfer_id),
+    updated_at = now()
+WHERE
+    id = sqlc.arg (id)
+RETURNING
+    *;
+
+-- name: CreateTransferApproval :one
+INSERT INTO
+    transfer_approvals (transfer_request_id, username)
+VALUES ($1, $2)
+RETURNING
+    *;
+
+-- name: CountTransferApprovals :one
+SELECT COUNT(*) FROM transfer_approvals WHERE transfer_request_id = $1;
@@ -0,0 +1,28 @@
+-- name: CreateTransferReversal :one
+INSERT INTO
+    transfer_reversals (
+        transfer_id,
+        reversal_transfer_id,
+        amount,
+        reason,
+        reversed_by
+    )
+VALUES ($1, $2, $3, $4, $5)
+RETURNING
+    *;
+
+-- name: ListTransferReversals :many
+SELECT * FROM transfer_reversals WHERE transfer_id = $1 ORDER BY id;
+
+-- name: GetTransferReversalByReversalTransfer :one
+SELECT *
+FROM transfer_reversals
+WHERE
+    reversal_transfer_id = $1
+LIMIT 1;
+
+-- name: SumTransferReversals :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total
+FROM transfer_reversals
+WHERE
+    transfer_id = $1;
@@ -0,0 +1,40 @@
+-- name: CreateTransferReview :one
+INSERT INTO
+    transfer_reviews (
+        from_account_id,
+        to_account_id,
+        amount,
+        requested_by,
+        reasons
+    )
+VALUES ($1, $2, $3, $4, $5)
+RETURNING
+    *;
+
+-- name: GetTransferReview :one
+SELECT * FROM transfer_reviews WHERE id = $1 LIMIT 1;
+
+-- name: GetTransferReviewForUpdate :one
+SELECT * FROM transfer_reviews WHERE id = $1 LIMIT 1 FOR NO KEY UPDATE;
+
+-- name: ListTransferReviews :many
+SELECT *
+FROM transfer_reviews
+WHERE
+    status = $1
+ORDER BY id
+LIMIT $2
+OFFSET
+    $3;
+
+-- name: UpdateTransferReview :one
+UPDATE transfer_reviews
+SET
+    status = sqlc.arg (status),
+    reviewed_by = sqlc.arg (reviewed_by)::varchar,
+    reviewed_at = now(),
+    transfer_id = sqlc.narg (transfer_id)
+WHERE
+    id = sqlc.arg (id)
+RETURNING
+    *;
@@ -11,4 +11,70 @@ RETURNING
     *;
 
 -- name: GetUser :one
-SELECT * FROM users WHERE username = $1 LIMIT 1;
\ No newline at end of file
+SELECT * FROM users WHERE username = $1 LIMIT 1;
+
+-- name: EraseUser :one
+UPDATE users
+SET
+    full_name = 'Erased user',
+    email = username || '@erased.invalid',
+    hashed_password = '',
+    erased_at = now()
+WHERE
+    username = $1
+    AND erased_at IS NULL
+RETURNING
+    *;
+
+-- name: IsUserErased :one
+SELECT (erased_at IS NOT NULL)::bool AS erased FROM users WHERE username = $1 LIMIT 1;
+
+-- name: GetUserByEmail :one
+SELECT * FROM users WHERE email = $1 LIMIT 1;
+
+-- name: UpdateUserPassword :one
+UPDATE users
+SET
+    hashed_password = sqlc.arg (hashed_password),
+    password_changed_at = now()
+WHERE
+    username = sqlc.arg (username)
+RETURNING
+    *;
+
+-- name: RehashUserPassword :exec
+UPDATE users
+SET
+    hashed_password = sqlc.arg (hashed_password)
+WHERE
+    username = sqlc.arg (username)
+    AND hashed_password = sqlc.arg (previous_hashed_password);
+
+-- name: UpdateUser :one
+UPDATE users
+SET
+    hashed_password = COALESCE(
+        sqlc.narg (hashed_password),
+        hashed_password
+    ),
+    password_changed_at = CASE
+        WHEN sqlc.narg (hashed_password)::varchar IS NULL THEN password_changed_at
+        ELSE now()
+    END,
+    full_name = COALESCE(sqlc.narg (full_name), full_name),
+    email = COALESCE(sqlc.narg (email), email)
+WHERE
+    username = sqlc.arg (username)
+    AND erased_at IS NULL
+RETURNING
+    *;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET
+    role = sqlc.arg (role)
+WHERE
+    username = sqlc.arg (username)
+    AND erased_at IS NULL
+RETURNING
+    *;
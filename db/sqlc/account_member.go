@@ -0,0 +1,17 @@
+package db
+
+const (
+	// AccountPermissionView lets a member read the account, its entries and events
+	AccountPermissionView = "view"
+	// AccountPermissionTransact also lets a member send money from the account
+	AccountPermissionTransact = "transact"
+	// AccountPermissionManage also lets a member manage the members and settings of the account
+	AccountPermissionManage = "manage"
+)
+
+const (
+	// AccountMemberStatusInvited is the status of a member who hasn't accepted the invitation yet
+	AccountMemberStatusInvited = "invited"
+	// AccountMemberStatusActive is the status of a member who accepted the invitation
+	AccountMemberStatusActive = "active"
+)
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAccountMember(t *testing.T, account Account, permission string) AccountMember {
+	user := createRandomUser(t)
+
+	arg := CreateAccountMemberParams{
+		AccountID:  account.ID,
+		Username:   user.Username,
+		Permission: permission,
+		InvitedBy:  account.Owner,
+	}
+
+	member, err := testStore.CreateAccountMember(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.AccountID, member.AccountID)
+	require.Equal(t, arg.Username, member.Username)
+	require.Equal(t, arg.Permission, member.Permission)
+	require.Equal(t, AccountMemberStatusInvited, member.Status)
+	require.False(t, member.AcceptedAt.Valid)
+	require.NotZero(t, member.CreatedAt)
+
+	return member
+}
+
+func TestAcceptAccountMember(t *testing.T) {
+	account := createRandomAccount(t)
+	member := createRandomAccountMember(t, account, AccountPermissionView)
+
+	invitations, err := testStore.ListAccountInvitations(context.Background(), member.Username)
+	require.NoError(t, err)
+	require.Len(t, invitations, 1)
+
+	accepted, err := testStore.AcceptAccountMember(context.Background(), AcceptAccountMemberParams{
+		AccountID: account.ID,
+		Username:  member.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountMemberStatusActive, accepted.Status)
+	require.True(t, accepted.AcceptedAt.Valid)
+
+	// an invitation can only be accepted once
+	_, err = testStore.AcceptAccountMember(context.Background(), AcceptAccountMemberParams{
+		AccountID: account.ID,
+		Username:  member.Username,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	invitations, err = testStore.ListAccountInvitations(context.Background(), member.Username)
+	require.NoError(t, err)
+	require.Empty(t, invitations)
+}
+
+func TestListAccountsIncludesSharedAccounts(t *testing.T) {
+	account := createRandomAccount(t)
+	member := createRandomAccountMember(t, account, AccountPermissionTransact)
+
+	arg := ListAccountsParams{
+		Owner:  member.Username,
+		Limit:  5,
+		Offset: 0,
+	}
+
+	// pending invitations don't share the account yet
+	accounts, err := testStore.ListAccounts(context.Background(), arg)
+	require.NoError(t, err)
+	require.Empty(t, accounts)
+
+	_, err = testStore.AcceptAccountMember(context.Background(), AcceptAccountMemberParams{
+		AccountID: account.ID,
+		Username:  member.Username,
+	})
+	require.NoError(t, err)
+
+	accounts, err = testStore.ListAccounts(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account.ID, accounts[0].ID)
+}
+
+func TestUpdateAndDeleteAccountMember(t *testing.T) {
+	account := createRandomAccount(t)
+	member := createRandomAccountMember(t, account, AccountPermissionView)
+
+	updated, err := testStore.UpdateAccountMemberPermission(context.Background(), UpdateAccountMemberPermissionParams{
+		AccountID:  account.ID,
+		Username:   member.Username,
+		Permission: AccountPermissionManage,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountPermissionManage, updated.Permission)
+
+	err = testStore.DeleteAccountMember(context.Background(), DeleteAccountMemberParams{
+		AccountID: account.ID,
+		Username:  member.Username,
+	})
+	require.NoError(t, err)
+
+	_, err = testStore.GetAccountMember(context.Background(), GetAccountMemberParams{
+		AccountID: account.ID,
+		Username:  member.Username,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
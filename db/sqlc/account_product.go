@@ -0,0 +1,8 @@
+package db
+
+const (
+	// AccountProductChecking is the default product, it doesn't earn interest
+	AccountProductChecking = "checking"
+	// AccountProductSavings earns interest at the annual rate of the product
+	AccountProductSavings = "savings"
+)
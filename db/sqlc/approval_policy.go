@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RequiresApproval reports whether a transfer of amount must be approved under the policy
+func (policy ApprovalPolicy) RequiresApproval(amount int64) bool {
+	return amount > policy.Threshold
+}
+
+// FindApprovalPolicy returns the approval policy of the account, and false when the account has none,
+// in which case its transfers never need approvals.
+func FindApprovalPolicy(ctx context.Context, q Querier, accountID int64) (ApprovalPolicy, bool, error) {
+	policy, err := q.GetApprovalPolicy(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return ApprovalPolicy{}, false, nil
+		}
+		return ApprovalPolicy{}, false, err
+	}
+	return policy, true, nil
+}
+
+// RequestTransferApprovalParams contains the input parameters of RequestTransferApproval
+type RequestTransferApprovalParams struct {
+	TransferTxParams
+	RequestedBy string `json:"requested_by"`
+	// ExpiresAt is when the request expires if it isn't approved by then
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// RequestTransferApproval holds the transfer for approvals when the approval policy of the source account requires them.
+// It returns false, without creating a request, when the transfer can be executed right away.
+func RequestTransferApproval(ctx context.Context, q Querier, arg RequestTransferApprovalParams) (TransferRequest, bool, error) {
+	policy, found, err := FindApprovalPolicy(ctx, q, arg.FromAccountID)
+	if err != nil || !found || !policy.RequiresApproval(arg.Amount) {
+		return TransferRequest{}, false, err
+	}
+
+	request, err := q.CreateTransferRequest(ctx, CreateTransferRequestParams{
+		FromAccountID:     arg.FromAccountID,
+		ToAccountID:       arg.ToAccountID,
+		Amount:            arg.Amount,
+		RequestedBy:       arg.RequestedBy,
+		RequiredApprovals: policy.RequiredApprovals,
+		ExpiresAt:         arg.ExpiresAt,
+	})
+	if err != nil {
+		return TransferRequest{}, false, err
+	}
+	return request, true, nil
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestTransferApproval(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	arg := RequestTransferApprovalParams{
+		TransferTxParams: TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        100,
+		},
+		RequestedBy: account1.Owner,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+
+	// accounts without a policy never need approvals
+	_, requested, err := RequestTransferApproval(context.Background(), testStore, arg)
+	require.NoError(t, err)
+	require.False(t, requested)
+
+	policy, err := testStore.UpsertApprovalPolicy(context.Background(), UpsertApprovalPolicyParams{
+		AccountID:         account1.ID,
+		Threshold:         100,
+		RequiredApprovals: 2,
+	})
+	require.NoError(t, err)
+
+	// the threshold itself doesn't need approvals
+	_, requested, err = RequestTransferApproval(context.Background(), testStore, arg)
+	require.NoError(t, err)
+	require.False(t, requested)
+
+	arg.Amount = policy.Threshold + 1
+	request, requested, err := RequestTransferApproval(context.Background(), testStore, arg)
+	require.NoError(t, err)
+	require.True(t, requested)
+	require.Equal(t, account1.ID, request.FromAccountID)
+	require.Equal(t, account2.ID, request.ToAccountID)
+	require.Equal(t, arg.Amount, request.Amount)
+	require.Equal(t, account1.Owner, request.RequestedBy)
+	require.Equal(t, policy.RequiredApprovals, request.RequiredApprovals)
+	require.WithinDuration(t, arg.ExpiresAt, request.ExpiresAt, time.Second)
+}
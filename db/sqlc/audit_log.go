@@ -0,0 +1,18 @@
+package db
+
+const (
+	// AuditActionUserErased is recorded when the personal data of a user is erased
+	AuditActionUserErased = "user.erased"
+	// AuditActionPasswordReset is recorded when a user resets their password with a reset token
+	AuditActionPasswordReset = "user.password_reset"
+	// AuditActionMFAEnabled is recorded when a user confirms their enrollment in two-factor authentication
+	AuditActionMFAEnabled = "user.mfa_enabled"
+	// AuditActionMFADisabled is recorded when the two-factor authentication of a user is turned off
+	AuditActionMFADisabled = "user.mfa_disabled"
+	// AuditActionUserUnlocked is recorded when a banker lifts the login lockout of a user
+	AuditActionUserUnlocked = "user.unlocked"
+	// AuditActionUserUpdated is recorded when a user or a banker changes the full name, email or password of a user
+	AuditActionUserUpdated = "user.updated"
+	// AuditActionTokenIssued is recorded when an operator issues an access token for a user from the command line
+	AuditActionTokenIssued = "user.token_issued"
+)
@@ -7,6 +7,7 @@ package db
 
 import (
 	"context"
+	"time"
 )
 
 const createEntry = `-- name: CreateEntry :one
@@ -14,7 +15,7 @@ INSERT INTO
     entries (account_id, amount)
 VALUES ($1, $2)
 RETURNING
-    id, account_id, amount, created_at
+    id, account_id, amount, created_at, external_ref
 `
 
 type CreateEntryParams struct {
@@ -30,12 +31,57 @@ func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ExternalRef,
+	)
+	return i, err
+}
+
+const createImportedEntry = `-- name: CreateImportedEntry :one
+INSERT INTO
+    entries (
+        account_id,
+        amount,
+        external_ref,
+        created_at
+    )
+VALUES (
+        $1,
+        $2,
+        $3::varchar,
+        $4
+    )
+ON CONFLICT (account_id, external_ref) DO NOTHING
+RETURNING
+    id, account_id, amount, created_at, external_ref
+`
+
+type CreateImportedEntryParams struct {
+	AccountID   int64     `json:"account_id"`
+	Amount      int64     `json:"amount"`
+	ExternalRef string    `json:"external_ref"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (q *Queries) CreateImportedEntry(ctx context.Context, arg CreateImportedEntryParams) (Entry, error) {
+	row := q.db.QueryRow(ctx, createImportedEntry,
+		arg.AccountID,
+		arg.Amount,
+		arg.ExternalRef,
+		arg.CreatedAt,
+	)
+	var i Entry
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ExternalRef,
 	)
 	return i, err
 }
 
 const getEntry = `-- name: GetEntry :one
-SELECT id, account_id, amount, created_at FROM entries WHERE id = $1 LIMIT 1
+SELECT id, account_id, amount, created_at, external_ref FROM entries WHERE id = $1 LIMIT 1
 `
 
 func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
@@ -46,12 +92,13 @@ func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ExternalRef,
 	)
 	return i, err
 }
 
 const listEntries = `-- name: ListEntries :many
-SELECT id, account_id, amount, created_at
+SELECT id, account_id, amount, created_at, external_ref
 FROM entries
 WHERE
     account_id = $1
@@ -81,6 +128,7 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.ExternalRef,
 		); err != nil {
 			return nil, err
 		}
@@ -8,8 +8,10 @@ import (
 )
 
 const (
-	ForeignKeyViolation = "23503"
-	UniqueViolation     = "23505"
+	ForeignKeyViolation  = "23503"
+	UniqueViolation      = "23505"
+	SerializationFailure = "40001"
+	DeadlockDetected     = "40P01"
 )
 
 var ErrRecordNotFound = pgx.ErrNoRows
@@ -3,9 +3,44 @@ package db
 import (
 	"context"
 	"fmt"
+
+	"bitbucket.org/jessyw/go_simplebank/metrics"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// maxTxAttempts is how many times a transaction is run when Postgres aborts it to resolve a conflict
+const maxTxAttempts = 3
+
+// execTx runs fn within a database transaction, and runs it again in a new transaction when Postgres aborts it
+// with a serialization failure or a deadlock. fn must therefore start over from scratch on every call,
+// resetting the results it captured from the previous attempt.
+// The span of the transaction covers its BEGIN and COMMIT, the queries of fn are traced in the context fn captured.
 func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "execTx")
+	defer span.End()
+
+	for attempt := 1; ; attempt++ {
+		err := store.runTx(ctx, fn)
+		code := ErrorCode(err)
+		if err == nil || attempt == maxTxAttempts || (code != SerializationFailure && code != DeadlockDetected) {
+			span.SetAttributes(attribute.Int("db.tx.attempts", attempt))
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return err
+		}
+
+		metrics.TxRetries.WithLabelValues(code).Inc()
+		span.AddEvent("retry", trace.WithAttributes(attribute.String("db.error_code", code)))
+	}
+}
+
+func (store *SQLStore) runTx(ctx context.Context, fn func(*Queries) error) error {
 	tx, err := store.connPool.Begin(ctx)
 	if err != nil {
 		return err
@@ -15,7 +50,28 @@ func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) erro
 	err = fn(q)
 	if err != nil {
 		if rbErr := tx.Rollback(ctx); rbErr != nil {
-			return fmt.Errorf("tx err: %v, rb err: %v", err, rbErr)
+			// wrapped so that execTx still sees the SQLSTATE code of err
+			return fmt.Errorf("tx err: %w, rb err: %v", err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ReadOnlyTx runs fn within a read-only REPEATABLE READ transaction, so that all the queries of fn
+// read the same snapshot of the database whatever is committed meanwhile.
+// Unlike execTx it never runs fn again, since fn may already have used what it read.
+func (store *SQLStore) ReadOnlyTx(ctx context.Context, fn func(Querier) error) error {
+	tx, err := store.connPool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return err
+	}
+
+	err = fn(New(tx))
+	if err != nil {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			return fmt.Errorf("tx err: %w, rb err: %v", err, rbErr)
 		}
 		return err
 	}
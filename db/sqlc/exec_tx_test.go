@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"bitbucket.org/jessyw/go_simplebank/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// raiseError makes Postgres abort the transaction with the given condition, as it does to resolve a conflict
+func raiseError(ctx context.Context, q *Queries, condition string) error {
+	_, err := q.db.Exec(ctx, fmt.Sprintf("DO $$ BEGIN RAISE EXCEPTION 'forced' USING ERRCODE = '%s'; END $$", condition))
+	return err
+}
+
+func TestExecTxRetry(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	store := testStore.(*SQLStore)
+	retries := testutil.ToFloat64(metrics.TxRetries.WithLabelValues(SerializationFailure))
+
+	attempts := 0
+	err := store.execTx(context.Background(), func(q *Queries) error {
+		attempts++
+		if attempts == 1 {
+			return raiseError(context.Background(), q, "serialization_failure")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, attempts)
+	require.Equal(t, retries+1, testutil.ToFloat64(metrics.TxRetries.WithLabelValues(SerializationFailure)))
+
+	var span sdktrace.ReadOnlySpan
+	for _, ended := range recorder.Ended() {
+		if ended.Name() == "execTx" {
+			span = ended
+		}
+	}
+	require.NotNil(t, span)
+	require.Contains(t, span.Attributes(), attribute.Int("db.tx.attempts", 2))
+}
+
+func TestExecTxRetryGivesUp(t *testing.T) {
+	store := testStore.(*SQLStore)
+	retries := testutil.ToFloat64(metrics.TxRetries.WithLabelValues(DeadlockDetected))
+
+	attempts := 0
+	err := store.execTx(context.Background(), func(q *Queries) error {
+		attempts++
+		return raiseError(context.Background(), q, "deadlock_detected")
+	})
+	require.Equal(t, DeadlockDetected, ErrorCode(err))
+	require.Equal(t, maxTxAttempts, attempts)
+	// the last attempt isn't retried
+	require.Equal(t, retries+maxTxAttempts-1, testutil.ToFloat64(metrics.TxRetries.WithLabelValues(DeadlockDetected)))
+}
+
+func TestExecTxNoRetry(t *testing.T) {
+	store := testStore.(*SQLStore)
+
+	attempts := 0
+	err := store.execTx(context.Background(), func(q *Queries) error {
+		attempts++
+		return raiseError(context.Background(), q, "unique_violation")
+	})
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+	require.Equal(t, 1, attempts)
+}
+
+func TestRunTxRollbackError(t *testing.T) {
+	store := testStore.(*SQLStore)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	err := store.runTx(ctx, func(q *Queries) error {
+		err := raiseError(ctx, q, "serialization_failure")
+		// the rollback fails too once the context is canceled
+		cancel()
+		return err
+	})
+	require.ErrorContains(t, err, "rb err")
+	require.Equal(t, SerializationFailure, ErrorCode(err))
+}
+
+func TestReadOnlyTx(t *testing.T) {
+	account := createRandomAccount(t)
+
+	err := testStore.ReadOnlyTx(context.Background(), func(q Querier) error {
+		before, err := q.GetAccount(context.Background(), account.ID)
+		require.NoError(t, err)
+
+		// a change committed meanwhile isn't seen by the snapshot
+		_, err = testStore.AddAccountBalance(context.Background(), AddAccountBalanceParams{ID: account.ID, Amount: 10})
+		require.NoError(t, err)
+
+		after, err := q.GetAccount(context.Background(), account.ID)
+		require.NoError(t, err)
+		require.Equal(t, before.Balance, after.Balance)
+
+		_, err = q.AddAccountBalance(context.Background(), AddAccountBalanceParams{ID: account.ID, Amount: 10})
+		require.Equal(t, "25006", ErrorCode(err)) // read_only_sql_transaction
+		return err
+	})
+	require.Error(t, err)
+
+	updated, err := testStore.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance+10, updated.Balance)
+}
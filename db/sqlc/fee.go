@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	// FeeKindFlat charges the same fee on every transfer
+	FeeKindFlat = "flat"
+	// FeeKindPercentage charges the flat fee plus a share of the amount
+	FeeKindPercentage = "percentage"
+	// FeeKindTiered charges the flat fee plus a share of the amount of the tier the amount falls in
+	FeeKindTiered = "tiered"
+)
+
+const basisPoints = 10_000
+
+// ComputeFee returns the fee charged on top of a transfer of amount under the fee schedule.
+// Tiers must be sorted by min_amount. The share of the amount is rounded to the nearest minor unit, halves up,
+// then the fee is raised to the minimum fee and capped to the maximum fee of the schedule.
+func ComputeFee(schedule FeeSchedule, tiers []FeeTier, amount int64) int64 {
+	var flatFee, rateBps int64
+	switch schedule.Kind {
+	case FeeKindFlat:
+		flatFee = schedule.FlatFee
+	case FeeKindPercentage:
+		flatFee, rateBps = schedule.FlatFee, schedule.RateBps
+	case FeeKindTiered:
+		for _, tier := range tiers {
+			if tier.MinAmount > amount {
+				break
+			}
+			flatFee, rateBps = tier.FlatFee, tier.RateBps
+		}
+	}
+
+	// splitting the amount keeps the multiplication from overflowing
+	share := amount/basisPoints*rateBps + (amount%basisPoints*rateBps+basisPoints/2)/basisPoints
+
+	fee := flatFee + share
+	if fee < schedule.MinFee {
+		fee = schedule.MinFee
+	}
+	if schedule.MaxFee.Valid && fee > schedule.MaxFee.Int64 {
+		fee = schedule.MaxFee.Int64
+	}
+	return fee
+}
+
+// TransferFee returns the fee of a transfer of amount in currency. Currencies without a fee schedule are free.
+func TransferFee(ctx context.Context, q Querier, currency string, amount int64) (int64, error) {
+	schedule, err := q.GetFeeSchedule(ctx, currency)
+	if err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	var tiers []FeeTier
+	if schedule.Kind == FeeKindTiered {
+		tiers, err = q.ListFeeTiers(ctx, currency)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return ComputeFee(schedule, tiers, amount), nil
+}
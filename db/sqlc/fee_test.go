@@ -0,0 +1,134 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeFee(t *testing.T) {
+	tiers := []FeeTier{
+		{MinAmount: 0, FlatFee: 50},
+		{MinAmount: 1_000, FlatFee: 0, RateBps: 100},
+		{MinAmount: 100_000, FlatFee: 0, RateBps: 50},
+	}
+
+	testCases := []struct {
+		name     string
+		schedule FeeSchedule
+		amount   int64
+		fee      int64
+	}{
+		{
+			name:     "Flat",
+			schedule: FeeSchedule{Kind: FeeKindFlat, FlatFee: 25, RateBps: 100},
+			amount:   10_000,
+			fee:      25,
+		},
+		{
+			name:     "Percentage",
+			schedule: FeeSchedule{Kind: FeeKindPercentage, FlatFee: 10, RateBps: 150},
+			amount:   10_000,
+			fee:      160,
+		},
+		{
+			name:     "PercentageRoundsHalfUp",
+			schedule: FeeSchedule{Kind: FeeKindPercentage, RateBps: 50},
+			amount:   100,
+			fee:      1,
+		},
+		{
+			name:     "PercentageRoundsDown",
+			schedule: FeeSchedule{Kind: FeeKindPercentage, RateBps: 49},
+			amount:   100,
+			fee:      0,
+		},
+		{
+			name:     "MinFee",
+			schedule: FeeSchedule{Kind: FeeKindPercentage, RateBps: 100, MinFee: 30},
+			amount:   1_000,
+			fee:      30,
+		},
+		{
+			name:     "MaxFee",
+			schedule: FeeSchedule{Kind: FeeKindPercentage, RateBps: 100, MaxFee: pgtype.Int8{Int64: 500, Valid: true}},
+			amount:   1_000_000,
+			fee:      500,
+		},
+		{
+			name:     "FirstTier",
+			schedule: FeeSchedule{Kind: FeeKindTiered},
+			amount:   999,
+			fee:      50,
+		},
+		{
+			name:     "MiddleTier",
+			schedule: FeeSchedule{Kind: FeeKindTiered},
+			amount:   1_000,
+			fee:      10,
+		},
+		{
+			name:     "LastTier",
+			schedule: FeeSchedule{Kind: FeeKindTiered},
+			amount:   200_000,
+			fee:      1_000,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.fee, ComputeFee(tc.schedule, tiers, tc.amount))
+		})
+	}
+}
+
+func TestTransferTxWithFee(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	_, err := testStore.SetFeeScheduleTx(context.Background(), SetFeeScheduleTxParams{
+		UpsertFeeScheduleParams: UpsertFeeScheduleParams{
+			Currency: account1.Currency,
+			Kind:     FeeKindTiered,
+		},
+		Tiers: []CreateFeeTierParams{
+			{MinAmount: 0, FlatFee: 2},
+			{MinAmount: 100, FlatFee: 5},
+		},
+	})
+	require.NoError(t, err)
+	defer testStore.DeleteFeeSchedule(context.Background(), account1.Currency)
+
+	feeAccount, err := testStore.GetSystemAccount(context.Background(), GetSystemAccountParams{
+		Purpose:  SystemAccountFeeRevenue,
+		Currency: account1.Currency,
+	})
+	require.NoError(t, err)
+	revenueBefore, err := testStore.GetAccount(context.Background(), feeAccount.AccountID)
+	require.NoError(t, err)
+
+	result, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), result.Fee)
+	require.Equal(t, int64(2), result.Transfer.Fee)
+	require.Equal(t, int64(-2), result.FeeEntry.Amount)
+	require.Equal(t, account1.ID, result.FeeEntry.AccountID)
+	require.Equal(t, account1.Balance-12, result.FromAccount.Balance)
+	require.Equal(t, account2.Balance+10, result.ToAccount.Balance)
+
+	revenueAfter, err := testStore.GetAccount(context.Background(), feeAccount.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, revenueBefore.Balance+2, revenueAfter.Balance)
+
+	fee, err := TransferFee(context.Background(), testStore, account1.Currency, 100)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), fee)
+}
@@ -0,0 +1,8 @@
+package db
+
+const (
+	// LoginThrottleKindUsername tracks the failed logins of a username
+	LoginThrottleKindUsername = "username"
+	// LoginThrottleKindIP tracks the failed logins from a client IP, whatever the username
+	LoginThrottleKindIP = "ip"
+)
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReserveLoginAttempt(t *testing.T) {
+	key := util.RandomOwner()
+
+	throttle, err := testStore.CreateLoginThrottle(context.Background(), CreateLoginThrottleParams{
+		Kind: LoginThrottleKindUsername,
+		Key:  key,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), throttle.Failures)
+
+	// the throttle exists now, a concurrent login must reserve against it instead
+	_, err = testStore.CreateLoginThrottle(context.Background(), CreateLoginThrottleParams{
+		Kind: LoginThrottleKindUsername,
+		Key:  key,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	arg := ReserveLoginAttemptParams{
+		ResetBefore:  time.Now().Add(-time.Hour),
+		Kind:         LoginThrottleKindUsername,
+		Key:          key,
+		Failures:     throttle.Failures,
+		LastFailedAt: throttle.LastFailedAt,
+	}
+	reserved, err := testStore.ReserveLoginAttempt(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), reserved.Failures)
+
+	// the same reservation fails once another one changed the throttle
+	_, err = testStore.ReserveLoginAttempt(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	err = testStore.ReleaseLoginAttempt(context.Background(), ReleaseLoginAttemptParams{
+		Kind: LoginThrottleKindUsername,
+		Key:  key,
+	})
+	require.NoError(t, err)
+
+	released, err := testStore.GetLoginThrottle(context.Background(), GetLoginThrottleParams{
+		Kind: LoginThrottleKindUsername,
+		Key:  key,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), released.Failures)
+}
+
+func TestReserveLoginAttemptAfterReset(t *testing.T) {
+	key := util.RandomOwner()
+
+	throttle, err := testStore.CreateLoginThrottle(context.Background(), CreateLoginThrottleParams{
+		Kind: LoginThrottleKindIP,
+		Key:  key,
+	})
+	require.NoError(t, err)
+
+	// failures older than the reset start over
+	reserved, err := testStore.ReserveLoginAttempt(context.Background(), ReserveLoginAttemptParams{
+		ResetBefore:  time.Now().Add(time.Minute),
+		Kind:         LoginThrottleKindIP,
+		Key:          key,
+		Failures:     throttle.Failures,
+		LastFailedAt: throttle.LastFailedAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), reserved.Failures)
+}
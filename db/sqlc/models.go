@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Account struct {
@@ -16,6 +17,55 @@ type Account struct {
 	Balance   int64     `json:"balance"`
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
+	Product   string    `json:"product"`
+	// NULL while the account is open, closed accounts keep their ledger but can't send or receive money
+	ClosedAt pgtype.Timestamptz `json:"closed_at"`
+}
+
+type AccountMember struct {
+	AccountID int64  `json:"account_id"`
+	Username  string `json:"username"`
+	// view: read the account, transact: also send money, manage: also manage members and settings
+	Permission string `json:"permission"`
+	// invited until the user accepts the invitation
+	Status     string             `json:"status"`
+	InvitedBy  string             `json:"invited_by"`
+	AcceptedAt pgtype.Timestamptz `json:"accepted_at"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+type AccountProduct struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+	// annual interest rate in basis points, 150 is 1.50%
+	AnnualRateBps int64     `json:"annual_rate_bps"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type AccountSignatory struct {
+	AccountID int64 `json:"account_id"`
+	// user allowed to approve transfers requested on the account
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ApprovalPolicy struct {
+	AccountID int64 `json:"account_id"`
+	// transfers above this amount need approvals
+	Threshold         int64     `json:"threshold"`
+	RequiredApprovals int32     `json:"required_approvals"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+type AuditLog struct {
+	ID     int64  `json:"id"`
+	Action string `json:"action"`
+	// user who performed the action, not a foreign key so that the trail outlives any user
+	Actor string `json:"actor"`
+	// what the action was performed on, such as the username of an erased user
+	Subject   string    `json:"subject"`
+	Details   []byte    `json:"details"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type Entry struct {
@@ -24,6 +74,97 @@ type Entry struct {
 	// can be negative or positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// reference of the transaction in the file it was imported from, NULL for entries created by the bank
+	ExternalRef pgtype.Text `json:"external_ref"`
+}
+
+type FeeSchedule struct {
+	Currency string `json:"currency"`
+	// flat: flat_fee, percentage: flat_fee plus rate_bps of the amount, tiered: the fee of the tier the amount falls in
+	Kind    string `json:"kind"`
+	FlatFee int64  `json:"flat_fee"`
+	RateBps int64  `json:"rate_bps"`
+	MinFee  int64  `json:"min_fee"`
+	// NULL for no maximum
+	MaxFee    pgtype.Int8 `json:"max_fee"`
+	UpdatedAt time.Time   `json:"updated_at"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+type FeeTier struct {
+	Currency string `json:"currency"`
+	// the tier applies to amounts from min_amount up to the min_amount of the next tier
+	MinAmount int64 `json:"min_amount"`
+	FlatFee   int64 `json:"flat_fee"`
+	RateBps   int64 `json:"rate_bps"`
+}
+
+type InterestAccrual struct {
+	AccountID     int64       `json:"account_id"`
+	AccrualDate   pgtype.Date `json:"accrual_date"`
+	Balance       int64       `json:"balance"`
+	AnnualRateBps int64       `json:"annual_rate_bps"`
+	// interest accrued for the day, in millionths of a minor unit
+	AmountMicros int64       `json:"amount_micros"`
+	PostingID    pgtype.Int8 `json:"posting_id"`
+	CreatedAt    time.Time   `json:"created_at"`
+}
+
+type InterestPosting struct {
+	ID        int64 `json:"id"`
+	AccountID int64 `json:"account_id"`
+	// first day of the month the interest was accrued in
+	Period pgtype.Date `json:"period"`
+	// accruals of the period plus the carry of the previous posting, in millionths of a minor unit
+	AccruedMicros int64 `json:"accrued_micros"`
+	Amount        int64 `json:"amount"`
+	// fraction of a minor unit left over, carried to the next posting
+	CarryMicros    int64       `json:"carry_micros"`
+	EntryID        pgtype.Int8 `json:"entry_id"`
+	ExpenseEntryID pgtype.Int8 `json:"expense_entry_id"`
+	CreatedAt      time.Time   `json:"created_at"`
+}
+
+type LoginChallenge struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// SHA-256 of the challenge token returned once the password is checked, the token is never stored
+	TokenHash string `json:"token_hash"`
+	// number of codes tried, the challenge is refused after too many
+	Attempts  int32              `json:"attempts"`
+	ExpiresAt time.Time          `json:"expires_at"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+type LoginThrottle struct {
+	// username or ip
+	Kind string `json:"kind"`
+	// the username or the client IP, usernames that don't exist are tracked too
+	Key string `json:"key"`
+	// failed logins in a row, counting starts again once the lockout duration passed without failure
+	Failures     int32     `json:"failures"`
+	LastFailedAt time.Time `json:"last_failed_at"`
+}
+
+type PasswordReset struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// SHA-256 of the token sent to the user, the token itself is never stored
+	TokenHash string    `json:"token_hash"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// set once the token resets the password, or when another token of the user does
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+type RecoveryCode struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// SHA-256 of the recovery code, each code can be used once instead of a TOTP code
+	CodeHash  string             `json:"code_hash"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt time.Time          `json:"created_at"`
 }
 
 type Session struct {
@@ -37,6 +178,23 @@ type Session struct {
 	CreatedAt    time.Time `json:"created_at"`
 }
 
+type SystemAccount struct {
+	Purpose   string    `json:"purpose"`
+	Currency  string    `json:"currency"`
+	AccountID int64     `json:"account_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TotpSecret struct {
+	Username string `json:"username"`
+	Secret   string `json:"secret"`
+	// NULL until the user confirms the enrollment with a first code
+	EnabledAt pgtype.Timestamptz `json:"enabled_at"`
+	// time step of the last accepted code, older steps are refused so that codes can't be replayed
+	LastUsedStep int64     `json:"last_used_step"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
 type Transfer struct {
 	ID            int64 `json:"id"`
 	FromAccountID int64 `json:"from_account_id"`
@@ -44,6 +202,74 @@ type Transfer struct {
 	// must be positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// charged to the sender on top of the amount
+	Fee int64 `json:"fee"`
+}
+
+type TransferApproval struct {
+	TransferRequestID int64     `json:"transfer_request_id"`
+	Username          string    `json:"username"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+type TransferLimit struct {
+	ID int64 `json:"id"`
+	// limit every account of this user, NULL for all users
+	Username pgtype.Text `json:"username"`
+	// limit this account only, NULL for all accounts
+	AccountID pgtype.Int8 `json:"account_id"`
+	// NULL only for count limits, which apply to every currency
+	Currency pgtype.Text `json:"currency"`
+	// amount: total amount sent, count: number of transfers
+	Kind     string `json:"kind"`
+	MaxValue int64  `json:"max_value"`
+	// rolling window the limit is evaluated on, NULL for a per-transaction maximum
+	Period    pgtype.Interval `json:"period"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type TransferRequest struct {
+	ID            int64  `json:"id"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	RequestedBy   string `json:"requested_by"`
+	Status        string `json:"status"`
+	// copied from the approval policy when the transfer is requested
+	RequiredApprovals int32 `json:"required_approvals"`
+	// transfer executed once enough approvals are reached
+	TransferID pgtype.Int8 `json:"transfer_id"`
+	ExpiresAt  time.Time   `json:"expires_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+type TransferReversal struct {
+	ID int64 `json:"id"`
+	// transfer being refunded
+	TransferID int64 `json:"transfer_id"`
+	// compensating transfer from the receiver back to the sender
+	ReversalTransferID int64     `json:"reversal_transfer_id"`
+	Amount             int64     `json:"amount"`
+	Reason             string    `json:"reason"`
+	ReversedBy         string    `json:"reversed_by"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+type TransferReview struct {
+	ID            int64  `json:"id"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	RequestedBy   string `json:"requested_by"`
+	Status        string `json:"status"`
+	// why the risk screening held the transfer
+	Reasons    []string           `json:"reasons"`
+	ReviewedBy pgtype.Text        `json:"reviewed_by"`
+	ReviewedAt pgtype.Timestamptz `json:"reviewed_at"`
+	// transfer executed once the review is approved
+	TransferID pgtype.Int8 `json:"transfer_id"`
+	CreatedAt  time.Time   `json:"created_at"`
 }
 
 type User struct {
@@ -53,4 +279,7 @@ type User struct {
 	Email             string    `json:"email"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
+	Role              string    `json:"role"`
+	// set when the personal data of the user is erased, the username is kept for the ledger
+	ErasedAt pgtype.Timestamptz `json:"erased_at"`
 }
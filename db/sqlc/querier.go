@@ -6,28 +6,138 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Querier interface {
+	AcceptAccountMember(ctx context.Context, arg AcceptAccountMemberParams) (AccountMember, error)
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	AttemptLoginChallenge(ctx context.Context, tokenHash string) (LoginChallenge, error)
+	BlockUserSessions(ctx context.Context, username string) (int64, error)
+	CloseAccount(ctx context.Context, id int64) (Account, error)
+	CountNewCounterparties(ctx context.Context, arg CountNewCounterpartiesParams) (int64, error)
+	CountTransferApprovals(ctx context.Context, transferRequestID int64) (int64, error)
+	CountTransfersBetween(ctx context.Context, arg CountTransfersBetweenParams) (int64, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateAccountMember(ctx context.Context, arg CreateAccountMemberParams) (AccountMember, error)
+	CreateAccountSignatory(ctx context.Context, arg CreateAccountSignatoryParams) (AccountSignatory, error)
+	CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) (AuditLog, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateFeeTier(ctx context.Context, arg CreateFeeTierParams) (FeeTier, error)
+	CreateImportedEntry(ctx context.Context, arg CreateImportedEntryParams) (Entry, error)
+	CreateInterestAccrual(ctx context.Context, arg CreateInterestAccrualParams) (int64, error)
+	CreateInterestPosting(ctx context.Context, arg CreateInterestPostingParams) (InterestPosting, error)
+	CreateLoginChallenge(ctx context.Context, arg CreateLoginChallengeParams) (LoginChallenge, error)
+	CreateLoginThrottle(ctx context.Context, arg CreateLoginThrottleParams) (LoginThrottle, error)
+	CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) (PasswordReset, error)
+	CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) (RecoveryCode, error)
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	CreateTotpSecret(ctx context.Context, arg CreateTotpSecretParams) (TotpSecret, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
+	CreateTransferApproval(ctx context.Context, arg CreateTransferApprovalParams) (TransferApproval, error)
+	CreateTransferLimit(ctx context.Context, arg CreateTransferLimitParams) (TransferLimit, error)
+	CreateTransferRequest(ctx context.Context, arg CreateTransferRequestParams) (TransferRequest, error)
+	CreateTransferReversal(ctx context.Context, arg CreateTransferReversalParams) (TransferReversal, error)
+	CreateTransferReview(ctx context.Context, arg CreateTransferReviewParams) (TransferReview, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	DeleteAccount(ctx context.Context, id int64) error
+	DeleteAccountMember(ctx context.Context, arg DeleteAccountMemberParams) error
+	DeleteAccountSignatory(ctx context.Context, arg DeleteAccountSignatoryParams) error
+	DeleteApprovalPolicy(ctx context.Context, accountID int64) error
+	DeleteFeeSchedule(ctx context.Context, currency string) error
+	DeleteFeeTiers(ctx context.Context, currency string) error
+	DeleteLoginThrottle(ctx context.Context, arg DeleteLoginThrottleParams) (int64, error)
+	DeleteRecoveryCodes(ctx context.Context, username string) error
+	DeleteTotpSecret(ctx context.Context, username string) error
+	DeleteTransferLimit(ctx context.Context, id int64) error
+	DeleteUserAccountMembers(ctx context.Context, username string) (int64, error)
+	DeleteUserAccountSignatories(ctx context.Context, username string) (int64, error)
+	EnableTotpSecret(ctx context.Context, arg EnableTotpSecretParams) (TotpSecret, error)
+	EraseUser(ctx context.Context, username string) (User, error)
+	ExportAccountMembers(ctx context.Context, arg ExportAccountMembersParams) ([]AccountMember, error)
+	ExportAccountSignatories(ctx context.Context, arg ExportAccountSignatoriesParams) ([]AccountSignatory, error)
+	ExportAccounts(ctx context.Context, arg ExportAccountsParams) ([]Account, error)
+	ExportAuditLogs(ctx context.Context, arg ExportAuditLogsParams) ([]AuditLog, error)
+	ExportEntries(ctx context.Context, arg ExportEntriesParams) ([]Entry, error)
+	ExportPasswordResets(ctx context.Context, arg ExportPasswordResetsParams) ([]PasswordReset, error)
+	ExportRecoveryCodes(ctx context.Context, username string) ([]RecoveryCode, error)
+	ExportSessions(ctx context.Context, arg ExportSessionsParams) ([]Session, error)
+	ExportTransferApprovals(ctx context.Context, arg ExportTransferApprovalsParams) ([]TransferApproval, error)
+	ExportTransferRequests(ctx context.Context, arg ExportTransferRequestsParams) ([]TransferRequest, error)
+	ExportTransferReviews(ctx context.Context, arg ExportTransferReviewsParams) ([]TransferReview, error)
+	ExportTransfers(ctx context.Context, arg ExportTransfersParams) ([]Transfer, error)
 	GetAccount(ctx context.Context, id int64) (Account, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
+	GetAccountMember(ctx context.Context, arg GetAccountMemberParams) (AccountMember, error)
+	GetAccountProduct(ctx context.Context, code string) (AccountProduct, error)
+	GetAccountSignatory(ctx context.Context, arg GetAccountSignatoryParams) (AccountSignatory, error)
+	GetAccountTransferHistory(ctx context.Context, arg GetAccountTransferHistoryParams) (GetAccountTransferHistoryRow, error)
+	GetAccountTransferStats(ctx context.Context, arg GetAccountTransferStatsParams) (GetAccountTransferStatsRow, error)
+	GetApprovalPolicy(ctx context.Context, accountID int64) (ApprovalPolicy, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetFeeSchedule(ctx context.Context, currency string) (FeeSchedule, error)
+	GetInterestPosting(ctx context.Context, arg GetInterestPostingParams) (InterestPosting, error)
+	GetLastInterestPosting(ctx context.Context, arg GetLastInterestPostingParams) (InterestPosting, error)
+	GetLoginThrottle(ctx context.Context, arg GetLoginThrottleParams) (LoginThrottle, error)
+	GetOwnerTransferStats(ctx context.Context, arg GetOwnerTransferStatsParams) (GetOwnerTransferStatsRow, error)
+	GetPasswordReset(ctx context.Context, tokenHash string) (PasswordReset, error)
+	GetPasswordResetForUpdate(ctx context.Context, tokenHash string) (PasswordReset, error)
 	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
+	GetSessionDeviceStats(ctx context.Context, arg GetSessionDeviceStatsParams) (GetSessionDeviceStatsRow, error)
+	GetSystemAccount(ctx context.Context, arg GetSystemAccountParams) (SystemAccount, error)
+	GetTotpSecret(ctx context.Context, username string) (TotpSecret, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error)
+	GetTransferRequest(ctx context.Context, id int64) (TransferRequest, error)
+	GetTransferRequestForUpdate(ctx context.Context, id int64) (TransferRequest, error)
+	GetTransferReversalByReversalTransfer(ctx context.Context, reversalTransferID int64) (TransferReversal, error)
+	GetTransferReview(ctx context.Context, id int64) (TransferReview, error)
+	GetTransferReviewForUpdate(ctx context.Context, id int64) (TransferReview, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
+	IsUserErased(ctx context.Context, username string) (bool, error)
+	ListAccountInvitations(ctx context.Context, username string) ([]AccountMember, error)
+	ListAccountMembers(ctx context.Context, accountID int64) ([]AccountMember, error)
+	ListAccountProducts(ctx context.Context) ([]AccountProduct, error)
+	ListAccountSignatories(ctx context.Context, accountID int64) ([]AccountSignatory, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	ListApplicableTransferLimits(ctx context.Context, arg ListApplicableTransferLimitsParams) ([]TransferLimit, error)
+	ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([]AuditLog, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	ListFeeSchedules(ctx context.Context) ([]FeeSchedule, error)
+	ListFeeTiers(ctx context.Context, currency string) ([]FeeTier, error)
+	ListInterestBearingAccounts(ctx context.Context, dayEnd time.Time) ([]ListInterestBearingAccountsRow, error)
+	ListInterestPostings(ctx context.Context, arg ListInterestPostingsParams) ([]InterestPosting, error)
+	ListOwnedAccounts(ctx context.Context, owner string) ([]Account, error)
+	ListTransferRequests(ctx context.Context, arg ListTransferRequestsParams) ([]TransferRequest, error)
+	ListTransferReversals(ctx context.Context, transferID int64) ([]TransferReversal, error)
+	ListTransferReviews(ctx context.Context, arg ListTransferReviewsParams) ([]TransferReview, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	ListUnbalancedAccounts(ctx context.Context) ([]ListUnbalancedAccountsRow, error)
+	ListUnpostedInterestAccounts(ctx context.Context, arg ListUnpostedInterestAccountsParams) ([]int64, error)
+	LockOwnerTransfers(ctx context.Context, owner string) error
+	MarkInterestAccrualsPosted(ctx context.Context, arg MarkInterestAccrualsPostedParams) error
+	RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginThrottle, error)
+	RehashUserPassword(ctx context.Context, arg RehashUserPasswordParams) error
+	ReleaseLoginAttempt(ctx context.Context, arg ReleaseLoginAttemptParams) error
+	ReserveLoginAttempt(ctx context.Context, arg ReserveLoginAttemptParams) (LoginThrottle, error)
+	SumTransferReversals(ctx context.Context, transferID int64) (int64, error)
+	SumUnpostedInterestAccruals(ctx context.Context, arg SumUnpostedInterestAccrualsParams) (int64, error)
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateAccountMemberPermission(ctx context.Context, arg UpdateAccountMemberPermissionParams) (AccountMember, error)
+	UpdateTransferRequestStatus(ctx context.Context, arg UpdateTransferRequestStatusParams) (TransferRequest, error)
+	UpdateTransferReview(ctx context.Context, arg UpdateTransferReviewParams) (TransferReview, error)
+	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error)
+	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error)
+	UpsertApprovalPolicy(ctx context.Context, arg UpsertApprovalPolicyParams) (ApprovalPolicy, error)
+	UpsertFeeSchedule(ctx context.Context, arg UpsertFeeScheduleParams) (FeeSchedule, error)
+	UseLoginChallenge(ctx context.Context, id int64) (LoginChallenge, error)
+	UsePasswordResets(ctx context.Context, username string) error
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (RecoveryCode, error)
+	UseTotpStep(ctx context.Context, arg UseTotpStepParams) (TotpSecret, error)
 }
 
 var _ Querier = (*Queries)(nil)
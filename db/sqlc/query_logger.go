@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// QueryLogger is a pgx tracer logging the queries that fail, with the context they ran in,
+// so that the logs of a query carry the ID of the request that ran it
+type QueryLogger struct{}
+
+type queryStartKey struct{}
+
+type queryStart struct {
+	name string
+	at   time.Time
+}
+
+// TraceQueryStart implements pgx.QueryTracer
+func (QueryLogger) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryStartKey{}, queryStart{name: QueryName(data.SQL), at: time.Now()})
+}
+
+// TraceQueryEnd implements pgx.QueryTracer
+func (QueryLogger) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	if data.Err == nil || errors.Is(data.Err, ErrRecordNotFound) {
+		return
+	}
+
+	start, _ := ctx.Value(queryStartKey{}).(queryStart)
+	slog.ErrorContext(ctx, "query failed",
+		slog.String("query", start.name),
+		slog.Duration("duration", time.Since(start.at)),
+		slog.String("code", ErrorCode(data.Err)),
+		slog.Any("error", data.Err),
+	)
+}
+
+// QueryName returns the name sqlc gives a query in its first line, such as "-- name: GetAccount :one",
+// or the first word of the statements sqlc didn't generate, such as "begin"
+func QueryName(sql string) string {
+	sql = strings.TrimSpace(sql)
+	if name, ok := strings.CutPrefix(sql, "-- name: "); ok {
+		name, _, _ = strings.Cut(name, " ")
+		return name
+	}
+
+	word, _, _ := strings.Cut(sql, " ")
+	return strings.ToLower(word)
+}
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+
+// QueryTracer is a pgx tracer recording a span for each query, named after the sqlc query,
+// and logging the queries that fail like QueryLogger
+type QueryTracer struct {
+	QueryLogger
+}
+
+// TraceQueryStart implements pgx.QueryTracer
+func (tracer QueryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx = tracer.QueryLogger.TraceQueryStart(ctx, conn, data)
+	ctx, _ = otel.Tracer(tracerName).Start(ctx, QueryName(data.SQL),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBQueryText(data.SQL),
+		),
+	)
+	return ctx
+}
+
+// TraceQueryEnd implements pgx.QueryTracer
+func (tracer QueryTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil && !errors.Is(data.Err, ErrRecordNotFound) {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	} else {
+		span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	}
+	span.End()
+
+	tracer.QueryLogger.TraceQueryEnd(ctx, conn, data)
+}
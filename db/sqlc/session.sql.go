@@ -12,6 +12,23 @@ import (
 	"github.com/google/uuid"
 )
 
+const blockUserSessions = `-- name: BlockUserSessions :execrows
+UPDATE sessions
+SET
+    is_blocked = true
+WHERE
+    username = $1
+    AND NOT is_blocked
+`
+
+func (q *Queries) BlockUserSessions(ctx context.Context, username string) (int64, error) {
+	result, err := q.db.Exec(ctx, blockUserSessions, username)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const createSession = `-- name: CreateSession :one
 INSERT INTO
     sessions (
@@ -81,3 +98,43 @@ func (q *Queries) GetSession(ctx context.Context, id uuid.UUID) (Session, error)
 	)
 	return i, err
 }
+
+const getSessionDeviceStats = `-- name: GetSessionDeviceStats :one
+SELECT
+    COUNT(*)::bigint AS sessions,
+    (
+        COUNT(*) FILTER (
+            WHERE
+                client_ip = $1::varchar
+                AND user_agent = $2::varchar
+        )
+    )::bigint AS device_sessions
+FROM sessions
+WHERE
+    username = $3
+    AND created_at < $4::timestamptz
+`
+
+type GetSessionDeviceStatsParams struct {
+	ClientIp      string    `json:"client_ip"`
+	UserAgent     string    `json:"user_agent"`
+	Username      string    `json:"username"`
+	CreatedBefore time.Time `json:"created_before"`
+}
+
+type GetSessionDeviceStatsRow struct {
+	Sessions       int64 `json:"sessions"`
+	DeviceSessions int64 `json:"device_sessions"`
+}
+
+func (q *Queries) GetSessionDeviceStats(ctx context.Context, arg GetSessionDeviceStatsParams) (GetSessionDeviceStatsRow, error) {
+	row := q.db.QueryRow(ctx, getSessionDeviceStats,
+		arg.ClientIp,
+		arg.UserAgent,
+		arg.Username,
+		arg.CreatedBefore,
+	)
+	var i GetSessionDeviceStatsRow
+	err := row.Scan(&i.Sessions, &i.DeviceSessions)
+	return i, err
+}
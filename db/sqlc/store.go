@@ -10,7 +10,23 @@ import (
 type Store interface {
 	Querier
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
-	// CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error)
+	ReviewTransferTx(ctx context.Context, arg ReviewTransferTxParams) (ReviewTransferTxResult, error)
+	ApproveTransferRequestTx(ctx context.Context, arg ApproveTransferRequestTxParams) (ApproveTransferRequestTxResult, error)
+	RejectTransferRequestTx(ctx context.Context, arg RejectTransferRequestTxParams) (TransferRequest, error)
+	PostInterestTx(ctx context.Context, arg PostInterestTxParams) (PostInterestTxResult, error)
+	SetFeeScheduleTx(ctx context.Context, arg SetFeeScheduleTxParams) (SetFeeScheduleTxResult, error)
+	ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (ReverseTransferTxResult, error)
+	BatchTransferTx(ctx context.Context, arg BatchTransferTxParams) (BatchTransferTxResult, error)
+	ImportEntriesTx(ctx context.Context, arg ImportEntriesTxParams) (ImportEntriesTxResult, error)
+	EraseUserTx(ctx context.Context, arg EraseUserTxParams) (EraseUserTxResult, error)
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error)
+	EnableMFATx(ctx context.Context, arg EnableMFATxParams) (EnableMFATxResult, error)
+	DisableMFATx(ctx context.Context, arg DisableMFATxParams) error
+	UnlockUserTx(ctx context.Context, arg UnlockUserTxParams) (UnlockUserTxResult, error)
+	CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error)
+	UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (UpdateUserTxResult, error)
+	CloseAccountTx(ctx context.Context, arg CloseAccountTxParams) (Account, error)
+	ReadOnlyTx(ctx context.Context, fn func(Querier) error) error
 }
 
 // SQLStore provides all functions to execute SQL queries and transactions
@@ -0,0 +1,8 @@
+package db
+
+const (
+	// SystemAccountInterestExpense is the account the interest of savings accounts is paid from
+	SystemAccountInterestExpense = "interest_expense"
+	// SystemAccountFeeRevenue is the account the transfer fees are credited to
+	SystemAccountFeeRevenue = "fee_revenue"
+)
@@ -7,28 +7,84 @@ package db
 
 import (
 	"context"
+	"time"
 )
 
+const countNewCounterparties = `-- name: CountNewCounterparties :one
+SELECT COUNT(DISTINCT t.to_account_id)::bigint AS count
+FROM transfers t
+WHERE
+    t.from_account_id = $1
+    AND t.created_at > $2::timestamptz
+    AND NOT EXISTS (
+        SELECT 1
+        FROM transfers p
+        WHERE
+            p.from_account_id = t.from_account_id
+            AND p.to_account_id = t.to_account_id
+            AND p.created_at <= $2::timestamptz
+    )
+`
+
+type CountNewCounterpartiesParams struct {
+	FromAccountID int64     `json:"from_account_id"`
+	Since         time.Time `json:"since"`
+}
+
+func (q *Queries) CountNewCounterparties(ctx context.Context, arg CountNewCounterpartiesParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countNewCounterparties, arg.FromAccountID, arg.Since)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countTransfersBetween = `-- name: CountTransfersBetween :one
+SELECT COUNT(*)::bigint AS count
+FROM transfers
+WHERE
+    from_account_id = $1
+    AND to_account_id = $2
+`
+
+type CountTransfersBetweenParams struct {
+	FromAccountID int64 `json:"from_account_id"`
+	ToAccountID   int64 `json:"to_account_id"`
+}
+
+func (q *Queries) CountTransfersBetween(ctx context.Context, arg CountTransfersBetweenParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countTransfersBetween, arg.FromAccountID, arg.ToAccountID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO
     transfers (
         from_account_id,
         to_account_id,
-        amount
+        amount,
+        fee
     )
-VALUES ($1, $2, $3)
+VALUES ($1, $2, $3, $4)
 RETURNING
-    id, from_account_id, to_account_id, amount, created_at
+    id, from_account_id, to_account_id, amount, created_at, fee
 `
 
 type CreateTransferParams struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
 	Amount        int64 `json:"amount"`
+	Fee           int64 `json:"fee"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRow(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRow(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Fee,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -36,12 +92,42 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.Fee,
 	)
 	return i, err
 }
 
+const getAccountTransferHistory = `-- name: GetAccountTransferHistory :one
+SELECT
+    COUNT(*)::bigint AS count,
+    COALESCE(AVG(amount), 0)::bigint AS average,
+    COALESCE(MAX(amount), 0)::bigint AS maximum
+FROM transfers
+WHERE
+    from_account_id = $1
+    AND created_at > $2::timestamptz
+`
+
+type GetAccountTransferHistoryParams struct {
+	AccountID int64     `json:"account_id"`
+	Since     time.Time `json:"since"`
+}
+
+type GetAccountTransferHistoryRow struct {
+	Count   int64 `json:"count"`
+	Average int64 `json:"average"`
+	Maximum int64 `json:"maximum"`
+}
+
+func (q *Queries) GetAccountTransferHistory(ctx context.Context, arg GetAccountTransferHistoryParams) (GetAccountTransferHistoryRow, error) {
+	row := q.db.QueryRow(ctx, getAccountTransferHistory, arg.AccountID, arg.Since)
+	var i GetAccountTransferHistoryRow
+	err := row.Scan(&i.Count, &i.Average, &i.Maximum)
+	return i, err
+}
+
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers WHERE id = $1 LIMIT 1
+SELECT id, from_account_id, to_account_id, amount, created_at, fee FROM transfers WHERE id = $1 LIMIT 1
 `
 
 func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
@@ -53,12 +139,31 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.Fee,
+	)
+	return i, err
+}
+
+const getTransferForUpdate = `-- name: GetTransferForUpdate :one
+SELECT id, from_account_id, to_account_id, amount, created_at, fee FROM transfers WHERE id = $1 LIMIT 1 FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error) {
+	row := q.db.QueryRow(ctx, getTransferForUpdate, id)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.Fee,
 	)
 	return i, err
 }
 
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at
+SELECT id, from_account_id, to_account_id, amount, created_at, fee
 FROM transfers
 WHERE
     from_account_id = $1
@@ -96,6 +201,7 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.Fee,
 		); err != nil {
 			return nil, err
 		}
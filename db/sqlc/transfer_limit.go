@@ -0,0 +1,153 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// TransferLimitKindAmount limits the amount sent, in a single transfer or over a period
+	TransferLimitKindAmount = "amount"
+	// TransferLimitKindCount limits the number of transfers sent over a period
+	TransferLimitKindCount = "count"
+)
+
+// TransferLimitError is returned by TransferTx when a transfer would exceed a transfer limit
+type TransferLimitError struct {
+	Limit TransferLimit `json:"limit"`
+	// Current is the amount or the number of transfers over the limit period, this transfer included
+	Current int64 `json:"current"`
+}
+
+func (e *TransferLimitError) Error() string {
+	return fmt.Sprintf("transfer limit exceeded: %s", e.Reason())
+}
+
+// Reason describes the exceeded limit
+func (e *TransferLimitError) Reason() string {
+	limit := e.Limit
+	switch {
+	case limit.Kind == TransferLimitKindCount:
+		return fmt.Sprintf("at most %d transfers can be sent per %s, this would be transfer number %d",
+			limit.MaxValue, formatPeriod(limit.Period), e.Current)
+	case !limit.Period.Valid:
+		return fmt.Sprintf("amount %d %s exceeds the per-transaction maximum of %d %s",
+			e.Current, limit.Currency.String, limit.MaxValue, limit.Currency.String)
+	default:
+		return fmt.Sprintf("at most %d %s can be sent per %s, this transfer would bring the total to %d %s",
+			limit.MaxValue, limit.Currency.String, formatPeriod(limit.Period), e.Current, limit.Currency.String)
+	}
+}
+
+// Scope identifies who the exceeded limit applies to: one account, one user or every user
+func (e *TransferLimitError) Scope() string {
+	switch {
+	case e.Limit.AccountID.Valid:
+		return fmt.Sprintf("account:%d", e.Limit.AccountID.Int64)
+	case e.Limit.Username.Valid:
+		return fmt.Sprintf("user:%s", e.Limit.Username.String)
+	default:
+		return "global"
+	}
+}
+
+// checkTransferLimits returns a TransferLimitError if sending amount from the account exceeds one of its limits.
+// It must run after the account is locked so that concurrent transfers from the account can't slip past its limits.
+// The limits aggregating every account of the owner also take a lock on the owner until the end of the transaction,
+// as concurrent transfers from their other accounts don't wait for the lock of this one.
+func checkTransferLimits(ctx context.Context, q *Queries, fromAccount Account, amount int64) error {
+	limits, err := q.ListApplicableTransferLimits(ctx, ListApplicableTransferLimitsParams{
+		AccountID: fromAccount.ID,
+		Owner:     fromAccount.Owner,
+		Currency:  fromAccount.Currency,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, limit := range limits {
+		if limit.Period.Valid && !limit.AccountID.Valid {
+			err = q.LockOwnerTransfers(ctx, fromAccount.Owner)
+			if err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	for _, limit := range limits {
+		current, err := transferLimitUsage(ctx, q, limit, fromAccount, amount)
+		if err != nil {
+			return err
+		}
+
+		if current > limit.MaxValue {
+			return &TransferLimitError{
+				Limit:   limit,
+				Current: current,
+			}
+		}
+	}
+
+	return nil
+}
+
+// transferLimitUsage computes what the limit is compared against if the transfer goes through.
+// Limits set on an account aggregate the transfers of that account, the others aggregate the transfers of every account of the owner.
+func transferLimitUsage(ctx context.Context, q *Queries, limit TransferLimit, fromAccount Account, amount int64) (int64, error) {
+	if !limit.Period.Valid {
+		return amount, nil
+	}
+
+	var count, total int64
+	if limit.AccountID.Valid {
+		stats, err := q.GetAccountTransferStats(ctx, GetAccountTransferStatsParams{
+			AccountID: fromAccount.ID,
+			Period:    limit.Period,
+		})
+		if err != nil {
+			return 0, err
+		}
+		count, total = stats.Count, stats.Total
+	} else {
+		stats, err := q.GetOwnerTransferStats(ctx, GetOwnerTransferStatsParams{
+			Owner:    fromAccount.Owner,
+			Currency: limit.Currency,
+			Period:   limit.Period,
+		})
+		if err != nil {
+			return 0, err
+		}
+		count, total = stats.Count, stats.Total
+	}
+
+	if limit.Kind == TransferLimitKindCount {
+		return count + 1, nil
+	}
+	return total + amount, nil
+}
+
+func formatPeriod(period pgtype.Interval) string {
+	var parts []string
+	if period.Months == 1 {
+		parts = append(parts, "month")
+	} else if period.Months > 1 {
+		parts = append(parts, fmt.Sprintf("%d months", period.Months))
+	}
+
+	if period.Days == 1 {
+		parts = append(parts, "day")
+	} else if period.Days > 1 {
+		parts = append(parts, fmt.Sprintf("%d days", period.Days))
+	}
+
+	if period.Microseconds > 0 {
+		parts = append(parts, (time.Duration(period.Microseconds) * time.Microsecond).String())
+	}
+
+	return strings.Join(parts, " ")
+}
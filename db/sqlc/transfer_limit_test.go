@@ -0,0 +1,186 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAccountLimit(t *testing.T, account Account, kind string, maxValue int64, period pgtype.Interval) TransferLimit {
+	arg := CreateTransferLimitParams{
+		AccountID: pgtype.Int8{Int64: account.ID, Valid: true},
+		Currency:  pgtype.Text{String: account.Currency, Valid: true},
+		Kind:      kind,
+		MaxValue:  maxValue,
+		Period:    period,
+	}
+
+	limit, err := testStore.CreateTransferLimit(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, limit.ID)
+	require.Equal(t, arg.AccountID, limit.AccountID)
+	require.Equal(t, arg.Kind, limit.Kind)
+	require.Equal(t, arg.MaxValue, limit.MaxValue)
+	require.NotZero(t, limit.CreatedAt)
+
+	return limit
+}
+
+func TestTransferTxPerTransactionLimit(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	limit := createRandomAccountLimit(t, account1, TransferLimitKindAmount, 100, pgtype.Interval{})
+
+	_, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        101,
+	})
+	require.Error(t, err)
+
+	var limitErr *TransferLimitError
+	require.True(t, errors.As(err, &limitErr))
+	require.Equal(t, limit.ID, limitErr.Limit.ID)
+	require.Equal(t, int64(101), limitErr.Current)
+
+	_, err = testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+	})
+	require.NoError(t, err)
+}
+
+func TestTransferTxDailyAmountLimit(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	day := pgtype.Interval{Days: 1, Valid: true}
+	createRandomAccountLimit(t, account1, TransferLimitKindAmount, 25, day)
+
+	for i := 0; i < 2; i++ {
+		_, err := testStore.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        10,
+		})
+		require.NoError(t, err)
+	}
+
+	_, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+
+	var limitErr *TransferLimitError
+	require.True(t, errors.As(err, &limitErr))
+	require.Equal(t, int64(30), limitErr.Current)
+	require.Equal(t, fmt.Sprintf("account:%d", account1.ID), limitErr.Scope())
+
+	// the failed transfer must not leave anything behind
+	updatedAccount1, err := testStore.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-20, updatedAccount1.Balance)
+}
+
+func TestTransferTxCountLimit(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	hour := pgtype.Interval{Microseconds: 3600 * 1000000, Valid: true}
+	createRandomAccountLimit(t, account1, TransferLimitKindCount, 1, hour)
+
+	_, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1,
+	})
+	require.NoError(t, err)
+
+	_, err = testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1,
+	})
+
+	var limitErr *TransferLimitError
+	require.True(t, errors.As(err, &limitErr))
+	require.Equal(t, int64(2), limitErr.Current)
+
+	// limits only apply to the account sending money
+	_, err = testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        1,
+	})
+	require.NoError(t, err)
+}
+
+func TestTransferTxConcurrentOwnerLimit(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2, err := testStore.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    account1.Owner,
+		Balance:  account1.Balance,
+		Currency: account1.Currency,
+		Product:  AccountProductSavings,
+	})
+	require.NoError(t, err)
+	recipient := createRandomAccount(t)
+
+	_, err = testStore.CreateTransferLimit(context.Background(), CreateTransferLimitParams{
+		Username: pgtype.Text{String: account1.Owner, Valid: true},
+		Currency: pgtype.Text{String: account1.Currency, Valid: true},
+		Kind:     TransferLimitKindAmount,
+		MaxValue: 15,
+		Period:   pgtype.Interval{Days: 1, Valid: true},
+	})
+	require.NoError(t, err)
+
+	// the limit adds up both accounts, only one of two concurrent transfers from them fits
+	errs := make(chan error)
+	for _, account := range []Account{account1, account2} {
+		go func(account Account) {
+			_, err := testStore.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID: account.ID,
+				ToAccountID:   recipient.ID,
+				Amount:        10,
+			})
+			errs <- err
+		}(account)
+	}
+
+	var limitErrs int
+	for i := 0; i < 2; i++ {
+		err := <-errs
+		var limitErr *TransferLimitError
+		if errors.As(err, &limitErr) {
+			limitErrs++
+			continue
+		}
+		require.NoError(t, err)
+	}
+	require.Equal(t, 1, limitErrs)
+}
+
+func TestDeleteTransferLimit(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	limit := createRandomAccountLimit(t, account1, TransferLimitKindAmount, 0, pgtype.Interval{})
+
+	err := testStore.DeleteTransferLimit(context.Background(), limit.ID)
+	require.NoError(t, err)
+
+	_, err = testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1,
+	})
+	require.NoError(t, err)
+}
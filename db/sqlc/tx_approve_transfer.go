@@ -0,0 +1,191 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// TransferRequestStatusPending is the status of a transfer waiting for approvals
+	TransferRequestStatusPending = "pending"
+	// TransferRequestStatusApproved is the status of a transfer executed after reaching its approvals
+	TransferRequestStatusApproved = "approved"
+	// TransferRequestStatusRejected is the status of a transfer rejected by a signatory or cancelled by its requester
+	TransferRequestStatusRejected = "rejected"
+	// TransferRequestStatusExpired is the status of a transfer that didn't reach its approvals in time
+	TransferRequestStatusExpired = "expired"
+)
+
+var (
+	// ErrTransferRequestClosed is returned when acting on a transfer request that is no longer pending
+	ErrTransferRequestClosed = errors.New("transfer request is already closed")
+	// ErrTransferRequestExpired is returned when acting on a transfer request past its expiry, which is then closed
+	ErrTransferRequestExpired = errors.New("transfer request has expired")
+	// ErrNotSignatory is returned when a user who isn't a signatory of the source account approves or rejects a transfer
+	ErrNotSignatory = errors.New("user is not a signatory of the account")
+	// ErrSelfApproval is returned when the user who requested a transfer tries to approve it
+	ErrSelfApproval = errors.New("a transfer can't be approved by the user who requested it")
+	// ErrAlreadyApproved is returned when a signatory approves the same transfer twice
+	ErrAlreadyApproved = errors.New("transfer request is already approved by this user")
+)
+
+// ApproveTransferRequestTxParams contains the input parameters of the approve transfer request transaction
+type ApproveTransferRequestTxParams struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+// ApproveTransferRequestTxResult is the result of the approve transfer request transaction
+type ApproveTransferRequestTxResult struct {
+	Request   TransferRequest `json:"request"`
+	Approvals int64           `json:"approvals"`
+	// Transfer is only set when this approval was the last one required
+	Transfer *TransferTxResult `json:"transfer,omitempty"`
+}
+
+// ApproveTransferRequestTx records the approval of a signatory of the source account.
+// Once the required approvals are reached, the transfer is executed within the same database transaction, transfer limits included.
+func (store *SQLStore) ApproveTransferRequestTx(ctx context.Context, arg ApproveTransferRequestTxParams) (ApproveTransferRequestTxResult, error) {
+	var result ApproveTransferRequestTxResult
+	var expired bool
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		result = ApproveTransferRequestTxResult{}
+		var err error
+		result.Request, expired, err = lockPendingTransferRequest(ctx, q, arg.ID)
+		if err != nil || expired {
+			return err
+		}
+
+		if result.Request.RequestedBy == arg.Username {
+			return ErrSelfApproval
+		}
+
+		err = checkSignatory(ctx, q, result.Request.FromAccountID, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreateTransferApproval(ctx, CreateTransferApprovalParams{
+			TransferRequestID: arg.ID,
+			Username:          arg.Username,
+		})
+		if err != nil {
+			if ErrorCode(err) == UniqueViolation {
+				return ErrAlreadyApproved
+			}
+			return err
+		}
+
+		result.Approvals, err = q.CountTransferApprovals(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		if result.Approvals < int64(result.Request.RequiredApprovals) {
+			return nil
+		}
+
+		transferResult, err := transfer(ctx, q, TransferTxParams{
+			FromAccountID: result.Request.FromAccountID,
+			ToAccountID:   result.Request.ToAccountID,
+			Amount:        result.Request.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Transfer = &transferResult
+		result.Request, err = q.UpdateTransferRequestStatus(ctx, UpdateTransferRequestStatusParams{
+			ID:         arg.ID,
+			Status:     TransferRequestStatusApproved,
+			TransferID: pgtype.Int8{Int64: transferResult.Transfer.ID, Valid: true},
+		})
+		return err
+	})
+	if err == nil && expired {
+		err = ErrTransferRequestExpired
+	}
+	if err == nil && result.Transfer != nil {
+		observeTransfer(*result.Transfer)
+	}
+
+	return result, err
+}
+
+// RejectTransferRequestTxParams contains the input parameters of the reject transfer request transaction
+type RejectTransferRequestTxParams struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+// RejectTransferRequestTx closes a pending transfer request without executing it.
+// Signatories of the source account can reject it, and its requester can cancel it.
+func (store *SQLStore) RejectTransferRequestTx(ctx context.Context, arg RejectTransferRequestTxParams) (TransferRequest, error) {
+	var request TransferRequest
+	var expired bool
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		request, expired = TransferRequest{}, false
+		var err error
+		request, expired, err = lockPendingTransferRequest(ctx, q, arg.ID)
+		if err != nil || expired {
+			return err
+		}
+
+		if request.RequestedBy != arg.Username {
+			err = checkSignatory(ctx, q, request.FromAccountID, arg.Username)
+			if err != nil {
+				return err
+			}
+		}
+
+		request, err = q.UpdateTransferRequestStatus(ctx, UpdateTransferRequestStatusParams{
+			ID:     arg.ID,
+			Status: TransferRequestStatusRejected,
+		})
+		return err
+	})
+	if err == nil && expired {
+		err = ErrTransferRequestExpired
+	}
+
+	return request, err
+}
+
+// lockPendingTransferRequest locks a transfer request that must still be pending.
+// A request past its expiry is marked as expired, the caller must then commit and report ErrTransferRequestExpired.
+func lockPendingTransferRequest(ctx context.Context, q *Queries, id int64) (request TransferRequest, expired bool, err error) {
+	request, err = q.GetTransferRequestForUpdate(ctx, id)
+	if err != nil {
+		return
+	}
+
+	if request.Status != TransferRequestStatusPending {
+		err = ErrTransferRequestClosed
+		return
+	}
+
+	if time.Now().After(request.ExpiresAt) {
+		expired = true
+		request, err = q.UpdateTransferRequestStatus(ctx, UpdateTransferRequestStatusParams{
+			ID:     id,
+			Status: TransferRequestStatusExpired,
+		})
+	}
+	return
+}
+
+func checkSignatory(ctx context.Context, q *Queries, accountID int64, username string) error {
+	_, err := q.GetAccountSignatory(ctx, GetAccountSignatoryParams{
+		AccountID: accountID,
+		Username:  username,
+	})
+	if errors.Is(err, ErrRecordNotFound) {
+		return ErrNotSignatory
+	}
+	return err
+}
@@ -0,0 +1,153 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomTransferRequest(t *testing.T, account1, account2 Account, requiredApprovals int32, expiresAt time.Time) TransferRequest {
+	arg := CreateTransferRequestParams{
+		FromAccountID:     account1.ID,
+		ToAccountID:       account2.ID,
+		Amount:            10,
+		RequestedBy:       account1.Owner,
+		RequiredApprovals: requiredApprovals,
+		ExpiresAt:         expiresAt,
+	}
+
+	request, err := testStore.CreateTransferRequest(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, request.ID)
+	require.Equal(t, TransferRequestStatusPending, request.Status)
+	require.Equal(t, arg.RequiredApprovals, request.RequiredApprovals)
+	require.WithinDuration(t, arg.ExpiresAt, request.ExpiresAt, time.Second)
+
+	return request
+}
+
+func createRandomSignatory(t *testing.T, account Account) User {
+	user := createRandomUser(t)
+
+	signatory, err := testStore.CreateAccountSignatory(context.Background(), CreateAccountSignatoryParams{
+		AccountID: account.ID,
+		Username:  user.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, signatory.Username)
+
+	return user
+}
+
+func TestApproveTransferRequestTx(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	signatory1 := createRandomSignatory(t, account1)
+	signatory2 := createRandomSignatory(t, account1)
+	request := createRandomTransferRequest(t, account1, account2, 2, time.Now().Add(time.Hour))
+
+	// the requester can't approve its own transfer
+	_, err := testStore.ApproveTransferRequestTx(context.Background(), ApproveTransferRequestTxParams{
+		ID:       request.ID,
+		Username: account1.Owner,
+	})
+	require.ErrorIs(t, err, ErrSelfApproval)
+
+	// neither can a user who isn't a signatory
+	_, err = testStore.ApproveTransferRequestTx(context.Background(), ApproveTransferRequestTxParams{
+		ID:       request.ID,
+		Username: account2.Owner,
+	})
+	require.ErrorIs(t, err, ErrNotSignatory)
+
+	result, err := testStore.ApproveTransferRequestTx(context.Background(), ApproveTransferRequestTxParams{
+		ID:       request.ID,
+		Username: signatory1.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), result.Approvals)
+	require.Nil(t, result.Transfer)
+	require.Equal(t, TransferRequestStatusPending, result.Request.Status)
+
+	_, err = testStore.ApproveTransferRequestTx(context.Background(), ApproveTransferRequestTxParams{
+		ID:       request.ID,
+		Username: signatory1.Username,
+	})
+	require.ErrorIs(t, err, ErrAlreadyApproved)
+
+	result, err = testStore.ApproveTransferRequestTx(context.Background(), ApproveTransferRequestTxParams{
+		ID:       request.ID,
+		Username: signatory2.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), result.Approvals)
+	require.NotNil(t, result.Transfer)
+	require.Equal(t, TransferRequestStatusApproved, result.Request.Status)
+	require.Equal(t, result.Transfer.Transfer.ID, result.Request.TransferID.Int64)
+	require.Equal(t, account1.Balance-request.Amount, result.Transfer.FromAccount.Balance)
+
+	_, err = testStore.RejectTransferRequestTx(context.Background(), RejectTransferRequestTxParams{
+		ID:       request.ID,
+		Username: signatory1.Username,
+	})
+	require.ErrorIs(t, err, ErrTransferRequestClosed)
+}
+
+func TestApproveTransferRequestTxExpired(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	signatory := createRandomSignatory(t, account1)
+	request := createRandomTransferRequest(t, account1, account2, 1, time.Now().Add(-time.Minute))
+
+	_, err := testStore.ApproveTransferRequestTx(context.Background(), ApproveTransferRequestTxParams{
+		ID:       request.ID,
+		Username: signatory.Username,
+	})
+	require.ErrorIs(t, err, ErrTransferRequestExpired)
+
+	// the expiry is committed even though the approval failed
+	expiredRequest, err := testStore.GetTransferRequest(context.Background(), request.ID)
+	require.NoError(t, err)
+	require.Equal(t, TransferRequestStatusExpired, expiredRequest.Status)
+}
+
+func TestRejectTransferRequestTx(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	request := createRandomTransferRequest(t, account1, account2, 1, time.Now().Add(time.Hour))
+
+	_, err := testStore.RejectTransferRequestTx(context.Background(), RejectTransferRequestTxParams{
+		ID:       request.ID,
+		Username: account2.Owner,
+	})
+	require.ErrorIs(t, err, ErrNotSignatory)
+
+	// the requester can cancel its own transfer
+	rejectedRequest, err := testStore.RejectTransferRequestTx(context.Background(), RejectTransferRequestTxParams{
+		ID:       request.ID,
+		Username: account1.Owner,
+	})
+	require.NoError(t, err)
+	require.Equal(t, TransferRequestStatusRejected, rejectedRequest.Status)
+	require.False(t, rejectedRequest.TransferID.Valid)
+}
+
+func TestListTransferRequestsSkipsExpired(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	pending := createRandomTransferRequest(t, account1, account2, 1, time.Now().Add(time.Hour))
+	createRandomTransferRequest(t, account1, account2, 1, time.Now().Add(-time.Minute))
+
+	requests, err := testStore.ListTransferRequests(context.Background(), ListTransferRequestsParams{
+		FromAccountID: account1.ID,
+		Status:        TransferRequestStatusPending,
+		Limit:         5,
+		Offset:        0,
+	})
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	require.Equal(t, pending.ID, requests[0].ID)
+}
@@ -0,0 +1,246 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"bitbucket.org/jessyw/go_simplebank/metrics"
+)
+
+const (
+	// BatchItemStatusCompleted is the status of an item whose transfer went through
+	BatchItemStatusCompleted = "completed"
+	// BatchItemStatusFailed is the status of an item that was invalid or exceeded a transfer limit
+	BatchItemStatusFailed = "failed"
+	// BatchItemStatusCancelled is the status of the other items when an item of an all-or-nothing batch fails
+	BatchItemStatusCancelled = "cancelled"
+)
+
+// ErrBatchTransferFailed is returned when an item of an all-or-nothing batch fails, nothing is transferred then
+var ErrBatchTransferFailed = errors.New("an item of the batch failed, nothing was transferred")
+
+// BatchTransferItem is one payment of a batch transfer
+type BatchTransferItem struct {
+	ToAccountID int64 `json:"to_account_id"`
+	Amount      int64 `json:"amount"`
+}
+
+// BatchTransferTxParams contains the input parameters of the batch transfer transaction
+type BatchTransferTxParams struct {
+	FromAccountID int64               `json:"from_account_id"`
+	Items         []BatchTransferItem `json:"items"`
+	// AllOrNothing rolls every item back when one of them fails, otherwise the failed items are skipped
+	AllOrNothing bool `json:"all_or_nothing"`
+}
+
+// Total sums the amounts of the items, capped at math.MaxInt64 instead of overflowing
+func (arg BatchTransferTxParams) Total() int64 {
+	var total int64
+	for _, item := range arg.Items {
+		if item.Amount > math.MaxInt64-total {
+			return math.MaxInt64
+		}
+		total += item.Amount
+	}
+	return total
+}
+
+// BatchTransferItemResult is the outcome of one item of a batch transfer
+type BatchTransferItemResult struct {
+	ToAccountID int64  `json:"to_account_id"`
+	Amount      int64  `json:"amount"`
+	Status      string `json:"status"`
+	// Error explains why the item failed
+	Error    string   `json:"error,omitempty"`
+	Transfer Transfer `json:"transfer"`
+	Fee      int64    `json:"fee"`
+}
+
+// BatchTransferTxResult is the result of the batch transfer transaction
+type BatchTransferTxResult struct {
+	FromAccount Account                   `json:"from_account"`
+	Items       []BatchTransferItemResult `json:"items"`
+	Completed   int                       `json:"completed"`
+	Failed      int                       `json:"failed"`
+}
+
+// BatchTransferTx sends money from one account to many accounts in a single database transaction.
+// Every item is validated before anything is locked, then the source account, the recipients and the fee
+// revenue account are locked once, in the order of their IDs, and the items are executed in order.
+// An item fails when its recipient doesn't exist, is closed or has another currency, or when it exceeds a transfer limit
+// of the source account. In an all-or-nothing batch, the first failure rolls everything back and
+// ErrBatchTransferFailed is returned along with the per-item results; any other error aborts the whole batch.
+func (store *SQLStore) BatchTransferTx(ctx context.Context, arg BatchTransferTxParams) (BatchTransferTxResult, error) {
+	var result BatchTransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result, err = batchTransfer(ctx, q, arg)
+		return err
+	})
+	if err == nil {
+		for _, item := range result.Items {
+			if item.Status == BatchItemStatusCompleted {
+				metrics.ObserveTransfer(result.FromAccount.Currency, item.Transfer.Amount)
+			}
+		}
+	}
+
+	return result, err
+}
+
+func batchTransfer(ctx context.Context, q *Queries, arg BatchTransferTxParams) (BatchTransferTxResult, error) {
+	result := BatchTransferTxResult{
+		Items: make([]BatchTransferItemResult, len(arg.Items)),
+	}
+
+	fromAccount, err := q.GetAccount(ctx, arg.FromAccountID)
+	if err != nil {
+		return result, err
+	}
+
+	accountIDs := []int64{arg.FromAccountID}
+	fees := make([]int64, len(arg.Items))
+	for i, item := range arg.Items {
+		result.Items[i] = BatchTransferItemResult{
+			ToAccountID: item.ToAccountID,
+			Amount:      item.Amount,
+		}
+
+		err := validateBatchTransferItem(ctx, q, fromAccount, item)
+		if err != nil {
+			if errors.Is(err, ErrRecordNotFound) {
+				err = fmt.Errorf("account [%d] not found", item.ToAccountID)
+			} else if !errors.Is(err, errInvalidBatchTransferItem) {
+				return result, err
+			}
+			failBatchTransferItem(&result, i, err)
+			continue
+		}
+
+		fees[i], err = TransferFee(ctx, q, fromAccount.Currency, item.Amount)
+		if err != nil {
+			return result, err
+		}
+		accountIDs = append(accountIDs, item.ToAccountID)
+	}
+
+	if arg.AllOrNothing && result.Failed > 0 {
+		return result, cancelBatchTransfer(&result)
+	}
+
+	var feeAccount SystemAccount
+	for _, fee := range fees {
+		if fee > 0 {
+			feeAccount, err = q.GetSystemAccount(ctx, GetSystemAccountParams{
+				Purpose:  SystemAccountFeeRevenue,
+				Currency: fromAccount.Currency,
+			})
+			if err != nil {
+				return result, err
+			}
+			accountIDs = append(accountIDs, feeAccount.AccountID)
+			break
+		}
+	}
+
+	accounts, err := lockAccounts(ctx, q, accountIDs...)
+	if err != nil {
+		return result, err
+	}
+	result.FromAccount = accounts[arg.FromAccountID]
+
+	err = checkAccountsOpen(result.FromAccount)
+	if err != nil {
+		return result, err
+	}
+
+	for i, item := range arg.Items {
+		if result.Items[i].Status == BatchItemStatusFailed {
+			continue
+		}
+
+		// the recipient may have been closed since it was validated
+		var transfer TransferTxResult
+		err := checkAccountsOpen(accounts[item.ToAccountID])
+		if err == nil {
+			transfer, err = sendMoney(ctx, q, result.FromAccount, TransferTxParams{
+				FromAccountID: arg.FromAccountID,
+				ToAccountID:   item.ToAccountID,
+				Amount:        item.Amount,
+			}, fees[i], feeAccount.AccountID)
+		}
+		if err != nil {
+			var limitErr *TransferLimitError
+			if !errors.As(err, &limitErr) && !errors.Is(err, ErrAccountClosed) {
+				return result, err
+			}
+
+			// limits and closed accounts are checked before anything is written, there is nothing to undo for this item
+			failBatchTransferItem(&result, i, err)
+			if arg.AllOrNothing {
+				result.FromAccount = accounts[arg.FromAccountID]
+				return result, cancelBatchTransfer(&result)
+			}
+			continue
+		}
+
+		result.FromAccount = transfer.FromAccount
+		result.Items[i].Status = BatchItemStatusCompleted
+		result.Items[i].Transfer = transfer.Transfer
+		result.Items[i].Fee = transfer.Fee
+		result.Completed++
+	}
+
+	return result, nil
+}
+
+var errInvalidBatchTransferItem = errors.New("invalid batch transfer item")
+
+func validateBatchTransferItem(ctx context.Context, q *Queries, fromAccount Account, item BatchTransferItem) error {
+	if item.Amount <= 0 {
+		return fmt.Errorf("%w: amount must be positive", errInvalidBatchTransferItem)
+	}
+
+	if item.ToAccountID == fromAccount.ID {
+		return fmt.Errorf("%w: can't transfer to the source account", errInvalidBatchTransferItem)
+	}
+
+	toAccount, err := q.GetAccount(ctx, item.ToAccountID)
+	if err != nil {
+		return err
+	}
+
+	if toAccount.ClosedAt.Valid {
+		return fmt.Errorf("%w: account [%d] is closed", errInvalidBatchTransferItem, toAccount.ID)
+	}
+
+	if toAccount.Currency != fromAccount.Currency {
+		return fmt.Errorf("%w: account [%d] currency mismatch: %s vs %s",
+			errInvalidBatchTransferItem, toAccount.ID, toAccount.Currency, fromAccount.Currency)
+	}
+
+	return nil
+}
+
+func failBatchTransferItem(result *BatchTransferTxResult, i int, err error) {
+	result.Items[i].Status = BatchItemStatusFailed
+	result.Items[i].Error = err.Error()
+	result.Failed++
+}
+
+// cancelBatchTransfer marks every item that didn't fail as cancelled, the caller's transaction is rolled back.
+func cancelBatchTransfer(result *BatchTransferTxResult) error {
+	for i := range result.Items {
+		if result.Items[i].Status != BatchItemStatusFailed {
+			result.Items[i].Status = BatchItemStatusCancelled
+			result.Items[i].Transfer = Transfer{}
+			result.Items[i].Fee = 0
+		}
+	}
+	result.Completed = 0
+
+	return ErrBatchTransferFailed
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+// createRandomAccountIn creates an account in the given currency, batch items must match the source currency
+func createRandomAccountIn(t *testing.T, currency string) Account {
+	user := createRandomUser(t)
+	account, err := testStore.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  util.RandomMoney(),
+		Currency: currency,
+		Product:  AccountProductChecking,
+	})
+	require.NoError(t, err)
+
+	return account
+}
+
+func TestBatchTransferTx(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountIn(t, account1.Currency)
+	account3 := createRandomAccountIn(t, account1.Currency)
+
+	result, err := testStore.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		FromAccountID: account1.ID,
+		Items: []BatchTransferItem{
+			{ToAccountID: account2.ID, Amount: 10},
+			{ToAccountID: account3.ID, Amount: 20},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, result.Completed)
+	require.Zero(t, result.Failed)
+	require.Len(t, result.Items, 2)
+
+	for i, item := range result.Items {
+		require.Equal(t, BatchItemStatusCompleted, item.Status)
+		require.Empty(t, item.Error)
+		require.NotZero(t, item.Transfer.ID)
+		require.Equal(t, account1.ID, item.Transfer.FromAccountID)
+		require.Equal(t, []int64{account2.ID, account3.ID}[i], item.Transfer.ToAccountID)
+	}
+	require.Equal(t, account1.Balance-30-result.Items[0].Fee-result.Items[1].Fee, result.FromAccount.Balance)
+}
+
+func TestBatchTransferTxBestEffort(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountIn(t, account1.Currency)
+
+	createRandomAccountLimit(t, account1, TransferLimitKindAmount, 50, pgtype.Interval{})
+
+	result, err := testStore.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		FromAccountID: account1.ID,
+		Items: []BatchTransferItem{
+			{ToAccountID: account2.ID, Amount: 10},
+			{ToAccountID: account2.ID, Amount: 51},
+			{ToAccountID: account1.ID, Amount: 10},
+			{ToAccountID: account2.ID, Amount: 5},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, result.Completed)
+	require.Equal(t, 2, result.Failed)
+	require.Equal(t, BatchItemStatusCompleted, result.Items[0].Status)
+	require.Equal(t, BatchItemStatusFailed, result.Items[1].Status)
+	require.Contains(t, result.Items[1].Error, "transfer limit exceeded")
+	require.Equal(t, BatchItemStatusFailed, result.Items[2].Status)
+	require.Equal(t, BatchItemStatusCompleted, result.Items[3].Status)
+
+	updatedAccount2, err := testStore.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance+15, updatedAccount2.Balance)
+}
+
+func TestBatchTransferTxAllOrNothing(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountIn(t, account1.Currency)
+
+	createRandomAccountLimit(t, account1, TransferLimitKindAmount, 50, pgtype.Interval{})
+
+	result, err := testStore.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		FromAccountID: account1.ID,
+		Items: []BatchTransferItem{
+			{ToAccountID: account2.ID, Amount: 10},
+			{ToAccountID: account2.ID, Amount: 51},
+			{ToAccountID: account2.ID, Amount: 5},
+		},
+		AllOrNothing: true,
+	})
+	require.ErrorIs(t, err, ErrBatchTransferFailed)
+	require.Zero(t, result.Completed)
+	require.Equal(t, 1, result.Failed)
+	require.Equal(t, BatchItemStatusCancelled, result.Items[0].Status)
+	require.Equal(t, BatchItemStatusFailed, result.Items[1].Status)
+	require.Equal(t, BatchItemStatusCancelled, result.Items[2].Status)
+
+	// the first item was rolled back with the rest
+	updatedAccount1, err := testStore.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+
+	// an unknown recipient fails the batch before anything is locked
+	_, err = testStore.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		FromAccountID: account1.ID,
+		Items:         []BatchTransferItem{{ToAccountID: account2.ID, Amount: 1}, {ToAccountID: -1, Amount: 1}},
+		AllOrNothing:  true,
+	})
+	require.ErrorIs(t, err, ErrBatchTransferFailed)
+}
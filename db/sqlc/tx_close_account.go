@@ -0,0 +1,40 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// CloseAccountTxParams contains the input parameters of the close account transaction
+type CloseAccountTxParams struct {
+	AccountID int64 `json:"account_id"`
+}
+
+// CloseAccountTx closes an account instead of deleting it, so that its entries and transfers stay in the ledger.
+// It returns ErrAccountHasBalance when the account still has money on it, and ErrAccountClosed when it is closed already.
+func (store *SQLStore) CloseAccountTx(ctx context.Context, arg CloseAccountTxParams) (Account, error) {
+	var account Account
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		// locking the account keeps a transfer from crediting it while it is closed
+		account, err = q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		err = checkAccountsOpen(account)
+		if err != nil {
+			return err
+		}
+
+		if account.Balance != 0 {
+			return fmt.Errorf("%w: account [%d] has a balance of %d %s", ErrAccountHasBalance, account.ID, account.Balance, account.Currency)
+		}
+
+		account, err = q.CloseAccount(ctx, account.ID)
+		return err
+	})
+
+	return account, err
+}
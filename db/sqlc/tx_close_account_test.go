@@ -0,0 +1,34 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloseAccountTx(t *testing.T) {
+	account := createRandomAccount(t)
+
+	_, err := testStore.UpdateAccount(context.Background(), UpdateAccountParams{ID: account.ID, Balance: 10})
+	require.NoError(t, err)
+
+	_, err = testStore.CloseAccountTx(context.Background(), CloseAccountTxParams{AccountID: account.ID})
+	require.ErrorIs(t, err, ErrAccountHasBalance)
+
+	_, err = testStore.UpdateAccount(context.Background(), UpdateAccountParams{ID: account.ID, Balance: 0})
+	require.NoError(t, err)
+
+	closed, err := testStore.CloseAccountTx(context.Background(), CloseAccountTxParams{AccountID: account.ID})
+	require.NoError(t, err)
+	require.Equal(t, account.ID, closed.ID)
+	require.True(t, closed.ClosedAt.Valid)
+
+	// the account is kept, with its history
+	stored, err := testStore.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.True(t, stored.ClosedAt.Valid)
+
+	_, err = testStore.CloseAccountTx(context.Background(), CloseAccountTxParams{AccountID: account.ID})
+	require.ErrorIs(t, err, ErrAccountClosed)
+}
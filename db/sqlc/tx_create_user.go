@@ -0,0 +1,39 @@
+package db
+
+import "context"
+
+// CreateUserTxParams contains the input parameters of the create user transaction
+type CreateUserTxParams struct {
+	CreateUserParams
+	// Role is given to the user once created, the users signing up are depositors
+	Role string `json:"role"`
+}
+
+// CreateUserTxResult is the result of the create user transaction
+type CreateUserTxResult struct {
+	User User `json:"user"`
+}
+
+// CreateUserTx creates a user with the given role within a single database transaction,
+// so that a user meant to be a banker never exists as a depositor.
+func (store *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error) {
+	var result CreateUserTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		result = CreateUserTxResult{}
+		var err error
+
+		result.User, err = q.CreateUser(ctx, arg.CreateUserParams)
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.UpdateUserRole(ctx, UpdateUserRoleParams{
+			Role:     arg.Role,
+			Username: arg.Username,
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateUserTx(t *testing.T) {
+	arg := CreateUserTxParams{
+		CreateUserParams: CreateUserParams{
+			Username:       util.RandomOwner(),
+			HashedPassword: util.RandomString(32),
+			FullName:       util.RandomOwner(),
+			Email:          util.RandomEmail(),
+		},
+		Role: util.BankerRole,
+	}
+
+	result, err := testStore.CreateUserTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, result.User.Username)
+	require.Equal(t, util.BankerRole, result.User.Role)
+
+	user, err := testStore.GetUser(context.Background(), arg.Username)
+	require.NoError(t, err)
+	require.Equal(t, util.BankerRole, user.Role)
+
+	// the user already exists, nothing is changed
+	arg.Role = util.DepositorRole
+	_, err = testStore.CreateUserTx(context.Background(), arg)
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+
+	user, err = testStore.GetUser(context.Background(), arg.Username)
+	require.NoError(t, err)
+	require.Equal(t, util.BankerRole, user.Role)
+}
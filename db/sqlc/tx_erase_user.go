@@ -0,0 +1,156 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrUserErased is returned when erasing a user who was already erased
+	ErrUserErased = errors.New("the user was already erased")
+	// ErrAccountHasBalance is returned when erasing a user who still owns an account with money on it
+	ErrAccountHasBalance = errors.New("account must have a zero balance to be closed")
+	// ErrAccountClosed is returned when moving money from or to a closed account
+	ErrAccountClosed = errors.New("account is closed")
+)
+
+// EraseUserTxParams contains the input parameters of the erase user transaction
+type EraseUserTxParams struct {
+	Username string `json:"username"`
+	// ErasedBy is the user who asked for the erasure, the user themselves or a banker
+	ErasedBy string `json:"erased_by"`
+}
+
+// EraseUserTxResult is the result of the erase user transaction
+type EraseUserTxResult struct {
+	User           User      `json:"user"`
+	ClosedAccounts []Account `json:"closed_accounts"`
+	// BlockedSessions is the number of sessions that were still usable
+	BlockedSessions int64 `json:"blocked_sessions"`
+	// RemovedMemberships and RemovedSignatories count the accounts of others the user had access to
+	RemovedMemberships int64    `json:"removed_memberships"`
+	RemovedSignatories int64    `json:"removed_signatories"`
+	AuditLog           AuditLog `json:"audit_log"`
+}
+
+// erasureDetails is what the audit log keeps about an erasure, it holds no personal data
+type erasureDetails struct {
+	ClosedAccounts     []int64 `json:"closed_accounts"`
+	BlockedSessions    int64   `json:"blocked_sessions"`
+	RemovedMemberships int64   `json:"removed_memberships"`
+	RemovedSignatories int64   `json:"removed_signatories"`
+}
+
+// EraseUserTx erases the personal data of a user: it closes the accounts they own, blocks their sessions and
+// password reset tokens, removes their second factor and their access to the accounts of others, as a member or
+// a signatory, and replaces their full name, email and password, then records the erasure in the audit log.
+// The username, the accounts and their entries and transfers are kept so that the ledger stays consistent.
+// It returns ErrAccountHasBalance when an account of the user still has money on it, nothing is erased then.
+func (store *SQLStore) EraseUserTx(ctx context.Context, arg EraseUserTxParams) (EraseUserTxResult, error) {
+	var result EraseUserTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		result = EraseUserTxResult{}
+		user, err := q.GetUser(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+		if user.ErasedAt.Valid {
+			return ErrUserErased
+		}
+
+		owned, err := q.ListOwnedAccounts(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		accountIDs := make([]int64, len(owned))
+		for i, account := range owned {
+			accountIDs[i] = account.ID
+		}
+
+		accounts, err := lockAccounts(ctx, q, accountIDs...)
+		if err != nil {
+			return err
+		}
+
+		details := erasureDetails{ClosedAccounts: []int64{}}
+		for _, accountID := range accountIDs {
+			account := accounts[accountID]
+			if account.Balance != 0 {
+				return fmt.Errorf("%w: account [%d] has a balance of %d %s", ErrAccountHasBalance, account.ID, account.Balance, account.Currency)
+			}
+			if account.ClosedAt.Valid {
+				continue
+			}
+
+			account, err = q.CloseAccount(ctx, account.ID)
+			if err != nil {
+				return err
+			}
+			result.ClosedAccounts = append(result.ClosedAccounts, account)
+			details.ClosedAccounts = append(details.ClosedAccounts, account.ID)
+		}
+
+		// a pending reset token must not bring the login back
+		err = q.UsePasswordResets(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		result.BlockedSessions, err = q.BlockUserSessions(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+		details.BlockedSessions = result.BlockedSessions
+
+		err = deleteMFA(ctx, q, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		result.RemovedMemberships, err = q.DeleteUserAccountMembers(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+		details.RemovedMemberships = result.RemovedMemberships
+
+		result.RemovedSignatories, err = q.DeleteUserAccountSignatories(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+		details.RemovedSignatories = result.RemovedSignatories
+
+		result.User, err = q.EraseUser(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(details)
+		if err != nil {
+			return err
+		}
+
+		result.AuditLog, err = q.CreateAuditLog(ctx, CreateAuditLogParams{
+			Action:  AuditActionUserErased,
+			Actor:   arg.ErasedBy,
+			Subject: arg.Username,
+			Details: data,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// checkAccountsOpen returns ErrAccountClosed when one of the accounts is closed
+func checkAccountsOpen(accounts ...Account) error {
+	for _, account := range accounts {
+		if account.ClosedAt.Valid {
+			return fmt.Errorf("%w: account [%d]", ErrAccountClosed, account.ID)
+		}
+	}
+	return nil
+}
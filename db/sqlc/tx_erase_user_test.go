@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEraseUserTx(t *testing.T) {
+	account := createRandomAccount(t)
+	_, err := testStore.UpdateAccount(context.Background(), UpdateAccountParams{ID: account.ID, Balance: 0})
+	require.NoError(t, err)
+
+	_, err = testStore.CreateSession(context.Background(), CreateSessionParams{
+		ID:           uuid.New(),
+		Username:     account.Owner,
+		RefreshToken: util.RandomString(32),
+		UserAgent:    "test",
+		ClientIp:     "127.0.0.1",
+	})
+	require.NoError(t, err)
+
+	// the user also has access to the account of someone else
+	shared := createRandomAccount(t)
+	_, err = testStore.CreateAccountMember(context.Background(), CreateAccountMemberParams{
+		AccountID:  shared.ID,
+		Username:   account.Owner,
+		Permission: AccountPermissionView,
+		InvitedBy:  shared.Owner,
+	})
+	require.NoError(t, err)
+	_, err = testStore.CreateAccountSignatory(context.Background(), CreateAccountSignatoryParams{
+		AccountID: shared.ID,
+		Username:  account.Owner,
+	})
+	require.NoError(t, err)
+
+	banker := createRandomUser(t)
+	result, err := testStore.EraseUserTx(context.Background(), EraseUserTxParams{
+		Username: account.Owner,
+		ErasedBy: banker.Username,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, account.Owner, result.User.Username)
+	require.Equal(t, "Erased user", result.User.FullName)
+	require.Equal(t, account.Owner+"@erased.invalid", result.User.Email)
+	require.Empty(t, result.User.HashedPassword)
+	require.True(t, result.User.ErasedAt.Valid)
+	require.Equal(t, int64(1), result.BlockedSessions)
+
+	require.Len(t, result.ClosedAccounts, 1)
+	require.Equal(t, account.ID, result.ClosedAccounts[0].ID)
+	require.True(t, result.ClosedAccounts[0].ClosedAt.Valid)
+
+	require.Equal(t, AuditActionUserErased, result.AuditLog.Action)
+	require.Equal(t, banker.Username, result.AuditLog.Actor)
+	require.Equal(t, account.Owner, result.AuditLog.Subject)
+
+	var details erasureDetails
+	require.NoError(t, json.Unmarshal(result.AuditLog.Details, &details))
+	require.Equal(t, []int64{account.ID}, details.ClosedAccounts)
+	require.Equal(t, int64(1), details.RemovedMemberships)
+	require.Equal(t, int64(1), details.RemovedSignatories)
+
+	_, err = testStore.GetAccountMember(context.Background(), GetAccountMemberParams{AccountID: shared.ID, Username: account.Owner})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+	_, err = testStore.GetAccountSignatory(context.Background(), GetAccountSignatoryParams{AccountID: shared.ID, Username: account.Owner})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	// the ledger is kept, but the account can't be used anymore
+	other := createRandomAccount(t)
+	_, err = testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: other.ID,
+		ToAccountID:   account.ID,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, ErrAccountClosed)
+
+	_, err = testStore.EraseUserTx(context.Background(), EraseUserTxParams{Username: account.Owner, ErasedBy: banker.Username})
+	require.ErrorIs(t, err, ErrUserErased)
+}
+
+func TestEraseUserTxAccountHasBalance(t *testing.T) {
+	account := createRandomAccount(t)
+	_, err := testStore.UpdateAccount(context.Background(), UpdateAccountParams{ID: account.ID, Balance: 10})
+	require.NoError(t, err)
+
+	_, err = testStore.EraseUserTx(context.Background(), EraseUserTxParams{Username: account.Owner, ErasedBy: account.Owner})
+	require.ErrorIs(t, err, ErrAccountHasBalance)
+
+	user, err := testStore.GetUser(context.Background(), account.Owner)
+	require.NoError(t, err)
+	require.False(t, user.ErasedAt.Valid)
+
+	account, err = testStore.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.False(t, account.ClosedAt.Valid)
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ImportEntry is a transaction of an external statement to record on an account
+type ImportEntry struct {
+	// ExternalRef identifies the transaction in the statement, it is imported at most once per account
+	ExternalRef string    `json:"external_ref"`
+	Amount      int64     `json:"amount"`
+	PostedAt    time.Time `json:"posted_at"`
+}
+
+// ImportEntriesTxParams contains the input parameters of the import entries transaction
+type ImportEntriesTxParams struct {
+	AccountID int64         `json:"account_id"`
+	Entries   []ImportEntry `json:"entries"`
+}
+
+// ImportEntriesTxResult is the result of the import entries transaction
+type ImportEntriesTxResult struct {
+	Account Account `json:"account"`
+	// Entries are in the order of the params, an entry whose external reference was already imported is left empty
+	Entries    []Entry `json:"entries"`
+	Duplicates int     `json:"duplicates"`
+}
+
+// ImportEntriesTx records the transactions of an external statement as entries of the account and updates its
+// balance, within a single database transaction. Transactions already imported on the account are skipped,
+// and nothing is imported on a closed account.
+func (store *SQLStore) ImportEntriesTx(ctx context.Context, arg ImportEntriesTxParams) (ImportEntriesTxResult, error) {
+	var result ImportEntriesTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		result = ImportEntriesTxResult{}
+		var err error
+
+		result.Account, err = q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		err = checkAccountsOpen(result.Account)
+		if err != nil {
+			return err
+		}
+
+		result.Entries = make([]Entry, len(arg.Entries))
+		var total int64
+		for i, entry := range arg.Entries {
+			result.Entries[i], err = q.CreateImportedEntry(ctx, CreateImportedEntryParams{
+				AccountID:   arg.AccountID,
+				Amount:      entry.Amount,
+				ExternalRef: entry.ExternalRef,
+				CreatedAt:   entry.PostedAt,
+			})
+			if errors.Is(err, ErrRecordNotFound) {
+				// the insert did nothing, the reference is already imported
+				result.Duplicates++
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			total += entry.Amount
+		}
+
+		if total == 0 {
+			return nil
+		}
+
+		result.Account, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			ID:     arg.AccountID,
+			Amount: total,
+		})
+		return err
+	})
+
+	return result, err
+}
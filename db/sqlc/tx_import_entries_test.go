@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportEntriesTx(t *testing.T) {
+	account := createRandomAccount(t)
+	postedAt := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	ref1 := util.RandomString(12)
+	ref2 := util.RandomString(12)
+
+	result, err := testStore.ImportEntriesTx(context.Background(), ImportEntriesTxParams{
+		AccountID: account.ID,
+		Entries: []ImportEntry{
+			{ExternalRef: ref1, Amount: 100, PostedAt: postedAt},
+			{ExternalRef: ref2, Amount: -30, PostedAt: postedAt},
+			{ExternalRef: ref1, Amount: 100, PostedAt: postedAt},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Duplicates)
+	require.Len(t, result.Entries, 3)
+	require.NotZero(t, result.Entries[0].ID)
+	require.Equal(t, ref1, result.Entries[0].ExternalRef.String)
+	require.WithinDuration(t, postedAt, result.Entries[0].CreatedAt, time.Second)
+	require.NotZero(t, result.Entries[1].ID)
+	require.Zero(t, result.Entries[2].ID)
+	require.Equal(t, account.Balance+70, result.Account.Balance)
+
+	// importing the same statement again changes nothing
+	result, err = testStore.ImportEntriesTx(context.Background(), ImportEntriesTxParams{
+		AccountID: account.ID,
+		Entries: []ImportEntry{
+			{ExternalRef: ref1, Amount: 100, PostedAt: postedAt},
+			{ExternalRef: ref2, Amount: -30, PostedAt: postedAt},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, result.Duplicates)
+	require.Equal(t, account.Balance+70, result.Account.Balance)
+
+	// references are unique per account only
+	other := createRandomAccount(t)
+	result, err = testStore.ImportEntriesTx(context.Background(), ImportEntriesTxParams{
+		AccountID: other.ID,
+		Entries:   []ImportEntry{{ExternalRef: ref1, Amount: 5, PostedAt: postedAt}},
+	})
+	require.NoError(t, err)
+	require.Zero(t, result.Duplicates)
+	require.Equal(t, other.Balance+5, result.Account.Balance)
+}
+
+func TestImportEntriesTxAccountClosed(t *testing.T) {
+	account := createRandomAccount(t)
+
+	_, err := testStore.CloseAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+
+	_, err = testStore.ImportEntriesTx(context.Background(), ImportEntriesTxParams{
+		AccountID: account.ID,
+		Entries:   []ImportEntry{{ExternalRef: util.RandomString(12), Amount: 100, PostedAt: time.Now()}},
+	})
+	require.ErrorIs(t, err, ErrAccountClosed)
+
+	closedAccount, err := testStore.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance, closedAccount.Balance)
+}
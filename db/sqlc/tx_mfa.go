@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// ErrMFANotPending is returned when enabling two-factor authentication for a user who isn't enrolling,
+// either because they never started or because it is already enabled, or when the code was already used
+var ErrMFANotPending = errors.New("two-factor authentication is not being enrolled")
+
+// EnableMFATxParams contains the input parameters of the enable MFA transaction
+type EnableMFATxParams struct {
+	Username string `json:"username"`
+	// Step is the time step of the code that confirmed the enrollment
+	Step int64 `json:"step"`
+	// RecoveryCodeHashes replace the recovery codes the user may still have
+	RecoveryCodeHashes []string `json:"recovery_code_hashes"`
+}
+
+// EnableMFATxResult is the result of the enable MFA transaction
+type EnableMFATxResult struct {
+	TotpSecret    TotpSecret     `json:"totp_secret"`
+	RecoveryCodes []RecoveryCode `json:"recovery_codes"`
+}
+
+// mfaDetails is what the audit log keeps about a change of two-factor authentication
+type mfaDetails struct {
+	RecoveryCodes int `json:"recovery_codes,omitempty"`
+}
+
+// EnableMFATx confirms the enrollment of a user in two-factor authentication, stores their recovery codes
+// and records the change in the audit log.
+// It returns ErrMFANotPending when the user has no pending enrollment.
+func (store *SQLStore) EnableMFATx(ctx context.Context, arg EnableMFATxParams) (EnableMFATxResult, error) {
+	var result EnableMFATxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		result = EnableMFATxResult{}
+		var err error
+		result.TotpSecret, err = q.EnableTotpSecret(ctx, EnableTotpSecretParams{
+			Step:     arg.Step,
+			Username: arg.Username,
+		})
+		if err != nil {
+			if errors.Is(err, ErrRecordNotFound) {
+				return ErrMFANotPending
+			}
+			return err
+		}
+
+		err = q.DeleteRecoveryCodes(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		for _, codeHash := range arg.RecoveryCodeHashes {
+			code, err := q.CreateRecoveryCode(ctx, CreateRecoveryCodeParams{
+				Username: arg.Username,
+				CodeHash: codeHash,
+			})
+			if err != nil {
+				return err
+			}
+			result.RecoveryCodes = append(result.RecoveryCodes, code)
+		}
+
+		data, err := json.Marshal(mfaDetails{RecoveryCodes: len(result.RecoveryCodes)})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreateAuditLog(ctx, CreateAuditLogParams{
+			Action:  AuditActionMFAEnabled,
+			Actor:   arg.Username,
+			Subject: arg.Username,
+			Details: data,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// DisableMFATxParams contains the input parameters of the disable MFA transaction
+type DisableMFATxParams struct {
+	Username string `json:"username"`
+	// DisabledBy is the user who turned it off, the user themselves or a banker
+	DisabledBy string `json:"disabled_by"`
+}
+
+// DisableMFATx removes the TOTP secret and the recovery codes of a user and records the change in the audit log
+func (store *SQLStore) DisableMFATx(ctx context.Context, arg DisableMFATxParams) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		err := deleteMFA(ctx, q, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(mfaDetails{})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreateAuditLog(ctx, CreateAuditLogParams{
+			Action:  AuditActionMFADisabled,
+			Actor:   arg.DisabledBy,
+			Subject: arg.Username,
+			Details: data,
+		})
+		return err
+	})
+}
+
+func deleteMFA(ctx context.Context, q *Queries, username string) error {
+	err := q.DeleteTotpSecret(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	return q.DeleteRecoveryCodes(ctx, username)
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnableMFATx(t *testing.T) {
+	user := createRandomUser(t)
+
+	// nothing to enable before the user enrolls
+	_, err := testStore.EnableMFATx(context.Background(), EnableMFATxParams{Username: user.Username, Step: 1})
+	require.ErrorIs(t, err, ErrMFANotPending)
+
+	secret, err := testStore.CreateTotpSecret(context.Background(), CreateTotpSecretParams{
+		Username: user.Username,
+		Secret:   util.RandomString(32),
+	})
+	require.NoError(t, err)
+	require.False(t, secret.EnabledAt.Valid)
+
+	arg := EnableMFATxParams{
+		Username:           user.Username,
+		Step:               100,
+		RecoveryCodeHashes: []string{util.RandomString(64), util.RandomString(64)},
+	}
+	result, err := testStore.EnableMFATx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.TotpSecret.EnabledAt.Valid)
+	require.Equal(t, arg.Step, result.TotpSecret.LastUsedStep)
+	require.Len(t, result.RecoveryCodes, 2)
+
+	// the enrollment can't be restarted once it is enabled
+	_, err = testStore.CreateTotpSecret(context.Background(), CreateTotpSecretParams{
+		Username: user.Username,
+		Secret:   util.RandomString(32),
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	_, err = testStore.EnableMFATx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrMFANotPending)
+
+	// a recovery code can only be used once
+	_, err = testStore.UseRecoveryCode(context.Background(), UseRecoveryCodeParams{
+		Username: user.Username,
+		CodeHash: arg.RecoveryCodeHashes[0],
+	})
+	require.NoError(t, err)
+	_, err = testStore.UseRecoveryCode(context.Background(), UseRecoveryCodeParams{
+		Username: user.Username,
+		CodeHash: arg.RecoveryCodeHashes[0],
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	// codes of a step that was already used are refused
+	_, err = testStore.UseTotpStep(context.Background(), UseTotpStepParams{Step: arg.Step, Username: user.Username})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+	_, err = testStore.UseTotpStep(context.Background(), UseTotpStepParams{Step: arg.Step + 1, Username: user.Username})
+	require.NoError(t, err)
+}
+
+func TestDisableMFATx(t *testing.T) {
+	user := createRandomUser(t)
+
+	_, err := testStore.CreateTotpSecret(context.Background(), CreateTotpSecretParams{
+		Username: user.Username,
+		Secret:   util.RandomString(32),
+	})
+	require.NoError(t, err)
+
+	err = testStore.DisableMFATx(context.Background(), DisableMFATxParams{
+		Username:   user.Username,
+		DisabledBy: user.Username,
+	})
+	require.NoError(t, err)
+
+	_, err = testStore.GetTotpSecret(context.Background(), user.Username)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
@@ -0,0 +1,144 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrInterestAlreadyPosted is returned when posting the interest of a period that has already been posted
+var ErrInterestAlreadyPosted = errors.New("interest already posted for this period")
+
+// PostInterestTxParams contains the input parameters of the post interest transaction
+type PostInterestTxParams struct {
+	AccountID int64 `json:"account_id"`
+	// Period is the first day of the month to post
+	Period time.Time `json:"period"`
+}
+
+// PostInterestTxResult is the result of the post interest transaction
+type PostInterestTxResult struct {
+	Posting InterestPosting `json:"posting"`
+	Account Account         `json:"account"`
+	// Entry and ExpenseEntry are only set when at least one minor unit is paid
+	Entry        Entry `json:"entry"`
+	ExpenseEntry Entry `json:"expense_entry"`
+}
+
+// PostInterestTx pays the interest an account accrued over a month from the interest expense account of its currency.
+// The accrued micros and the carry of the previous posting are rounded down to minor units as described in util,
+// what is left is carried to the next posting. A period is posted at most once, ErrInterestAlreadyPosted is returned otherwise.
+func (store *SQLStore) PostInterestTx(ctx context.Context, arg PostInterestTxParams) (PostInterestTxResult, error) {
+	var result PostInterestTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		result = PostInterestTxResult{}
+		account, err := q.GetAccount(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		system, err := q.GetSystemAccount(ctx, GetSystemAccountParams{
+			Purpose:  SystemAccountInterestExpense,
+			Currency: account.Currency,
+		})
+		if err != nil {
+			return err
+		}
+
+		// locking the account serializes the postings of the account
+		accounts, err := lockAccounts(ctx, q, arg.AccountID, system.AccountID)
+		if err != nil {
+			return err
+		}
+		result.Account = accounts[arg.AccountID]
+
+		period := pgtype.Date{Time: arg.Period, Valid: true}
+		_, err = q.GetInterestPosting(ctx, GetInterestPostingParams{
+			AccountID: arg.AccountID,
+			Period:    period,
+		})
+		if err == nil {
+			return ErrInterestAlreadyPosted
+		}
+		if !errors.Is(err, ErrRecordNotFound) {
+			return err
+		}
+
+		periodEnd := pgtype.Date{Time: arg.Period.AddDate(0, 1, 0), Valid: true}
+		accrued, err := q.SumUnpostedInterestAccruals(ctx, SumUnpostedInterestAccrualsParams{
+			AccountID:   arg.AccountID,
+			PeriodStart: period,
+			PeriodEnd:   periodEnd,
+		})
+		if err != nil {
+			return err
+		}
+
+		previous, err := q.GetLastInterestPosting(ctx, GetLastInterestPostingParams{
+			AccountID: arg.AccountID,
+			Period:    period,
+		})
+		if err != nil && !errors.Is(err, ErrRecordNotFound) {
+			return err
+		}
+		accrued += previous.CarryMicros
+
+		amount, carry := util.SplitInterestMicros(accrued)
+
+		postingArg := CreateInterestPostingParams{
+			AccountID:     arg.AccountID,
+			Period:        period,
+			AccruedMicros: accrued,
+			Amount:        amount,
+			CarryMicros:   carry,
+		}
+
+		if amount > 0 {
+			result.ExpenseEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+				AccountID: system.AccountID,
+				Amount:    -amount,
+			})
+			if err != nil {
+				return err
+			}
+
+			result.Entry, err = q.CreateEntry(ctx, CreateEntryParams{
+				AccountID: arg.AccountID,
+				Amount:    amount,
+			})
+			if err != nil {
+				return err
+			}
+
+			if arg.AccountID < system.AccountID {
+				result.Account, _, err = addMoney(ctx, q, arg.AccountID, amount, system.AccountID, -amount)
+			} else {
+				_, result.Account, err = addMoney(ctx, q, system.AccountID, -amount, arg.AccountID, amount)
+			}
+			if err != nil {
+				return err
+			}
+
+			postingArg.EntryID = pgtype.Int8{Int64: result.Entry.ID, Valid: true}
+			postingArg.ExpenseEntryID = pgtype.Int8{Int64: result.ExpenseEntry.ID, Valid: true}
+		}
+
+		result.Posting, err = q.CreateInterestPosting(ctx, postingArg)
+		if err != nil {
+			return err
+		}
+
+		return q.MarkInterestAccrualsPosted(ctx, MarkInterestAccrualsPostedParams{
+			PostingID:   pgtype.Int8{Int64: result.Posting.ID, Valid: true},
+			AccountID:   arg.AccountID,
+			PeriodStart: period,
+			PeriodEnd:   periodEnd,
+		})
+	})
+
+	return result, err
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomSavingsAccount(t *testing.T) Account {
+	user := createRandomUser(t)
+	account, err := testStore.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  util.RandomMoney(),
+		Currency: util.RandomCurrency(),
+		Product:  AccountProductSavings,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountProductSavings, account.Product)
+
+	return account
+}
+
+func accrueInterest(t *testing.T, account Account, day time.Time, micros int64) {
+	created, err := testStore.CreateInterestAccrual(context.Background(), CreateInterestAccrualParams{
+		AccountID:     account.ID,
+		AccrualDate:   pgtype.Date{Time: day, Valid: true},
+		Balance:       account.Balance,
+		AnnualRateBps: 150,
+		AmountMicros:  micros,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), created)
+}
+
+func TestPostInterestTx(t *testing.T) {
+	account := createRandomSavingsAccount(t)
+	january := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	february := january.AddDate(0, 1, 0)
+
+	accrueInterest(t, account, january, 1_500_000)
+	accrueInterest(t, account, january.AddDate(0, 0, 30), 800_000)
+	// accruing the same day again is a no-op
+	created, err := testStore.CreateInterestAccrual(context.Background(), CreateInterestAccrualParams{
+		AccountID:    account.ID,
+		AccrualDate:  pgtype.Date{Time: january, Valid: true},
+		AmountMicros: 1_500_000,
+	})
+	require.NoError(t, err)
+	require.Zero(t, created)
+
+	result, err := testStore.PostInterestTx(context.Background(), PostInterestTxParams{
+		AccountID: account.ID,
+		Period:    january,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(2_300_000), result.Posting.AccruedMicros)
+	require.Equal(t, int64(2), result.Posting.Amount)
+	require.Equal(t, int64(300_000), result.Posting.CarryMicros)
+	require.Equal(t, account.Balance+2, result.Account.Balance)
+	require.Equal(t, int64(2), result.Entry.Amount)
+	require.Equal(t, int64(-2), result.ExpenseEntry.Amount)
+
+	system, err := testStore.GetSystemAccount(context.Background(), GetSystemAccountParams{
+		Purpose:  SystemAccountInterestExpense,
+		Currency: account.Currency,
+	})
+	require.NoError(t, err)
+	require.Equal(t, system.AccountID, result.ExpenseEntry.AccountID)
+
+	_, err = testStore.PostInterestTx(context.Background(), PostInterestTxParams{
+		AccountID: account.ID,
+		Period:    january,
+	})
+	require.ErrorIs(t, err, ErrInterestAlreadyPosted)
+
+	// the carry of january is paid with february
+	accrueInterest(t, account, february, 700_000)
+
+	result, err = testStore.PostInterestTx(context.Background(), PostInterestTxParams{
+		AccountID: account.ID,
+		Period:    february,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1_000_000), result.Posting.AccruedMicros)
+	require.Equal(t, int64(1), result.Posting.Amount)
+	require.Zero(t, result.Posting.CarryMicros)
+	require.Equal(t, account.Balance+3, result.Account.Balance)
+}
+
+func TestPostInterestTxNothingToPay(t *testing.T) {
+	account := createRandomSavingsAccount(t)
+	period := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	accrueInterest(t, account, period, 400_000)
+
+	result, err := testStore.PostInterestTx(context.Background(), PostInterestTxParams{
+		AccountID: account.ID,
+		Period:    period,
+	})
+	require.NoError(t, err)
+	require.Zero(t, result.Posting.Amount)
+	require.Equal(t, int64(400_000), result.Posting.CarryMicros)
+	require.False(t, result.Posting.EntryID.Valid)
+	require.Equal(t, account.Balance, result.Account.Balance)
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrPasswordResetInvalid is returned when a reset token is unknown, already used or expired.
+// The cases aren't told apart so that the error reveals nothing about the tokens.
+var ErrPasswordResetInvalid = errors.New("the password reset token is invalid or has expired")
+
+// ResetPasswordTxParams contains the input parameters of the reset password transaction
+type ResetPasswordTxParams struct {
+	TokenHash      string `json:"token_hash"`
+	HashedPassword string `json:"hashed_password"`
+}
+
+// ResetPasswordTxResult is the result of the reset password transaction
+type ResetPasswordTxResult struct {
+	User User `json:"user"`
+	// BlockedSessions is the number of sessions that were still usable
+	BlockedSessions int64 `json:"blocked_sessions"`
+}
+
+// passwordResetDetails is what the audit log keeps about a password reset
+type passwordResetDetails struct {
+	BlockedSessions int64 `json:"blocked_sessions"`
+}
+
+// ResetPasswordTx replaces the password of a user with a single-use reset token.
+// Every token of the user is used up, their sessions are blocked and the reset is recorded in the audit log.
+func (store *SQLStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error) {
+	var result ResetPasswordTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		result = ResetPasswordTxResult{}
+		reset, err := q.GetPasswordResetForUpdate(ctx, arg.TokenHash)
+		if err != nil {
+			if errors.Is(err, ErrRecordNotFound) {
+				return ErrPasswordResetInvalid
+			}
+			return err
+		}
+		if reset.UsedAt.Valid || time.Now().After(reset.ExpiresAt) {
+			return ErrPasswordResetInvalid
+		}
+
+		user, err := q.GetUser(ctx, reset.Username)
+		if err != nil {
+			return err
+		}
+		if user.ErasedAt.Valid {
+			return ErrPasswordResetInvalid
+		}
+
+		result.User, err = q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			HashedPassword: arg.HashedPassword,
+			Username:       reset.Username,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.UsePasswordResets(ctx, reset.Username)
+		if err != nil {
+			return err
+		}
+
+		result.BlockedSessions, err = q.BlockUserSessions(ctx, reset.Username)
+		if err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(passwordResetDetails{BlockedSessions: result.BlockedSessions})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreateAuditLog(ctx, CreateAuditLogParams{
+			Action:  AuditActionPasswordReset,
+			Actor:   reset.Username,
+			Subject: reset.Username,
+			Details: data,
+		})
+		return err
+	})
+
+	return result, err
+}
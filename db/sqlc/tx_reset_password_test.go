@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPasswordReset(t *testing.T, user User, expiresAt time.Time) PasswordReset {
+	arg := CreatePasswordResetParams{
+		Username:  user.Username,
+		TokenHash: util.RandomString(64),
+		ExpiresAt: expiresAt,
+	}
+
+	reset, err := testStore.CreatePasswordReset(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, reset.Username)
+	require.Equal(t, arg.TokenHash, reset.TokenHash)
+	require.False(t, reset.UsedAt.Valid)
+
+	return reset
+}
+
+func TestResetPasswordTx(t *testing.T) {
+	user := createRandomUser(t)
+	reset := createRandomPasswordReset(t, user, time.Now().Add(time.Minute))
+	other := createRandomPasswordReset(t, user, time.Now().Add(time.Minute))
+
+	_, err := testStore.CreateSession(context.Background(), CreateSessionParams{
+		ID:           uuid.New(),
+		Username:     user.Username,
+		RefreshToken: util.RandomString(32),
+		UserAgent:    "test",
+		ClientIp:     "127.0.0.1",
+	})
+	require.NoError(t, err)
+
+	hashedPassword, err := util.HashPassword(util.RandomString(8))
+	require.NoError(t, err)
+
+	result, err := testStore.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		TokenHash:      reset.TokenHash,
+		HashedPassword: hashedPassword,
+	})
+	require.NoError(t, err)
+	require.Equal(t, hashedPassword, result.User.HashedPassword)
+	require.True(t, result.User.PasswordChangedAt.After(user.PasswordChangedAt))
+	require.Equal(t, int64(1), result.BlockedSessions)
+
+	// the token can't be used twice, and the other tokens of the user are used up too
+	for _, tokenHash := range []string{reset.TokenHash, other.TokenHash} {
+		_, err = testStore.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+			TokenHash:      tokenHash,
+			HashedPassword: hashedPassword,
+		})
+		require.ErrorIs(t, err, ErrPasswordResetInvalid)
+	}
+}
+
+func TestResetPasswordTxInvalidToken(t *testing.T) {
+	user := createRandomUser(t)
+	expired := createRandomPasswordReset(t, user, time.Now().Add(-time.Minute))
+
+	for _, tokenHash := range []string{expired.TokenHash, util.RandomString(64)} {
+		_, err := testStore.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+			TokenHash:      tokenHash,
+			HashedPassword: "hash",
+		})
+		require.ErrorIs(t, err, ErrPasswordResetInvalid)
+	}
+
+	got, err := testStore.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, user.HashedPassword, got.HashedPassword)
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrReversalExceedsTransfer is returned when refunding more than what is left to refund on a transfer
+	ErrReversalExceedsTransfer = errors.New("refund exceeds the amount left to refund on the transfer")
+	// ErrReverseReversal is returned when reversing a transfer that is itself a reversal
+	ErrReverseReversal = errors.New("a reversal can't be reversed")
+)
+
+// ReverseTransferTxParams contains the input parameters of the reverse transfer transaction
+type ReverseTransferTxParams struct {
+	TransferID int64 `json:"transfer_id"`
+	// Amount is the amount to refund, zero refunds everything that hasn't been refunded yet
+	Amount     int64  `json:"amount"`
+	Reason     string `json:"reason"`
+	ReversedBy string `json:"reversed_by"`
+}
+
+// ReverseTransferTxResult is the result of the reverse transfer transaction
+type ReverseTransferTxResult struct {
+	Reversal TransferReversal `json:"reversal"`
+	// Transfer is the compensating transfer from the receiver back to the sender
+	Transfer TransferTxResult `json:"transfer"`
+	// Remaining is what can still be refunded on the original transfer
+	Remaining int64 `json:"remaining"`
+}
+
+// ReverseTransferTx refunds all or part of a transfer with a compensating transfer linked to the original one.
+// The total refunded never exceeds the original amount, and both accounts must still be open. Refunds are neither charged a fee nor checked against
+// transfer limits, and the fee of the original transfer is not refunded.
+func (store *SQLStore) ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (ReverseTransferTxResult, error) {
+	var result ReverseTransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		result = ReverseTransferTxResult{}
+		// locking the original transfer serializes its refunds
+		original, err := q.GetTransferForUpdate(ctx, arg.TransferID)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.GetTransferReversalByReversalTransfer(ctx, original.ID)
+		if err == nil {
+			return ErrReverseReversal
+		}
+		if !errors.Is(err, ErrRecordNotFound) {
+			return err
+		}
+
+		refunded, err := q.SumTransferReversals(ctx, original.ID)
+		if err != nil {
+			return err
+		}
+
+		remaining := original.Amount - refunded
+		amount := arg.Amount
+		if amount == 0 {
+			amount = remaining
+		}
+		if amount <= 0 || amount > remaining {
+			return fmt.Errorf("%w: %d left to refund", ErrReversalExceedsTransfer, remaining)
+		}
+
+		accounts, err := lockAccounts(ctx, q, original.FromAccountID, original.ToAccountID)
+		if err != nil {
+			return err
+		}
+
+		// the refund can't go through a closed account, which has given its balance away
+		err = checkAccountsOpen(accounts[original.ToAccountID], accounts[original.FromAccountID])
+		if err != nil {
+			return err
+		}
+
+		result.Transfer, err = moveMoney(ctx, q, TransferTxParams{
+			FromAccountID: original.ToAccountID,
+			ToAccountID:   original.FromAccountID,
+			Amount:        amount,
+		}, 0)
+		if err != nil {
+			return err
+		}
+
+		result.Reversal, err = q.CreateTransferReversal(ctx, CreateTransferReversalParams{
+			TransferID:         original.ID,
+			ReversalTransferID: result.Transfer.Transfer.ID,
+			Amount:             amount,
+			Reason:             arg.Reason,
+			ReversedBy:         arg.ReversedBy,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Remaining = remaining - amount
+		return nil
+	})
+	if err == nil {
+		observeTransfer(result.Transfer)
+	}
+
+	return result, err
+}
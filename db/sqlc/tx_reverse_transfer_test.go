@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReverseTransferTxPartial(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	original, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        30,
+	})
+	require.NoError(t, err)
+
+	result, err := testStore.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+		Amount:     10,
+		Reason:     "damaged item",
+		ReversedBy: account2.Owner,
+	})
+	require.NoError(t, err)
+	require.Equal(t, original.Transfer.ID, result.Reversal.TransferID)
+	require.Equal(t, result.Transfer.Transfer.ID, result.Reversal.ReversalTransferID)
+	require.Equal(t, int64(10), result.Reversal.Amount)
+	require.Equal(t, "damaged item", result.Reversal.Reason)
+	require.Equal(t, int64(20), result.Remaining)
+	require.Equal(t, account2.ID, result.Transfer.Transfer.FromAccountID)
+	require.Equal(t, account1.ID, result.Transfer.Transfer.ToAccountID)
+	require.Equal(t, account2.Balance+20, result.Transfer.FromAccount.Balance)
+	require.Equal(t, account1.Balance-20, result.Transfer.ToAccount.Balance)
+
+	// refunding more than what is left fails and leaves nothing behind
+	_, err = testStore.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+		Amount:     21,
+		ReversedBy: account2.Owner,
+	})
+	require.ErrorIs(t, err, ErrReversalExceedsTransfer)
+
+	// no amount refunds the rest
+	result, err = testStore.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+		ReversedBy: account2.Owner,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(20), result.Reversal.Amount)
+	require.Zero(t, result.Remaining)
+
+	_, err = testStore.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+		ReversedBy: account2.Owner,
+	})
+	require.ErrorIs(t, err, ErrReversalExceedsTransfer)
+
+	reversals, err := testStore.ListTransferReversals(context.Background(), original.Transfer.ID)
+	require.NoError(t, err)
+	require.Len(t, reversals, 2)
+
+	updatedAccount1, err := testStore.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+}
+
+func TestReverseTransferTxReversal(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	original, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	result, err := testStore.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+		ReversedBy: account2.Owner,
+	})
+	require.NoError(t, err)
+
+	_, err = testStore.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: result.Transfer.Transfer.ID,
+		ReversedBy: account1.Owner,
+	})
+	require.ErrorIs(t, err, ErrReverseReversal)
+}
+
+func TestReverseTransferTxAccountClosed(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	original, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	_, err = testStore.CloseAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+
+	_, err = testStore.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+		ReversedBy: account2.Owner,
+	})
+	require.ErrorIs(t, err, ErrAccountClosed)
+
+	refunded, err := testStore.SumTransferReversals(context.Background(), original.Transfer.ID)
+	require.NoError(t, err)
+	require.Zero(t, refunded)
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// TransferReviewStatusPending is the status of a transfer held by the risk screening
+	TransferReviewStatusPending = "pending_review"
+	// TransferReviewStatusApproved is the status of a held transfer that has been executed, or sent to the signatories
+	// of the account when it needs their approval
+	TransferReviewStatusApproved = "approved"
+	// TransferReviewStatusRejected is the status of a held transfer that will never be executed
+	TransferReviewStatusRejected = "rejected"
+)
+
+// ErrTransferReviewClosed is returned when reviewing a transfer that has already been approved or rejected
+var ErrTransferReviewClosed = errors.New("transfer review is already closed")
+
+// ReviewTransferTxParams contains the input parameters of the review transfer transaction
+type ReviewTransferTxParams struct {
+	ID         int64  `json:"id"`
+	ReviewedBy string `json:"reviewed_by"`
+	Approved   bool   `json:"approved"`
+	// RequestExpiresAt is when the approval request expires, if the approved transfer needs the approval of signatories
+	RequestExpiresAt time.Time `json:"request_expires_at"`
+}
+
+// ReviewTransferTxResult is the result of the review transfer transaction
+type ReviewTransferTxResult struct {
+	Review TransferReview `json:"review"`
+	// Transfer is only set when the review is approved and the transfer executed
+	Transfer *TransferTxResult `json:"transfer,omitempty"`
+	// TransferRequest is only set when the review is approved but the transfer waits for the approval of signatories
+	TransferRequest *TransferRequest `json:"transfer_request,omitempty"`
+}
+
+// ReviewTransferTx approves or rejects a transfer held by the risk screening.
+// An approved transfer goes on as if it had passed the screening: when it exceeds the approval threshold of the account,
+// a transfer request waits for its signatories, otherwise it is executed within the same database transaction,
+// transfer limits included.
+func (store *SQLStore) ReviewTransferTx(ctx context.Context, arg ReviewTransferTxParams) (ReviewTransferTxResult, error) {
+	var result ReviewTransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		result = ReviewTransferTxResult{}
+		review, err := q.GetTransferReviewForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		if review.Status != TransferReviewStatusPending {
+			return ErrTransferReviewClosed
+		}
+
+		updateArg := UpdateTransferReviewParams{
+			ID:         arg.ID,
+			Status:     TransferReviewStatusRejected,
+			ReviewedBy: arg.ReviewedBy,
+		}
+
+		if arg.Approved {
+			updateArg.Status = TransferReviewStatusApproved
+
+			transferArg := TransferTxParams{
+				FromAccountID: review.FromAccountID,
+				ToAccountID:   review.ToAccountID,
+				Amount:        review.Amount,
+			}
+
+			request, requested, err := RequestTransferApproval(ctx, q, RequestTransferApprovalParams{
+				TransferTxParams: transferArg,
+				RequestedBy:      review.RequestedBy,
+				ExpiresAt:        arg.RequestExpiresAt,
+			})
+			if err != nil {
+				return err
+			}
+
+			if requested {
+				result.TransferRequest = &request
+			} else {
+				transferResult, err := transfer(ctx, q, transferArg)
+				if err != nil {
+					return err
+				}
+
+				result.Transfer = &transferResult
+				updateArg.TransferID = pgtype.Int8{Int64: transferResult.Transfer.ID, Valid: true}
+			}
+		}
+
+		result.Review, err = q.UpdateTransferReview(ctx, updateArg)
+		return err
+	})
+	if err == nil && result.Transfer != nil {
+		observeTransfer(*result.Transfer)
+	}
+
+	return result, err
+}
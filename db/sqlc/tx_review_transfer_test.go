@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomTransferReview(t *testing.T, account1, account2 Account) TransferReview {
+	arg := CreateTransferReviewParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		RequestedBy:   account1.Owner,
+		Reasons:       []string{"unusual amount"},
+	}
+
+	review, err := testStore.CreateTransferReview(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, review.ID)
+	require.Equal(t, TransferReviewStatusPending, review.Status)
+	require.Equal(t, arg.Reasons, review.Reasons)
+	require.False(t, review.TransferID.Valid)
+	require.NotZero(t, review.CreatedAt)
+
+	return review
+}
+
+func TestReviewTransferTxApproved(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	banker := createRandomUser(t)
+	review := createRandomTransferReview(t, account1, account2)
+
+	result, err := testStore.ReviewTransferTx(context.Background(), ReviewTransferTxParams{
+		ID:         review.ID,
+		ReviewedBy: banker.Username,
+		Approved:   true,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, result.Transfer)
+	require.Equal(t, TransferReviewStatusApproved, result.Review.Status)
+	require.Equal(t, banker.Username, result.Review.ReviewedBy.String)
+	require.True(t, result.Review.ReviewedAt.Valid)
+	require.Equal(t, result.Transfer.Transfer.ID, result.Review.TransferID.Int64)
+	require.Equal(t, account1.Balance-review.Amount, result.Transfer.FromAccount.Balance)
+	require.Equal(t, account2.Balance+review.Amount, result.Transfer.ToAccount.Balance)
+
+	// a review can only be closed once
+	_, err = testStore.ReviewTransferTx(context.Background(), ReviewTransferTxParams{
+		ID:         review.ID,
+		ReviewedBy: banker.Username,
+		Approved:   true,
+	})
+	require.ErrorIs(t, err, ErrTransferReviewClosed)
+}
+
+func TestReviewTransferTxApprovalPolicy(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	banker := createRandomUser(t)
+	review := createRandomTransferReview(t, account1, account2)
+
+	policy, err := testStore.UpsertApprovalPolicy(context.Background(), UpsertApprovalPolicyParams{
+		AccountID:         account1.ID,
+		Threshold:         review.Amount - 1,
+		RequiredApprovals: 2,
+	})
+	require.NoError(t, err)
+
+	// the approved transfer exceeds the threshold, so it waits for the signatories instead of being executed
+	expiresAt := time.Now().Add(time.Hour)
+	result, err := testStore.ReviewTransferTx(context.Background(), ReviewTransferTxParams{
+		ID:               review.ID,
+		ReviewedBy:       banker.Username,
+		Approved:         true,
+		RequestExpiresAt: expiresAt,
+	})
+	require.NoError(t, err)
+	require.Nil(t, result.Transfer)
+	require.NotNil(t, result.TransferRequest)
+	require.Equal(t, TransferReviewStatusApproved, result.Review.Status)
+	require.False(t, result.Review.TransferID.Valid)
+
+	require.Equal(t, review.FromAccountID, result.TransferRequest.FromAccountID)
+	require.Equal(t, review.ToAccountID, result.TransferRequest.ToAccountID)
+	require.Equal(t, review.Amount, result.TransferRequest.Amount)
+	require.Equal(t, review.RequestedBy, result.TransferRequest.RequestedBy)
+	require.Equal(t, policy.RequiredApprovals, result.TransferRequest.RequiredApprovals)
+	require.Equal(t, TransferRequestStatusPending, result.TransferRequest.Status)
+	require.WithinDuration(t, expiresAt, result.TransferRequest.ExpiresAt, time.Second)
+
+	updatedAccount1, err := testStore.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+}
+
+func TestReviewTransferTxRejected(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	banker := createRandomUser(t)
+	review := createRandomTransferReview(t, account1, account2)
+
+	result, err := testStore.ReviewTransferTx(context.Background(), ReviewTransferTxParams{
+		ID:         review.ID,
+		ReviewedBy: banker.Username,
+	})
+	require.NoError(t, err)
+	require.Nil(t, result.Transfer)
+	require.Equal(t, TransferReviewStatusRejected, result.Review.Status)
+	require.False(t, result.Review.TransferID.Valid)
+
+	updatedAccount1, err := testStore.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+}
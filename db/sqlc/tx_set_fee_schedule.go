@@ -0,0 +1,54 @@
+package db
+
+import "context"
+
+// SetFeeScheduleTxParams contains the input parameters of the set fee schedule transaction
+type SetFeeScheduleTxParams struct {
+	UpsertFeeScheduleParams
+	// Tiers are only used by tiered schedules, their currency is the one of the schedule
+	Tiers []CreateFeeTierParams `json:"tiers"`
+}
+
+// SetFeeScheduleTxResult is the result of the set fee schedule transaction
+type SetFeeScheduleTxResult struct {
+	Schedule FeeSchedule `json:"schedule"`
+	Tiers    []FeeTier   `json:"tiers"`
+}
+
+// SetFeeScheduleTx creates or replaces the fee schedule of a currency and its tiers within a single database transaction,
+// so that transfers never see a schedule with the tiers of the previous one.
+func (store *SQLStore) SetFeeScheduleTx(ctx context.Context, arg SetFeeScheduleTxParams) (SetFeeScheduleTxResult, error) {
+	var result SetFeeScheduleTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		result = SetFeeScheduleTxResult{}
+		var err error
+		result.Schedule, err = q.UpsertFeeSchedule(ctx, arg.UpsertFeeScheduleParams)
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteFeeTiers(ctx, arg.Currency)
+		if err != nil {
+			return err
+		}
+
+		result.Tiers = []FeeTier{}
+		if arg.Kind != FeeKindTiered {
+			return nil
+		}
+
+		for _, tierArg := range arg.Tiers {
+			tierArg.Currency = arg.Currency
+			tier, err := q.CreateFeeTier(ctx, tierArg)
+			if err != nil {
+				return err
+			}
+			result.Tiers = append(result.Tiers, tier)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
@@ -2,6 +2,9 @@ package db
 
 import (
 	"context"
+	"sort"
+
+	"bitbucket.org/jessyw/go_simplebank/metrics"
 )
 
 // TransferTxParams contains the input parameters of the transfer transaction
@@ -18,58 +21,176 @@ type TransferTxResult struct {
 	ToAccount   Account  `json:"to_account"`
 	FromEntry   Entry    `json:"from_entry"`
 	ToEntry     Entry    `json:"to_entry"`
+	// Fee is charged to the sender on top of the amount, FeeEntry is only set when it isn't zero
+	Fee      int64 `json:"fee"`
+	FeeEntry Entry `json:"fee_entry"`
 }
 
 // TransferTx performs a money transfer from one account to the other.
-// it create a transfer record, add account entries, and update accounts balance within a single database transaction
+// it create a transfer record, add account entries, and update accounts balance within a single database transaction.
+// The fee of the currency's fee schedule is debited from the sender and credited to the fee revenue account in the same transaction.
+// It returns a TransferLimitError when the transfer exceeds one of the limits of the source account,
+// and ErrAccountClosed when one of the accounts is closed.
 func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
 	var result TransferTxResult
 
 	err := store.execTx(ctx, func(q *Queries) error {
 		var err error
+		result, err = transfer(ctx, q, arg)
+		return err
+	})
+	if err == nil {
+		observeTransfer(result)
+	}
+
+	return result, err
+}
+
+// observeTransfer records a committed transfer in the metrics
+func observeTransfer(result TransferTxResult) {
+	metrics.ObserveTransfer(result.FromAccount.Currency, result.Transfer.Amount)
+}
+
+// transfer moves the money within the caller's database transaction.
+func transfer(ctx context.Context, q *Queries, arg TransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	fromAccount, err := q.GetAccount(ctx, arg.FromAccountID)
+	if err != nil {
+		return result, err
+	}
+
+	fee, err := TransferFee(ctx, q, fromAccount.Currency, arg.Amount)
+	if err != nil {
+		return result, err
+	}
 
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
+	accountIDs := []int64{arg.FromAccountID, arg.ToAccountID}
+	var feeAccount SystemAccount
+	if fee > 0 {
+		feeAccount, err = q.GetSystemAccount(ctx, GetSystemAccountParams{
+			Purpose:  SystemAccountFeeRevenue,
+			Currency: fromAccount.Currency,
 		})
 		if err != nil {
-			return err
+			return result, err
 		}
+		accountIDs = append(accountIDs, feeAccount.AccountID)
+	}
 
-		// create from entry
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
-		})
-		if err != nil {
+	accounts, err := lockAccounts(ctx, q, accountIDs...)
+	if err != nil {
+		return result, err
+	}
 
-			return err
-		}
+	err = checkAccountsOpen(accounts[arg.FromAccountID], accounts[arg.ToAccountID])
+	if err != nil {
+		return result, err
+	}
 
-		// create to entry
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
-		})
-		if err != nil {
+	return sendMoney(ctx, q, accounts[arg.FromAccountID], arg, fee, feeAccount.AccountID)
+}
 
-			return err
-		}
+// sendMoney checks the limits of the sender, then moves the money and charges the fee to the sender.
+// Every account involved must already be locked by the caller's database transaction.
+func sendMoney(ctx context.Context, q *Queries, fromAccount Account, arg TransferTxParams, fee int64, feeAccountID int64) (TransferTxResult, error) {
+	var result TransferTxResult
 
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
+	err := checkTransferLimits(ctx, q, fromAccount, arg.Amount)
+	if err != nil {
+		return result, err
+	}
 
-		} else {
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+	result, err = moveMoney(ctx, q, arg, fee)
+	result.Fee = fee
+	if err != nil || fee == 0 {
+		return result, err
+	}
 
-		}
-		return err
+	result.FeeEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: arg.FromAccountID,
+		Amount:    -result.Fee,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	_, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: feeAccountID,
+		Amount:    result.Fee,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	// every account is locked already, the order of the updates doesn't matter anymore
+	result.FromAccount, _, err = addMoney(ctx, q, arg.FromAccountID, -result.Fee, feeAccountID, result.Fee)
+	return result, err
+}
+
+// moveMoney records the transfer and its entries and updates both balances.
+// The accounts must already be locked by the caller's database transaction.
+func moveMoney(ctx context.Context, q *Queries, arg TransferTxParams, fee int64) (TransferTxResult, error) {
+	var result TransferTxResult
+	var err error
+
+	result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		Fee:           fee,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	// create from entry
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: arg.FromAccountID,
+		Amount:    -arg.Amount,
 	})
+	if err != nil {
+		return result, err
+	}
 
+	// create to entry
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: arg.ToAccountID,
+		Amount:    arg.Amount,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if arg.FromAccountID < arg.ToAccountID {
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
+	} else {
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+	}
 	return result, err
 }
 
+// lockAccounts locks the accounts in the order of their IDs to avoid deadlocks, and returns them by ID.
+func lockAccounts(ctx context.Context, q *Queries, accountIDs ...int64) (map[int64]Account, error) {
+	sorted := append([]int64{}, accountIDs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	accounts := make(map[int64]Account, len(sorted))
+	for _, accountID := range sorted {
+		if _, ok := accounts[accountID]; ok {
+			continue
+		}
+
+		account, err := q.GetAccountForUpdate(ctx, accountID)
+		if err != nil {
+			return nil, err
+		}
+		accounts[accountID] = account
+	}
+
+	return accounts, nil
+}
+
 func addMoney(ctx context.Context, q *Queries, accountID int64, amount int64, accountID2 int64, amount2 int64) (account Account, account2 Account, err error) {
 	account, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
 		ID:     accountID,
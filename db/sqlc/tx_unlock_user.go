@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// UnlockUserTxParams contains the input parameters of the unlock user transaction
+type UnlockUserTxParams struct {
+	Username   string `json:"username"`
+	UnlockedBy string `json:"unlocked_by"`
+}
+
+// UnlockUserTxResult is the result of the unlock user transaction
+type UnlockUserTxResult struct {
+	// Failures is the number of failed logins that were forgotten, 0 when the user had none
+	Failures int32    `json:"failures"`
+	AuditLog AuditLog `json:"audit_log"`
+}
+
+// unlockDetails is what the audit log keeps about an unlock
+type unlockDetails struct {
+	Failures int32 `json:"failures"`
+}
+
+// UnlockUserTx forgets the failed logins of a user, which lifts their lockout, and records it in the audit log.
+// The failed logins tracked by client IP are kept.
+func (store *SQLStore) UnlockUserTx(ctx context.Context, arg UnlockUserTxParams) (UnlockUserTxResult, error) {
+	var result UnlockUserTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		result = UnlockUserTxResult{}
+		_, err := q.GetUser(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		key := GetLoginThrottleParams{
+			Kind: LoginThrottleKindUsername,
+			Key:  arg.Username,
+		}
+		throttle, err := q.GetLoginThrottle(ctx, key)
+		if err != nil && !errors.Is(err, ErrRecordNotFound) {
+			return err
+		}
+		result.Failures = throttle.Failures
+
+		_, err = q.DeleteLoginThrottle(ctx, DeleteLoginThrottleParams(key))
+		if err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(unlockDetails{Failures: result.Failures})
+		if err != nil {
+			return err
+		}
+
+		result.AuditLog, err = q.CreateAuditLog(ctx, CreateAuditLogParams{
+			Action:  AuditActionUserUnlocked,
+			Actor:   arg.UnlockedBy,
+			Subject: arg.Username,
+			Details: data,
+		})
+		return err
+	})
+
+	return result, err
+}
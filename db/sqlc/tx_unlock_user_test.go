@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnlockUserTx(t *testing.T) {
+	user := createRandomUser(t)
+	banker := createRandomUser(t)
+
+	for i := 1; i <= 3; i++ {
+		throttle, err := testStore.RecordLoginFailure(context.Background(), RecordLoginFailureParams{
+			Kind:        LoginThrottleKindUsername,
+			Key:         user.Username,
+			ResetBefore: time.Now().Add(-time.Hour),
+		})
+		require.NoError(t, err)
+		require.Equal(t, int32(i), throttle.Failures)
+	}
+
+	result, err := testStore.UnlockUserTx(context.Background(), UnlockUserTxParams{
+		Username:   user.Username,
+		UnlockedBy: banker.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(3), result.Failures)
+	require.Equal(t, AuditActionUserUnlocked, result.AuditLog.Action)
+	require.Equal(t, banker.Username, result.AuditLog.Actor)
+	require.Equal(t, user.Username, result.AuditLog.Subject)
+
+	_, err = testStore.GetLoginThrottle(context.Background(), GetLoginThrottleParams{
+		Kind: LoginThrottleKindUsername,
+		Key:  user.Username,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestRecordLoginFailureReset(t *testing.T) {
+	user := createRandomUser(t)
+	arg := RecordLoginFailureParams{
+		Kind:        LoginThrottleKindUsername,
+		Key:         user.Username,
+		ResetBefore: time.Now().Add(-time.Hour),
+	}
+
+	_, err := testStore.RecordLoginFailure(context.Background(), arg)
+	require.NoError(t, err)
+
+	// the previous failure is older than ResetBefore, counting starts again
+	arg.ResetBefore = time.Now().Add(time.Hour)
+	throttle, err := testStore.RecordLoginFailure(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), throttle.Failures)
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// UpdateUserTxParams contains the input parameters of the update user transaction
+type UpdateUserTxParams struct {
+	UpdateUserParams
+	// UpdatedBy is the user or the banker who made the change
+	UpdatedBy string `json:"updated_by"`
+}
+
+// UpdateUserTxResult is the result of the update user transaction
+type UpdateUserTxResult struct {
+	User User `json:"user"`
+	// BlockedSessions is the number of sessions that were still usable, 0 unless the password changed
+	BlockedSessions int64    `json:"blocked_sessions"`
+	AuditLog        AuditLog `json:"audit_log"`
+}
+
+// userUpdateDetails is what the audit log keeps about an update, the names of the changed fields but not their values
+type userUpdateDetails struct {
+	Fields          []string `json:"fields"`
+	BlockedSessions int64    `json:"blocked_sessions"`
+}
+
+// UpdateUserTx changes the full name, email or password of a user and records which of them changed in the audit log.
+// A new password blocks every session of the user, as a password reset does.
+func (store *SQLStore) UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (UpdateUserTxResult, error) {
+	var result UpdateUserTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		result = UpdateUserTxResult{}
+		var err error
+		result.User, err = q.UpdateUser(ctx, arg.UpdateUserParams)
+		if err != nil {
+			return err
+		}
+
+		var details userUpdateDetails
+		if arg.FullName.Valid {
+			details.Fields = append(details.Fields, "full_name")
+		}
+		if arg.Email.Valid {
+			details.Fields = append(details.Fields, "email")
+		}
+		if arg.HashedPassword.Valid {
+			details.Fields = append(details.Fields, "password")
+
+			result.BlockedSessions, err = q.BlockUserSessions(ctx, arg.Username)
+			if err != nil {
+				return err
+			}
+			details.BlockedSessions = result.BlockedSessions
+		}
+
+		data, err := json.Marshal(details)
+		if err != nil {
+			return err
+		}
+
+		result.AuditLog, err = q.CreateAuditLog(ctx, CreateAuditLogParams{
+			Action:  AuditActionUserUpdated,
+			Actor:   arg.UpdatedBy,
+			Subject: arg.Username,
+			Details: data,
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateUserTxPassword(t *testing.T) {
+	user := createRandomUser(t)
+	banker := createRandomUser(t)
+
+	_, err := testStore.CreateSession(context.Background(), CreateSessionParams{
+		ID:           uuid.New(),
+		Username:     user.Username,
+		RefreshToken: util.RandomString(32),
+		UserAgent:    "test",
+		ClientIp:     "127.0.0.1",
+	})
+	require.NoError(t, err)
+
+	hashedPassword, err := util.HashPassword(util.RandomString(8))
+	require.NoError(t, err)
+
+	result, err := testStore.UpdateUserTx(context.Background(), UpdateUserTxParams{
+		UpdateUserParams: UpdateUserParams{
+			Username:       user.Username,
+			HashedPassword: pgtype.Text{String: hashedPassword, Valid: true},
+		},
+		UpdatedBy: banker.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, hashedPassword, result.User.HashedPassword)
+	require.Equal(t, int64(1), result.BlockedSessions)
+
+	require.Equal(t, AuditActionUserUpdated, result.AuditLog.Action)
+	require.Equal(t, banker.Username, result.AuditLog.Actor)
+	require.Equal(t, user.Username, result.AuditLog.Subject)
+
+	var details userUpdateDetails
+	err = json.Unmarshal(result.AuditLog.Details, &details)
+	require.NoError(t, err)
+	require.Equal(t, []string{"password"}, details.Fields)
+	require.Equal(t, int64(1), details.BlockedSessions)
+}
+
+func TestUpdateUserTxEmail(t *testing.T) {
+	user := createRandomUser(t)
+
+	_, err := testStore.CreateSession(context.Background(), CreateSessionParams{
+		ID:           uuid.New(),
+		Username:     user.Username,
+		RefreshToken: util.RandomString(32),
+		UserAgent:    "test",
+		ClientIp:     "127.0.0.1",
+	})
+	require.NoError(t, err)
+
+	newEmail := util.RandomEmail()
+	result, err := testStore.UpdateUserTx(context.Background(), UpdateUserTxParams{
+		UpdateUserParams: UpdateUserParams{
+			Username: user.Username,
+			Email:    pgtype.Text{String: newEmail, Valid: true},
+		},
+		UpdatedBy: user.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, newEmail, result.User.Email)
+
+	// the sessions are only blocked by a new password
+	require.Zero(t, result.BlockedSessions)
+	require.Equal(t, user.Username, result.AuditLog.Actor)
+}
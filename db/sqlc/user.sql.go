@@ -7,6 +7,8 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createUser = `-- name: CreateUser :one
@@ -19,7 +21,7 @@ INSERT INTO
     )
 VALUES ($1, $2, $3, $4)
 RETURNING
-    username, hashed_password, full_name, email, password_changed_at, created_at
+    username, hashed_password, full_name, email, password_changed_at, created_at, role, erased_at
 `
 
 type CreateUserParams struct {
@@ -44,12 +46,44 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.Role,
+		&i.ErasedAt,
+	)
+	return i, err
+}
+
+const eraseUser = `-- name: EraseUser :one
+UPDATE users
+SET
+    full_name = 'Erased user',
+    email = username || '@erased.invalid',
+    hashed_password = '',
+    erased_at = now()
+WHERE
+    username = $1
+    AND erased_at IS NULL
+RETURNING
+    username, hashed_password, full_name, email, password_changed_at, created_at, role, erased_at
+`
+
+func (q *Queries) EraseUser(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRow(ctx, eraseUser, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.ErasedAt,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT username, hashed_password, full_name, email, password_changed_at, created_at FROM users WHERE username = $1 LIMIT 1
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, role, erased_at FROM users WHERE username = $1 LIMIT 1
 `
 
 func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
@@ -62,6 +96,171 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.Role,
+		&i.ErasedAt,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, role, erased_at FROM users WHERE email = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.ErasedAt,
+	)
+	return i, err
+}
+
+const isUserErased = `-- name: IsUserErased :one
+SELECT (erased_at IS NOT NULL)::bool AS erased FROM users WHERE username = $1 LIMIT 1
+`
+
+func (q *Queries) IsUserErased(ctx context.Context, username string) (bool, error) {
+	row := q.db.QueryRow(ctx, isUserErased, username)
+	var erased bool
+	err := row.Scan(&erased)
+	return erased, err
+}
+
+const rehashUserPassword = `-- name: RehashUserPassword :exec
+UPDATE users
+SET
+    hashed_password = $1
+WHERE
+    username = $2
+    AND hashed_password = $3
+`
+
+type RehashUserPasswordParams struct {
+	HashedPassword         string `json:"hashed_password"`
+	Username               string `json:"username"`
+	PreviousHashedPassword string `json:"previous_hashed_password"`
+}
+
+func (q *Queries) RehashUserPassword(ctx context.Context, arg RehashUserPasswordParams) error {
+	_, err := q.db.Exec(ctx, rehashUserPassword, arg.HashedPassword, arg.Username, arg.PreviousHashedPassword)
+	return err
+}
+
+const updateUser = `-- name: UpdateUser :one
+UPDATE users
+SET
+    hashed_password = COALESCE(
+        $1,
+        hashed_password
+    ),
+    password_changed_at = CASE
+        WHEN $1::varchar IS NULL THEN password_changed_at
+        ELSE now()
+    END,
+    full_name = COALESCE($2, full_name),
+    email = COALESCE($3, email)
+WHERE
+    username = $4
+    AND erased_at IS NULL
+RETURNING
+    username, hashed_password, full_name, email, password_changed_at, created_at, role, erased_at
+`
+
+type UpdateUserParams struct {
+	HashedPassword pgtype.Text `json:"hashed_password"`
+	FullName       pgtype.Text `json:"full_name"`
+	Email          pgtype.Text `json:"email"`
+	Username       string      `json:"username"`
+}
+
+func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUser,
+		arg.HashedPassword,
+		arg.FullName,
+		arg.Email,
+		arg.Username,
+	)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.ErasedAt,
+	)
+	return i, err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :one
+UPDATE users
+SET
+    hashed_password = $1,
+    password_changed_at = now()
+WHERE
+    username = $2
+RETURNING
+    username, hashed_password, full_name, email, password_changed_at, created_at, role, erased_at
+`
+
+type UpdateUserPasswordParams struct {
+	HashedPassword string `json:"hashed_password"`
+	Username       string `json:"username"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserPassword, arg.HashedPassword, arg.Username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.ErasedAt,
+	)
+	return i, err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE users
+SET
+    role = $1
+WHERE
+    username = $2
+    AND erased_at IS NULL
+RETURNING
+    username, hashed_password, full_name, email, password_changed_at, created_at, role, erased_at
+`
+
+type UpdateUserRoleParams struct {
+	Role     string `json:"role"`
+	Username string `json:"username"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserRole, arg.Role, arg.Username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.ErasedAt,
 	)
 	return i, err
 }
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/require"
 )
 
@@ -49,3 +50,46 @@ func TestGetUser(t *testing.T) {
 	require.Equal(t, user.Email, user2.Email)
 	require.WithinDuration(t, user.CreatedAt, user2.CreatedAt, time.Second)
 }
+
+func TestUpdateUserOnlyFullName(t *testing.T) {
+	oldUser := createRandomUser(t)
+
+	newFullName := util.RandomOwner()
+	updatedUser, err := testStore.UpdateUser(context.Background(), UpdateUserParams{
+		Username: oldUser.Username,
+		FullName: pgtype.Text{String: newFullName, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, newFullName, updatedUser.FullName)
+	require.Equal(t, oldUser.Email, updatedUser.Email)
+	require.Equal(t, oldUser.HashedPassword, updatedUser.HashedPassword)
+	require.WithinDuration(t, oldUser.PasswordChangedAt, updatedUser.PasswordChangedAt, time.Second)
+}
+
+func TestUpdateUserPasswordChangedAt(t *testing.T) {
+	oldUser := createRandomUser(t)
+
+	newHashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	updatedUser, err := testStore.UpdateUser(context.Background(), UpdateUserParams{
+		Username:       oldUser.Username,
+		HashedPassword: pgtype.Text{String: newHashedPassword, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, newHashedPassword, updatedUser.HashedPassword)
+	require.Equal(t, oldUser.FullName, updatedUser.FullName)
+	require.True(t, updatedUser.PasswordChangedAt.After(oldUser.PasswordChangedAt))
+}
+
+func TestUpdateUserErased(t *testing.T) {
+	user := createRandomUser(t)
+	_, err := testStore.EraseUser(context.Background(), user.Username)
+	require.NoError(t, err)
+
+	_, err = testStore.UpdateUser(context.Background(), UpdateUserParams{
+		Username: user.Username,
+		FullName: pgtype.Text{String: util.RandomOwner(), Valid: true},
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
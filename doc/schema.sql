@@ -10,7 +10,8 @@ CREATE TABLE "users" (
   "email" varchar UNIQUE NOT NULL,
   "is_email_verified" bool NOT NULL DEFAULT false,
   "password_changed_at" timestamptz NOT NULL DEFAULT '0001-01-01',
-  "created_at" timestamptz NOT NULL DEFAULT (now())
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "erased_at" timestamptz
 );
 
 CREATE TABLE "verify_emails" (
@@ -28,14 +29,17 @@ CREATE TABLE "accounts" (
   "owner" varchar NOT NULL,
   "balance" bigint NOT NULL,
   "currency" varchar NOT NULL,
-  "created_at" timestamptz NOT NULL DEFAULT (now())
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "product" varchar NOT NULL DEFAULT 'checking',
+  "closed_at" timestamptz
 );
 
 CREATE TABLE "entries" (
   "id" bigserial PRIMARY KEY,
   "account_id" bigint NOT NULL,
   "amount" bigint NOT NULL,
-  "created_at" timestamptz NOT NULL DEFAULT (now())
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "external_ref" varchar
 );
 
 CREATE TABLE "transfers" (
@@ -43,7 +47,8 @@ CREATE TABLE "transfers" (
   "from_account_id" bigint NOT NULL,
   "to_account_id" bigint NOT NULL,
   "amount" bigint NOT NULL,
-  "created_at" timestamptz NOT NULL DEFAULT (now())
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "fee" bigint NOT NULL DEFAULT 0
 );
 
 CREATE TABLE "sessions" (
@@ -57,22 +62,320 @@ CREATE TABLE "sessions" (
   "created_at" timestamptz NOT NULL DEFAULT (now())
 );
 
+CREATE TABLE "transfer_limits" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar,
+  "account_id" bigint,
+  "currency" varchar,
+  "kind" varchar NOT NULL,
+  "max_value" bigint NOT NULL,
+  "period" interval,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "transfer_reviews" (
+  "id" bigserial PRIMARY KEY,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "requested_by" varchar NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending_review',
+  "reasons" text[] NOT NULL,
+  "reviewed_by" varchar,
+  "reviewed_at" timestamptz,
+  "transfer_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "account_signatories" (
+  "account_id" bigint NOT NULL,
+  "username" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "username")
+);
+
+CREATE TABLE "approval_policies" (
+  "account_id" bigint PRIMARY KEY,
+  "threshold" bigint NOT NULL,
+  "required_approvals" integer NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "transfer_requests" (
+  "id" bigserial PRIMARY KEY,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "requested_by" varchar NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "required_approvals" integer NOT NULL,
+  "transfer_id" bigint,
+  "expires_at" timestamptz NOT NULL,
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "transfer_approvals" (
+  "transfer_request_id" bigint NOT NULL,
+  "username" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("transfer_request_id", "username")
+);
+
+CREATE TABLE "account_members" (
+  "account_id" bigint NOT NULL,
+  "username" varchar NOT NULL,
+  "permission" varchar NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'invited',
+  "invited_by" varchar NOT NULL,
+  "accepted_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "username")
+);
+
+CREATE TABLE "account_products" (
+  "code" varchar PRIMARY KEY,
+  "name" varchar NOT NULL,
+  "annual_rate_bps" bigint NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "system_accounts" (
+  "purpose" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "account_id" bigint UNIQUE NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("purpose", "currency")
+);
+
+CREATE TABLE "interest_postings" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "period" date NOT NULL,
+  "accrued_micros" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "carry_micros" bigint NOT NULL,
+  "entry_id" bigint,
+  "expense_entry_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "interest_accruals" (
+  "account_id" bigint NOT NULL,
+  "accrual_date" date NOT NULL,
+  "balance" bigint NOT NULL,
+  "annual_rate_bps" bigint NOT NULL,
+  "amount_micros" bigint NOT NULL,
+  "posting_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "accrual_date")
+);
+
+CREATE TABLE "fee_schedules" (
+  "currency" varchar PRIMARY KEY,
+  "kind" varchar NOT NULL,
+  "flat_fee" bigint NOT NULL DEFAULT 0,
+  "rate_bps" bigint NOT NULL DEFAULT 0,
+  "min_fee" bigint NOT NULL DEFAULT 0,
+  "max_fee" bigint,
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "fee_tiers" (
+  "currency" varchar NOT NULL,
+  "min_amount" bigint NOT NULL,
+  "flat_fee" bigint NOT NULL DEFAULT 0,
+  "rate_bps" bigint NOT NULL DEFAULT 0,
+  PRIMARY KEY ("currency", "min_amount")
+);
+
+CREATE TABLE "transfer_reversals" (
+  "id" bigserial PRIMARY KEY,
+  "transfer_id" bigint NOT NULL,
+  "reversal_transfer_id" bigint UNIQUE NOT NULL,
+  "amount" bigint NOT NULL,
+  "reason" varchar NOT NULL DEFAULT '',
+  "reversed_by" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "audit_logs" (
+  "id" bigserial PRIMARY KEY,
+  "action" varchar NOT NULL,
+  "actor" varchar NOT NULL,
+  "subject" varchar NOT NULL,
+  "details" jsonb NOT NULL DEFAULT '{}',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "password_resets" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "token_hash" varchar UNIQUE NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "used_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "totp_secrets" (
+  "username" varchar PRIMARY KEY,
+  "secret" varchar NOT NULL,
+  "enabled_at" timestamptz,
+  "last_used_step" bigint NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "recovery_codes" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "code_hash" varchar NOT NULL,
+  "used_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "login_challenges" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "token_hash" varchar UNIQUE NOT NULL,
+  "attempts" int NOT NULL DEFAULT 0,
+  "expires_at" timestamptz NOT NULL,
+  "used_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "login_throttles" (
+  "kind" varchar NOT NULL,
+  "key" varchar NOT NULL,
+  "failures" int NOT NULL DEFAULT 0,
+  "last_failed_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("kind", "key")
+);
+
 CREATE INDEX ON "accounts" ("owner");
 
-CREATE UNIQUE INDEX ON "accounts" ("owner", "currency");
+CREATE UNIQUE INDEX ON "accounts" ("owner", "currency", "product");
 
 CREATE INDEX ON "entries" ("account_id");
 
+CREATE UNIQUE INDEX ON "entries" ("account_id", "external_ref");
+
 CREATE INDEX ON "transfers" ("from_account_id");
 
 CREATE INDEX ON "transfers" ("to_account_id");
 
 CREATE INDEX ON "transfers" ("from_account_id", "to_account_id");
 
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
+
+CREATE INDEX ON "transfer_limits" ("username");
+
+CREATE INDEX ON "transfer_limits" ("account_id");
+
+CREATE INDEX ON "transfer_reviews" ("status", "id");
+
+CREATE INDEX ON "account_signatories" ("username");
+
+CREATE INDEX ON "transfer_requests" ("from_account_id", "status");
+
+CREATE INDEX ON "account_members" ("username", "status");
+
+CREATE UNIQUE INDEX ON "interest_postings" ("account_id", "period");
+
+CREATE INDEX ON "transfer_reversals" ("transfer_id");
+
+CREATE INDEX ON "audit_logs" ("subject", "id");
+
+CREATE INDEX ON "password_resets" ("username");
+
+CREATE UNIQUE INDEX ON "recovery_codes" ("username", "code_hash");
+
+CREATE INDEX ON "login_challenges" ("username");
+
 COMMENT ON COLUMN "entries"."amount" IS 'can be negative or positive';
 
+COMMENT ON COLUMN "entries"."external_ref" IS 'reference of the transaction in the file it was imported from, NULL for entries created by the bank';
+
 COMMENT ON COLUMN "transfers"."amount" IS 'must be positive';
 
+COMMENT ON COLUMN "transfers"."fee" IS 'charged to the sender on top of the amount';
+
+COMMENT ON COLUMN "transfer_limits"."username" IS 'limit every account of this user, NULL for all users';
+
+COMMENT ON COLUMN "transfer_limits"."account_id" IS 'limit this account only, NULL for all accounts';
+
+COMMENT ON COLUMN "transfer_limits"."currency" IS 'NULL only for count limits, which apply to every currency';
+
+COMMENT ON COLUMN "transfer_limits"."kind" IS 'amount: total amount sent, count: number of transfers';
+
+COMMENT ON COLUMN "transfer_limits"."period" IS 'rolling window the limit is evaluated on, NULL for a per-transaction maximum';
+
+COMMENT ON COLUMN "transfer_reviews"."reasons" IS 'why the risk screening held the transfer';
+
+COMMENT ON COLUMN "transfer_reviews"."transfer_id" IS 'transfer executed once the review is approved';
+
+COMMENT ON COLUMN "account_signatories"."username" IS 'user allowed to approve transfers requested on the account';
+
+COMMENT ON COLUMN "approval_policies"."threshold" IS 'transfers above this amount need approvals';
+
+COMMENT ON COLUMN "transfer_requests"."required_approvals" IS 'copied from the approval policy when the transfer is requested';
+
+COMMENT ON COLUMN "transfer_requests"."transfer_id" IS 'transfer executed once enough approvals are reached';
+
+COMMENT ON COLUMN "account_members"."permission" IS 'view: read the account, transact: also send money, manage: also manage members and settings';
+
+COMMENT ON COLUMN "account_members"."status" IS 'invited until the user accepts the invitation';
+
+COMMENT ON COLUMN "account_products"."annual_rate_bps" IS 'annual interest rate in basis points, 150 is 1.50%';
+
+COMMENT ON COLUMN "interest_postings"."period" IS 'first day of the month the interest was accrued in';
+
+COMMENT ON COLUMN "interest_postings"."accrued_micros" IS 'accruals of the period plus the carry of the previous posting, in millionths of a minor unit';
+
+COMMENT ON COLUMN "interest_postings"."carry_micros" IS 'fraction of a minor unit left over, carried to the next posting';
+
+COMMENT ON COLUMN "interest_accruals"."amount_micros" IS 'interest accrued for the day, in millionths of a minor unit';
+
+COMMENT ON COLUMN "fee_schedules"."kind" IS 'flat: flat_fee, percentage: flat_fee plus rate_bps of the amount, tiered: the fee of the tier the amount falls in';
+
+COMMENT ON COLUMN "fee_schedules"."max_fee" IS 'NULL for no maximum';
+
+COMMENT ON COLUMN "fee_tiers"."min_amount" IS 'the tier applies to amounts from min_amount up to the min_amount of the next tier';
+
+COMMENT ON COLUMN "transfer_reversals"."transfer_id" IS 'transfer being refunded';
+
+COMMENT ON COLUMN "transfer_reversals"."reversal_transfer_id" IS 'compensating transfer from the receiver back to the sender';
+
+COMMENT ON COLUMN "transfer_reversals"."amount" IS 'must be positive';
+
+COMMENT ON COLUMN "users"."erased_at" IS 'set when the personal data of the user is erased, the username is kept for the ledger';
+
+COMMENT ON COLUMN "accounts"."closed_at" IS 'NULL while the account is open, closed accounts keep their ledger but can''t send or receive money';
+
+COMMENT ON COLUMN "audit_logs"."actor" IS 'user who performed the action, not a foreign key so that the trail outlives any user';
+
+COMMENT ON COLUMN "audit_logs"."subject" IS 'what the action was performed on, such as the username of an erased user';
+
+COMMENT ON COLUMN "password_resets"."token_hash" IS 'SHA-256 of the token sent to the user, the token itself is never stored';
+
+COMMENT ON COLUMN "password_resets"."used_at" IS 'set once the token resets the password, or when another token of the user does';
+
+COMMENT ON COLUMN "totp_secrets"."enabled_at" IS 'NULL until the user confirms the enrollment with a first code';
+
+COMMENT ON COLUMN "totp_secrets"."last_used_step" IS 'time step of the last accepted code, older steps are refused so that codes can''t be replayed';
+
+COMMENT ON COLUMN "recovery_codes"."code_hash" IS 'SHA-256 of the recovery code, each code can be used once instead of a TOTP code';
+
+COMMENT ON COLUMN "login_challenges"."token_hash" IS 'SHA-256 of the challenge token returned once the password is checked, the token is never stored';
+
+COMMENT ON COLUMN "login_challenges"."attempts" IS 'number of codes tried, the challenge is refused after too many';
+
+COMMENT ON COLUMN "login_throttles"."kind" IS 'username or ip';
+
+COMMENT ON COLUMN "login_throttles"."key" IS 'the username or the client IP, usernames that don''t exist are tracked too';
+
+COMMENT ON COLUMN "login_throttles"."failures" IS 'failed logins in a row, counting starts again once the lockout duration passed without failure';
+
 ALTER TABLE "verify_emails" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
 
 ALTER TABLE "accounts" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
@@ -84,3 +387,71 @@ ALTER TABLE "transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts
 ALTER TABLE "transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
 
 ALTER TABLE "sessions" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_limits" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_limits" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_reviews" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_reviews" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_reviews" ADD FOREIGN KEY ("requested_by") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_reviews" ADD FOREIGN KEY ("reviewed_by") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_reviews" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "account_signatories" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "account_signatories" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "approval_policies" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_requests" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_requests" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_requests" ADD FOREIGN KEY ("requested_by") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_requests" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "transfer_approvals" ADD FOREIGN KEY ("transfer_request_id") REFERENCES "transfer_requests" ("id");
+
+ALTER TABLE "transfer_approvals" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("invited_by") REFERENCES "users" ("username");
+
+ALTER TABLE "accounts" ADD FOREIGN KEY ("product") REFERENCES "account_products" ("code");
+
+ALTER TABLE "system_accounts" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "interest_postings" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "interest_postings" ADD FOREIGN KEY ("entry_id") REFERENCES "entries" ("id");
+
+ALTER TABLE "interest_postings" ADD FOREIGN KEY ("expense_entry_id") REFERENCES "entries" ("id");
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("posting_id") REFERENCES "interest_postings" ("id");
+
+ALTER TABLE "fee_tiers" ADD FOREIGN KEY ("currency") REFERENCES "fee_schedules" ("currency");
+
+ALTER TABLE "transfer_reversals" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "transfer_reversals" ADD FOREIGN KEY ("reversal_transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "transfer_reversals" ADD FOREIGN KEY ("reversed_by") REFERENCES "users" ("username");
+
+ALTER TABLE "password_resets" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "totp_secrets" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "recovery_codes" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "login_challenges" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
@@ -0,0 +1,30 @@
+package event
+
+import "time"
+
+// AccountEventsChannel is the Postgres NOTIFY channel account events are published on.
+const AccountEventsChannel = "account_events"
+
+const (
+	TypeEntry   = "entry"
+	TypeBalance = "balance"
+)
+
+// AccountEvent describes a change on an account: a new entry or a new balance.
+type AccountEvent struct {
+	Type      string    `json:"type"`
+	AccountID int64     `json:"account_id"`
+	EntryID   int64     `json:"entry_id,omitempty"`
+	Amount    int64     `json:"amount,omitempty"`
+	Balance   int64     `json:"balance,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Broker fans account events out to the subscribers of each account.
+type Broker interface {
+	// Subscribe returns a channel receiving the events of an account and a function to unsubscribe
+	Subscribe(accountID int64) (<-chan *AccountEvent, func())
+
+	// Publish sends an event to every subscriber of its account
+	Publish(event *AccountEvent)
+}
@@ -0,0 +1,64 @@
+package event
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// subscriberBufferSize is the number of events a slow subscriber can lag behind before events are dropped
+const subscriberBufferSize = 32
+
+// MemoryBroker is an in-process Broker
+type MemoryBroker struct {
+	mutex       sync.RWMutex
+	subscribers map[int64]map[chan *AccountEvent]struct{}
+}
+
+// NewMemoryBroker creates a new MemoryBroker
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		subscribers: make(map[int64]map[chan *AccountEvent]struct{}),
+	}
+}
+
+// Subscribe implements Broker.
+func (broker *MemoryBroker) Subscribe(accountID int64) (<-chan *AccountEvent, func()) {
+	events := make(chan *AccountEvent, subscriberBufferSize)
+
+	broker.mutex.Lock()
+	if broker.subscribers[accountID] == nil {
+		broker.subscribers[accountID] = make(map[chan *AccountEvent]struct{})
+	}
+	broker.subscribers[accountID][events] = struct{}{}
+	broker.mutex.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			broker.mutex.Lock()
+			defer broker.mutex.Unlock()
+
+			delete(broker.subscribers[accountID], events)
+			if len(broker.subscribers[accountID]) == 0 {
+				delete(broker.subscribers, accountID)
+			}
+			close(events)
+		})
+	}
+
+	return events, cancel
+}
+
+// Publish implements Broker.
+func (broker *MemoryBroker) Publish(event *AccountEvent) {
+	broker.mutex.RLock()
+	defer broker.mutex.RUnlock()
+
+	for events := range broker.subscribers[event.AccountID] {
+		select {
+		case events <- event:
+		default:
+			slog.Warn("drop account event: subscriber is too slow", slog.String("type", event.Type), slog.Int64("account_id", event.AccountID))
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package event
+
+import (
+	"testing"
+
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryBroker(t *testing.T) {
+	broker := NewMemoryBroker()
+	accountID := util.RandomInt(1, 1000)
+
+	events1, cancel1 := broker.Subscribe(accountID)
+	events2, cancel2 := broker.Subscribe(accountID)
+	others, cancelOthers := broker.Subscribe(accountID + 1)
+	defer cancelOthers()
+
+	accountEvent := &AccountEvent{
+		Type:      TypeEntry,
+		AccountID: accountID,
+		EntryID:   util.RandomInt(1, 1000),
+		Amount:    util.RandomMoney(),
+	}
+	broker.Publish(accountEvent)
+
+	require.Equal(t, accountEvent, <-events1)
+	require.Equal(t, accountEvent, <-events2)
+	require.Empty(t, others)
+
+	cancel1()
+	_, ok := <-events1
+	require.False(t, ok)
+
+	// a second cancel must not panic on the closed channel
+	cancel1()
+
+	broker.Publish(accountEvent)
+	require.Equal(t, accountEvent, <-events2)
+
+	cancel2()
+	require.Empty(t, broker.subscribers[accountID])
+}
+
+func TestMemoryBrokerSlowSubscriber(t *testing.T) {
+	broker := NewMemoryBroker()
+	accountID := util.RandomInt(1, 1000)
+
+	events, cancel := broker.Subscribe(accountID)
+	defer cancel()
+
+	for i := 0; i < subscriberBufferSize+10; i++ {
+		broker.Publish(&AccountEvent{Type: TypeBalance, AccountID: accountID, Balance: int64(i)})
+	}
+
+	require.Len(t, events, subscriberBufferSize)
+}
@@ -0,0 +1,73 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// listenRetryDelay is the time to wait before listening again after the connection is lost
+const listenRetryDelay = 5 * time.Second
+
+// PGListener is a Broker fed by the Postgres notifications sent on AccountEventsChannel
+type PGListener struct {
+	*MemoryBroker
+	connPool *pgxpool.Pool
+}
+
+// NewPGListener creates a new PGListener
+func NewPGListener(connPool *pgxpool.Pool) *PGListener {
+	return &PGListener{
+		MemoryBroker: NewMemoryBroker(),
+		connPool:     connPool,
+	}
+}
+
+// Listen forwards the notifications to the subscribers until the context is done.
+// It listens again on a new connection whenever the current one fails.
+func (listener *PGListener) Listen(ctx context.Context) error {
+	for {
+		err := listener.listen(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		slog.WarnContext(ctx, "account events listener stopped, retrying", slog.Any("error", err), slog.Duration("retry_delay", listenRetryDelay))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(listenRetryDelay):
+		}
+	}
+}
+
+func (listener *PGListener) listen(ctx context.Context) error {
+	conn, err := listener.connPool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, "LISTEN "+AccountEventsChannel)
+	if err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		event := &AccountEvent{}
+		if err := json.Unmarshal([]byte(notification.Payload), event); err != nil {
+			slog.ErrorContext(ctx, "cannot decode account event", slog.String("payload", notification.Payload), slog.Any("error", err))
+			continue
+		}
+
+		listener.Publish(event)
+	}
+}
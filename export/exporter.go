@@ -0,0 +1,363 @@
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// pageSize is how many rows are loaded at once, so that long histories are streamed instead of held in memory
+const pageSize = 500
+
+// User is the profile of the user, without the password hash
+type User struct {
+	Username          string    `json:"username"`
+	FullName          string    `json:"full_name"`
+	Email             string    `json:"email"`
+	Role              string    `json:"role"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// Session is a login of the user, without the refresh token
+type Session struct {
+	ID        uuid.UUID `json:"id"`
+	UserAgent string    `json:"user_agent"`
+	ClientIP  string    `json:"client_ip"`
+	IsBlocked bool      `json:"is_blocked"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AuditLog is an action performed by or on the user
+type AuditLog struct {
+	ID        int64           `json:"id"`
+	Action    string          `json:"action"`
+	Actor     string          `json:"actor"`
+	Subject   string          `json:"subject"`
+	Details   json.RawMessage `json:"details"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// MFA is the two-factor authentication enrollment of the user, without the secret
+type MFA struct {
+	Enrolled bool `json:"enrolled"`
+	// invalid until the user confirms the enrollment
+	EnabledAt     pgtype.Timestamptz `json:"enabled_at"`
+	CreatedAt     time.Time          `json:"created_at"`
+	RecoveryCodes []RecoveryCode     `json:"recovery_codes"`
+}
+
+// RecoveryCode is a recovery code of the user, without its hash
+type RecoveryCode struct {
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+// PasswordReset is a password reset requested for the user, without the token hash
+type PasswordReset struct {
+	ID        int64              `json:"id"`
+	ExpiresAt time.Time          `json:"expires_at"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+// Exporter bundles everything the bank holds about a user
+type Exporter struct {
+	store db.Store
+}
+
+// NewExporter creates a new Exporter
+func NewExporter(store db.Store) *Exporter {
+	return &Exporter{
+		store: store,
+	}
+}
+
+// WriteZip writes a ZIP archive with one JSON file per kind of data: the profile of the user, their sessions,
+// the accounts they own and their memberships and signatory rights on other accounts, the entries and transfers
+// of their accounts, the transfer requests, approvals and reviews they take part in, the audit logs about them
+// or their actions, their two-factor authentication enrollment and their password resets.
+// All the rows are read from one read-only snapshot, so that the files agree with each other, and are read and
+// written page by page. Secrets such as hashes and tokens are left out. When an error occurs the archive is left incomplete.
+func (exporter *Exporter) WriteZip(ctx context.Context, username string, w io.Writer) error {
+	return exporter.store.ReadOnlyTx(ctx, func(q db.Querier) error {
+		return writeZip(ctx, q, username, w)
+	})
+}
+
+func writeZip(ctx context.Context, q db.Querier, username string, w io.Writer) error {
+	user, err := q.GetUser(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	archive := zip.NewWriter(w)
+
+	err = writeFile(archive, "user.json", func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(User{
+			Username:          user.Username,
+			FullName:          user.FullName,
+			Email:             user.Email,
+			Role:              user.Role,
+			PasswordChangedAt: user.PasswordChangedAt,
+			CreatedAt:         user.CreatedAt,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeFile(archive, "sessions.json", func(w io.Writer) error {
+		return writeArray(w, uuid.Nil, func(after uuid.UUID) ([]db.Session, error) {
+			return q.ExportSessions(ctx, db.ExportSessionsParams{Username: username, AfterID: after, Limit: pageSize})
+		}, func(session db.Session) (uuid.UUID, any) {
+			return session.ID, Session{
+				ID:        session.ID,
+				UserAgent: session.UserAgent,
+				ClientIP:  session.ClientIp,
+				IsBlocked: session.IsBlocked,
+				ExpiresAt: session.ExpiresAt,
+				CreatedAt: session.CreatedAt,
+			}
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeFile(archive, "accounts.json", func(w io.Writer) error {
+		return writeArray(w, 0, func(after int64) ([]db.Account, error) {
+			return q.ExportAccounts(ctx, db.ExportAccountsParams{Owner: username, AfterID: after, Limit: pageSize})
+		}, func(account db.Account) (int64, any) {
+			return account.ID, account
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeFile(archive, "memberships.json", func(w io.Writer) error {
+		return writeArray(w, 0, func(after int64) ([]db.AccountMember, error) {
+			return q.ExportAccountMembers(ctx, db.ExportAccountMembersParams{Username: username, AfterID: after, Limit: pageSize})
+		}, func(member db.AccountMember) (int64, any) {
+			return member.AccountID, member
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeFile(archive, "signatories.json", func(w io.Writer) error {
+		return writeArray(w, 0, func(after int64) ([]db.AccountSignatory, error) {
+			return q.ExportAccountSignatories(ctx, db.ExportAccountSignatoriesParams{Username: username, AfterID: after, Limit: pageSize})
+		}, func(signatory db.AccountSignatory) (int64, any) {
+			return signatory.AccountID, signatory
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeFile(archive, "entries.json", func(w io.Writer) error {
+		return writeArray(w, 0, func(after int64) ([]db.Entry, error) {
+			return q.ExportEntries(ctx, db.ExportEntriesParams{Owner: username, AfterID: after, Limit: pageSize})
+		}, func(entry db.Entry) (int64, any) {
+			return entry.ID, entry
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeFile(archive, "transfers.json", func(w io.Writer) error {
+		return writeArray(w, 0, func(after int64) ([]db.Transfer, error) {
+			return q.ExportTransfers(ctx, db.ExportTransfersParams{Owner: username, AfterID: after, Limit: pageSize})
+		}, func(transfer db.Transfer) (int64, any) {
+			return transfer.ID, transfer
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeFile(archive, "transfer_requests.json", func(w io.Writer) error {
+		return writeArray(w, 0, func(after int64) ([]db.TransferRequest, error) {
+			return q.ExportTransferRequests(ctx, db.ExportTransferRequestsParams{Username: username, AfterID: after, Limit: pageSize})
+		}, func(request db.TransferRequest) (int64, any) {
+			return request.ID, request
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeFile(archive, "transfer_approvals.json", func(w io.Writer) error {
+		return writeArray(w, 0, func(after int64) ([]db.TransferApproval, error) {
+			return q.ExportTransferApprovals(ctx, db.ExportTransferApprovalsParams{Username: username, AfterID: after, Limit: pageSize})
+		}, func(approval db.TransferApproval) (int64, any) {
+			return approval.TransferRequestID, approval
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeFile(archive, "transfer_reviews.json", func(w io.Writer) error {
+		return writeArray(w, 0, func(after int64) ([]db.TransferReview, error) {
+			return q.ExportTransferReviews(ctx, db.ExportTransferReviewsParams{Username: username, AfterID: after, Limit: pageSize})
+		}, func(review db.TransferReview) (int64, any) {
+			return review.ID, review
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeFile(archive, "audit_logs.json", func(w io.Writer) error {
+		return writeArray(w, 0, func(after int64) ([]db.AuditLog, error) {
+			return q.ExportAuditLogs(ctx, db.ExportAuditLogsParams{Username: username, AfterID: after, Limit: pageSize})
+		}, func(auditLog db.AuditLog) (int64, any) {
+			return auditLog.ID, AuditLog{
+				ID:        auditLog.ID,
+				Action:    auditLog.Action,
+				Actor:     auditLog.Actor,
+				Subject:   auditLog.Subject,
+				Details:   auditLog.Details,
+				CreatedAt: auditLog.CreatedAt,
+			}
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeFile(archive, "mfa.json", func(w io.Writer) error {
+		mfa, err := exportMFA(ctx, q, username)
+		if err != nil {
+			return err
+		}
+		return json.NewEncoder(w).Encode(mfa)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeFile(archive, "password_resets.json", func(w io.Writer) error {
+		return writeArray(w, 0, func(after int64) ([]db.PasswordReset, error) {
+			return q.ExportPasswordResets(ctx, db.ExportPasswordResetsParams{Username: username, AfterID: after, Limit: pageSize})
+		}, func(reset db.PasswordReset) (int64, any) {
+			return reset.ID, PasswordReset{
+				ID:        reset.ID,
+				ExpiresAt: reset.ExpiresAt,
+				UsedAt:    reset.UsedAt,
+				CreatedAt: reset.CreatedAt,
+			}
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+// exportMFA reads the two-factor authentication enrollment of the user, which is empty when they never enrolled
+func exportMFA(ctx context.Context, q db.Querier, username string) (MFA, error) {
+	secret, err := q.GetTotpSecret(ctx, username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return MFA{RecoveryCodes: []RecoveryCode{}}, nil
+		}
+		return MFA{}, err
+	}
+
+	codes, err := q.ExportRecoveryCodes(ctx, username)
+	if err != nil {
+		return MFA{}, err
+	}
+
+	mfa := MFA{
+		Enrolled:      true,
+		EnabledAt:     secret.EnabledAt,
+		CreatedAt:     secret.CreatedAt,
+		RecoveryCodes: make([]RecoveryCode, len(codes)),
+	}
+	for i, code := range codes {
+		mfa.RecoveryCodes[i] = RecoveryCode{
+			UsedAt:    code.UsedAt,
+			CreatedAt: code.CreatedAt,
+		}
+	}
+	return mfa, nil
+}
+
+func writeFile(archive *zip.Writer, name string, write func(w io.Writer) error) error {
+	w, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return write(w)
+}
+
+// writeArray writes the rows as a JSON array, fetching the pages that follow the key of the last row until one is short.
+// convert returns the key of a row and what to write for it.
+func writeArray[Row any, Key any](w io.Writer, after Key, fetch func(after Key) ([]Row, error), convert func(row Row) (Key, any)) error {
+	_, err := io.WriteString(w, "[")
+	if err != nil {
+		return err
+	}
+
+	first := true
+	for {
+		rows, err := fetch(after)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			var value any
+			after, value = convert(row)
+
+			data, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+
+			separator := ",\n"
+			if first {
+				separator = "\n"
+				first = false
+			}
+
+			_, err = io.WriteString(w, separator)
+			if err != nil {
+				return err
+			}
+
+			_, err = w.Write(data)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(rows) < pageSize {
+			break
+		}
+	}
+
+	_, err = io.WriteString(w, "\n]\n")
+	return err
+}
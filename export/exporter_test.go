@@ -0,0 +1,192 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	mockdb "bitbucket.org/jessyw/go_simplebank/db/mock"
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteZip(t *testing.T) {
+	user := db.User{
+		Username:       util.RandomOwner(),
+		HashedPassword: "secret-hash",
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+		Role:           util.DepositorRole,
+	}
+	session := db.Session{ID: uuid.New(), Username: user.Username, RefreshToken: "secret-token", UserAgent: "curl"}
+	account := db.Account{ID: 1, Owner: user.Username, Balance: 100, Currency: util.USD}
+	member := db.AccountMember{AccountID: 2, Username: user.Username, Permission: "view", Status: "active", InvitedBy: util.RandomOwner()}
+	auditLog := db.AuditLog{ID: 1, Action: db.AuditActionPasswordReset, Actor: user.Username, Subject: user.Username, Details: []byte(`{"ip":"1.2.3.4"}`)}
+	secret := db.TotpSecret{Username: user.Username, Secret: "secret-totp", EnabledAt: pgtype.Timestamptz{Time: time.Now(), Valid: true}}
+	recoveryCode := db.RecoveryCode{ID: 1, Username: user.Username, CodeHash: "secret-code-hash"}
+	reset := db.PasswordReset{ID: 1, Username: user.Username, TokenHash: "secret-reset-hash", ExpiresAt: time.Now()}
+
+	// a full page makes the exporter fetch the next one
+	entries := make([]db.Entry, pageSize)
+	for i := range entries {
+		entries[i] = db.Entry{ID: int64(i + 1), AccountID: account.ID, Amount: 1}
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	expectReadOnlyTx(store)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+	store.EXPECT().
+		ExportSessions(gomock.Any(), gomock.Eq(db.ExportSessionsParams{Username: user.Username, AfterID: uuid.Nil, Limit: pageSize})).
+		Times(1).
+		Return([]db.Session{session}, nil)
+	store.EXPECT().
+		ExportAccounts(gomock.Any(), gomock.Eq(db.ExportAccountsParams{Owner: user.Username, AfterID: 0, Limit: pageSize})).
+		Times(1).
+		Return([]db.Account{account}, nil)
+	store.EXPECT().
+		ExportEntries(gomock.Any(), gomock.Eq(db.ExportEntriesParams{Owner: user.Username, AfterID: 0, Limit: pageSize})).
+		Times(1).
+		Return(entries, nil)
+	store.EXPECT().
+		ExportEntries(gomock.Any(), gomock.Eq(db.ExportEntriesParams{Owner: user.Username, AfterID: pageSize, Limit: pageSize})).
+		Times(1).
+		Return([]db.Entry{{ID: pageSize + 1, AccountID: account.ID, Amount: 2}}, nil)
+	store.EXPECT().
+		ExportTransfers(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.Transfer{}, nil)
+	store.EXPECT().
+		ExportAccountMembers(gomock.Any(), gomock.Eq(db.ExportAccountMembersParams{Username: user.Username, AfterID: 0, Limit: pageSize})).
+		Times(1).
+		Return([]db.AccountMember{member}, nil)
+	store.EXPECT().ExportAccountSignatories(gomock.Any(), gomock.Any()).Times(1).Return([]db.AccountSignatory{}, nil)
+	store.EXPECT().ExportTransferRequests(gomock.Any(), gomock.Any()).Times(1).Return([]db.TransferRequest{}, nil)
+	store.EXPECT().ExportTransferApprovals(gomock.Any(), gomock.Any()).Times(1).Return([]db.TransferApproval{}, nil)
+	store.EXPECT().ExportTransferReviews(gomock.Any(), gomock.Any()).Times(1).Return([]db.TransferReview{}, nil)
+	store.EXPECT().
+		ExportAuditLogs(gomock.Any(), gomock.Eq(db.ExportAuditLogsParams{Username: user.Username, AfterID: 0, Limit: pageSize})).
+		Times(1).
+		Return([]db.AuditLog{auditLog}, nil)
+	store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(secret, nil)
+	store.EXPECT().ExportRecoveryCodes(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return([]db.RecoveryCode{recoveryCode}, nil)
+	store.EXPECT().
+		ExportPasswordResets(gomock.Any(), gomock.Eq(db.ExportPasswordResetsParams{Username: user.Username, AfterID: 0, Limit: pageSize})).
+		Times(1).
+		Return([]db.PasswordReset{reset}, nil)
+
+	var buf bytes.Buffer
+	err := NewExporter(store).WriteZip(context.Background(), user.Username, &buf)
+	require.NoError(t, err)
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	files := map[string][]byte{}
+	for _, file := range archive.File {
+		r, err := file.Open()
+		require.NoError(t, err)
+		files[file.Name], err = io.ReadAll(r)
+		require.NoError(t, err)
+		r.Close()
+	}
+	require.Len(t, files, 13)
+	for name, data := range files {
+		require.NotContains(t, string(data), "secret-", name)
+	}
+
+	var gotUser User
+	require.NoError(t, json.Unmarshal(files["user.json"], &gotUser))
+	require.Equal(t, user.Email, gotUser.Email)
+
+	var gotSessions []Session
+	require.NoError(t, json.Unmarshal(files["sessions.json"], &gotSessions))
+	require.Len(t, gotSessions, 1)
+	require.Equal(t, session.ID, gotSessions[0].ID)
+
+	var gotAccounts []db.Account
+	require.NoError(t, json.Unmarshal(files["accounts.json"], &gotAccounts))
+	require.Equal(t, []db.Account{account}, gotAccounts)
+
+	var gotEntries []db.Entry
+	require.NoError(t, json.Unmarshal(files["entries.json"], &gotEntries))
+	require.Len(t, gotEntries, pageSize+1)
+	require.Equal(t, int64(pageSize+1), gotEntries[pageSize].ID)
+
+	var gotTransfers []db.Transfer
+	require.NoError(t, json.Unmarshal(files["transfers.json"], &gotTransfers))
+	require.Empty(t, gotTransfers)
+
+	var gotMembers []db.AccountMember
+	require.NoError(t, json.Unmarshal(files["memberships.json"], &gotMembers))
+	require.Equal(t, []db.AccountMember{member}, gotMembers)
+
+	var gotAuditLogs []AuditLog
+	require.NoError(t, json.Unmarshal(files["audit_logs.json"], &gotAuditLogs))
+	require.Len(t, gotAuditLogs, 1)
+	require.JSONEq(t, string(auditLog.Details), string(gotAuditLogs[0].Details))
+
+	var gotMFA MFA
+	require.NoError(t, json.Unmarshal(files["mfa.json"], &gotMFA))
+	require.True(t, gotMFA.Enrolled)
+	require.True(t, gotMFA.EnabledAt.Valid)
+	require.Len(t, gotMFA.RecoveryCodes, 1)
+
+	var gotResets []PasswordReset
+	require.NoError(t, json.Unmarshal(files["password_resets.json"], &gotResets))
+	require.Len(t, gotResets, 1)
+	require.Equal(t, reset.ID, gotResets[0].ID)
+}
+
+func TestExportMFANotEnrolled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Any()).Times(1).Return(db.TotpSecret{}, db.ErrRecordNotFound)
+	store.EXPECT().ExportRecoveryCodes(gomock.Any(), gomock.Any()).Times(0)
+
+	mfa, err := exportMFA(context.Background(), store, "user")
+	require.NoError(t, err)
+	require.False(t, mfa.Enrolled)
+	require.Empty(t, mfa.RecoveryCodes)
+}
+
+func TestWriteZipErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	expectReadOnlyTx(store).Times(2)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, db.ErrRecordNotFound)
+
+	var buf bytes.Buffer
+	err := NewExporter(store).WriteZip(context.Background(), "missing", &buf)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+	require.Zero(t, buf.Len())
+
+	store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{Username: "user"}, nil)
+	store.EXPECT().ExportSessions(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+	err = NewExporter(store).WriteZip(context.Background(), "user", &buf)
+	require.ErrorIs(t, err, sql.ErrConnDone)
+}
+
+// expectReadOnlyTx runs the function given to ReadOnlyTx with the store itself
+func expectReadOnlyTx(store *mockdb.MockStore) *gomock.Call {
+	return store.EXPECT().
+		ReadOnlyTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(db.Querier) error) error {
+			return fn(store)
+		})
+}
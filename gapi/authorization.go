@@ -0,0 +1,75 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"bitbucket.org/jessyw/go_simplebank/authz"
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/logging"
+	"bitbucket.org/jessyw/go_simplebank/token"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	authorizationHeader = "authorization"
+	authorizationBearer = "bearer"
+)
+
+func (server *Server) authorizeUser(ctx context.Context) (*token.Payload, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("missing metadata")
+	}
+
+	values := md.Get(authorizationHeader)
+	if len(values) == 0 {
+		return nil, fmt.Errorf("missing authorization header")
+	}
+
+	fields := strings.Fields(values[0])
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("invalid authorization header format")
+	}
+
+	authType := strings.ToLower(fields[0])
+	if authType != authorizationBearer {
+		return nil, fmt.Errorf("unsupported authorization type: %s", authType)
+	}
+
+	accessToken := fields[1]
+	payload, err := server.tokenMaker.VerifyToken(accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid access token: %s", err)
+	}
+
+	// the access tokens of an erased user stay valid until they expire, the user is checked instead
+	erased, err := server.store.IsUserErased(ctx, payload.Username)
+	if err != nil {
+		return nil, fmt.Errorf("cannot check the user of the access token: %s", err)
+	}
+	if erased {
+		return nil, db.ErrUserErased
+	}
+
+	logging.SetUser(ctx, payload.Username)
+	return payload, nil
+}
+
+// authorizeAccount checks that the user can act on the account with the given permission,
+// as its owner or as one of its members.
+func (server *Server) authorizeAccount(ctx context.Context, account db.Account, username string, permission string) error {
+	err := authz.AuthorizeAccount(ctx, server.store, account, username, permission)
+	if err != nil {
+		if errors.Is(err, authz.ErrAccessDenied) {
+			return status.Errorf(codes.PermissionDenied, "%s", err)
+		}
+		return status.Errorf(codes.Internal, "failed to authorize account access: %s", err)
+	}
+
+	return nil
+}
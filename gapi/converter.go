@@ -2,6 +2,7 @@ package gapi
 
 import (
 	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/event"
 	"bitbucket.org/jessyw/go_simplebank/pb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -15,3 +16,53 @@ func convertUser(user db.User) *pb.User {
 		CreatedAt:         timestamppb.New(user.CreatedAt),
 	}
 }
+
+func convertAccount(account db.Account) *pb.Account {
+	return &pb.Account{
+		Id:        account.ID,
+		Owner:     account.Owner,
+		Balance:   account.Balance,
+		Currency:  account.Currency,
+		CreatedAt: timestamppb.New(account.CreatedAt),
+		Product:   account.Product,
+	}
+}
+
+func convertEntry(entry db.Entry) *pb.Entry {
+	return &pb.Entry{
+		Id:        entry.ID,
+		AccountId: entry.AccountID,
+		Amount:    entry.Amount,
+		CreatedAt: timestamppb.New(entry.CreatedAt),
+	}
+}
+
+func convertTransfer(transfer db.Transfer) *pb.Transfer {
+	return &pb.Transfer{
+		Id:            transfer.ID,
+		FromAccountId: transfer.FromAccountID,
+		ToAccountId:   transfer.ToAccountID,
+		Amount:        transfer.Amount,
+		CreatedAt:     timestamppb.New(transfer.CreatedAt),
+		Fee:           transfer.Fee,
+	}
+}
+
+func convertAccountEvent(accountEvent *event.AccountEvent) *pb.AccountEvent {
+	eventType := pb.AccountEvent_TYPE_UNSPECIFIED
+	switch accountEvent.Type {
+	case event.TypeEntry:
+		eventType = pb.AccountEvent_ENTRY
+	case event.TypeBalance:
+		eventType = pb.AccountEvent_BALANCE
+	}
+
+	return &pb.AccountEvent{
+		Type:      eventType,
+		AccountId: accountEvent.AccountID,
+		EntryId:   accountEvent.EntryID,
+		Amount:    accountEvent.Amount,
+		Balance:   accountEvent.Balance,
+		CreatedAt: timestamppb.New(accountEvent.CreatedAt),
+	}
+}
@@ -1,6 +1,10 @@
 package gapi
 
 import (
+	"strconv"
+	"strings"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -28,3 +32,52 @@ func invalidArgumentError(violations []*errdetails.BadRequest_FieldViolation) er
 func unauthenticatedError(err error) error {
 	return status.Errorf(codes.Unauthenticated, "unauthorized: %s", err)
 }
+
+func transferLimitError(limitErr *db.TransferLimitError) error {
+	statusExhausted := status.New(codes.ResourceExhausted, limitErr.Error())
+
+	errorInfo := &errdetails.ErrorInfo{
+		Reason: "TRANSFER_LIMIT_EXCEEDED",
+		Domain: "simplebank",
+		Metadata: map[string]string{
+			"limit_id":  strconv.FormatInt(limitErr.Limit.ID, 10),
+			"kind":      limitErr.Limit.Kind,
+			"max_value": strconv.FormatInt(limitErr.Limit.MaxValue, 10),
+			"current":   strconv.FormatInt(limitErr.Current, 10),
+		},
+	}
+	quotaFailure := &errdetails.QuotaFailure{
+		Violations: []*errdetails.QuotaFailure_Violation{
+			{
+				Subject:     limitErr.Scope(),
+				Description: limitErr.Reason(),
+			},
+		},
+	}
+
+	statusDetails, err := statusExhausted.WithDetails(errorInfo, quotaFailure)
+	if err != nil {
+		return statusExhausted.Err()
+	}
+
+	return statusDetails.Err()
+}
+
+func transferDeniedError(reasons []string) error {
+	statusDenied := status.New(codes.PermissionDenied, "transfer denied by risk screening")
+
+	errorInfo := &errdetails.ErrorInfo{
+		Reason: "TRANSFER_DENIED",
+		Domain: "simplebank",
+		Metadata: map[string]string{
+			"reasons": strings.Join(reasons, "; "),
+		},
+	}
+
+	statusDetails, err := statusDenied.WithDetails(errorInfo)
+	if err != nil {
+		return statusDenied.Err()
+	}
+
+	return statusDetails.Err()
+}
@@ -0,0 +1,137 @@
+package gapi
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"bitbucket.org/jessyw/go_simplebank/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// withRequest adds the request ID given in the metadata, or a new one, to the context of the call,
+// and sends it back in the response header
+func withRequest(ctx context.Context) context.Context {
+	var given string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(logging.RequestIDHeader); len(values) > 0 {
+			given = values[0]
+		}
+	}
+
+	requestID := logging.NewRequestID(given)
+	grpc.SetHeader(ctx, metadata.Pairs(logging.RequestIDHeader, requestID))
+	return logging.WithRequest(ctx, requestID)
+}
+
+func logCall(ctx context.Context, fullMethod string, startTime time.Time, err error) {
+	statusCode := status.Code(err)
+	level := slog.LevelInfo
+	if statusCode == codes.Internal || statusCode == codes.Unknown || statusCode == codes.DataLoss {
+		level = slog.LevelError
+	}
+
+	attrs := []slog.Attr{
+		slog.String("protocol", "grpc"),
+		slog.String("method", fullMethod),
+		slog.Int("status_code", int(statusCode)),
+		slog.String("status_text", statusCode.String()),
+		slog.Duration("duration", time.Since(startTime)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	slog.LogAttrs(ctx, level, "received a gRPC request", attrs...)
+}
+
+// GrpcLogger gives each call an ID and logs it once served.
+// It must come first in the chain, so that the other interceptors log with the request ID.
+func GrpcLogger(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	startTime := time.Now()
+	ctx = withRequest(ctx)
+
+	result, err := handler(ctx, req)
+	logCall(ctx, info.FullMethod, startTime, err)
+	return result, err
+}
+
+// loggedStream replaces the context of a stream by the one carrying the request ID
+type loggedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (stream *loggedStream) Context() context.Context {
+	return stream.ctx
+}
+
+// StreamGrpcLogger gives each stream an ID and logs it once closed
+func StreamGrpcLogger(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	startTime := time.Now()
+	ctx := withRequest(stream.Context())
+
+	err := handler(srv, &loggedStream{ServerStream: stream, ctx: ctx})
+	logCall(ctx, info.FullMethod, startTime, err)
+	return err
+}
+
+// ResponseRecorder keeps the status code written by the gateway
+type ResponseRecorder struct {
+	http.ResponseWriter
+	StatusCode int
+}
+
+func (rec *ResponseRecorder) WriteHeader(statusCode int) {
+	rec.StatusCode = statusCode
+	rec.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Flush lets the gateway stream the responses of server streaming RPCs
+func (rec *ResponseRecorder) Flush() {
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// HttpLogger gives each request of the HTTP gateway an ID and logs it once served.
+// The gateway calls the RPCs in process, without going through GrpcLogger.
+func HttpLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		startTime := time.Now()
+		requestID := logging.NewRequestID(r.Header.Get(logging.RequestIDHeader))
+		ctx := logging.WithRequest(r.Context(), requestID)
+		w.Header().Set(logging.RequestIDHeader, requestID)
+
+		rec := &ResponseRecorder{ResponseWriter: w, StatusCode: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		level := slog.LevelInfo
+		if rec.StatusCode >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		slog.LogAttrs(ctx, level, "received an HTTP request",
+			slog.String("protocol", "http"),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status_code", rec.StatusCode),
+			slog.String("status_text", http.StatusText(rec.StatusCode)),
+			slog.Duration("duration", time.Since(startTime)),
+			slog.String("client_ip", hostOf(r.RemoteAddr)),
+		)
+	})
+}
+
+// GatewayMetadata forwards the request ID given by HttpLogger and the client host to the RPCs in the gRPC metadata
+func GatewayMetadata(ctx context.Context, r *http.Request) metadata.MD {
+	md := metadata.Pairs(gatewayClientIPHeader, hostOf(r.RemoteAddr))
+	if requestID := logging.RequestID(r.Context()); requestID != "" {
+		md.Set(logging.RequestIDHeader, requestID)
+	}
+	return md
+}
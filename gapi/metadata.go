@@ -10,7 +10,8 @@ import (
 const (
 	grpcGatewayUserAgentHeader = "grpcgateway-user-agent"
 	userAgentHeader            = "user-agent"
-	xForwardedForHeader        = "x-forwarded-for"
+	// gatewayClientIPHeader carries the client host set by GatewayMetadata, clients cannot override it
+	gatewayClientIPHeader = "x-gateway-client-ip"
 )
 
 type Metadata struct {
@@ -30,13 +31,15 @@ func (server *Server) extractMetadata(ctx context.Context) *Metadata {
 			mtdt.UserAgent = userAgents[0]
 		}
 
-		if clientIPs := md.Get(xForwardedForHeader); len(clientIPs) > 0 {
-			mtdt.ClientIP = clientIPs[0]
+		// the gateway appends its metadata after the Grpc-Metadata-* headers of the client, only the last value is its own
+		if clientIPs := md.Get(gatewayClientIPHeader); len(clientIPs) > 0 {
+			mtdt.ClientIP = clientIPs[len(clientIPs)-1]
 		}
 	}
 
+	// the port changes with every connection, the sessions and the risk rules only compare the host
 	if p, ok := peer.FromContext(ctx); ok {
-		mtdt.ClientIP = p.Addr.String()
+		mtdt.ClientIP = hostOf(p.Addr.String())
 	}
 
 	return mtdt
@@ -0,0 +1,44 @@
+package gapi
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bitbucket.org/jessyw/go_simplebank/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// GrpcMetrics counts the calls and observes their latency, by method and status code
+func GrpcMetrics(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	startTime := time.Now()
+	result, err := handler(ctx, req)
+	metrics.ObserveRequest("grpc", info.FullMethod, status.Code(err).String(), time.Since(startTime))
+	return result, err
+}
+
+// StreamGrpcMetrics counts the streams and observes how long they stay open, by method and status code
+func StreamGrpcMetrics(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	startTime := time.Now()
+	err := handler(srv, stream)
+	metrics.ObserveRequest("grpc", info.FullMethod, status.Code(err).String(), time.Since(startTime))
+	return err
+}
+
+// HttpMetrics counts the requests of the HTTP gateway and observes their latency, by RPC and status code
+func HttpMetrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		startTime := time.Now()
+		rec := &ResponseRecorder{ResponseWriter: w, StatusCode: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		// the paths that aren't routes are counted together, to keep the number of series bounded
+		method, ok := gatewayMethods[r.URL.Path]
+		if !ok {
+			method = "unmatched"
+		}
+		metrics.ObserveRequest("http", method, strconv.Itoa(rec.StatusCode), time.Since(startTime))
+	})
+}
@@ -0,0 +1,164 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"bitbucket.org/jessyw/go_simplebank/pb"
+	"bitbucket.org/jessyw/go_simplebank/ratelimit"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// methodBudgets are the rate limit budgets of the RPCs, the ones that aren't listed use the write budget
+var methodBudgets = map[string]string{
+	pb.SimpleBank_CreateUser_FullMethodName:           ratelimit.BudgetLogin,
+	pb.SimpleBank_LoginUser_FullMethodName:            ratelimit.BudgetLogin,
+	pb.SimpleBank_VerifyLoginMFA_FullMethodName:       ratelimit.BudgetLogin,
+	pb.SimpleBank_RequestPasswordReset_FullMethodName: ratelimit.BudgetLogin,
+	pb.SimpleBank_ResetPassword_FullMethodName:        ratelimit.BudgetLogin,
+	pb.SimpleBank_CreateTransfer_FullMethodName:       ratelimit.BudgetTransfer,
+	pb.SimpleBank_BatchTransfer_FullMethodName:        ratelimit.BudgetTransfer,
+	pb.SimpleBank_WatchAccount_FullMethodName:         ratelimit.BudgetRead,
+}
+
+// gatewayMethods are the RPCs served by the HTTP gateway, by path
+var gatewayMethods = map[string]string{
+	"/v1/create_user":            pb.SimpleBank_CreateUser_FullMethodName,
+	"/v1/login_user":             pb.SimpleBank_LoginUser_FullMethodName,
+	"/v1/verify_login_mfa":       pb.SimpleBank_VerifyLoginMFA_FullMethodName,
+	"/v1/enroll_mfa":             pb.SimpleBank_EnrollMFA_FullMethodName,
+	"/v1/enable_mfa":             pb.SimpleBank_EnableMFA_FullMethodName,
+	"/v1/disable_mfa":            pb.SimpleBank_DisableMFA_FullMethodName,
+	"/v1/update_user":            pb.SimpleBank_UpdateUser_FullMethodName,
+	"/v1/request_password_reset": pb.SimpleBank_RequestPasswordReset_FullMethodName,
+	"/v1/reset_password":         pb.SimpleBank_ResetPassword_FullMethodName,
+	"/v1/create_transfer":        pb.SimpleBank_CreateTransfer_FullMethodName,
+	"/v1/batch_transfer":         pb.SimpleBank_BatchTransfer_FullMethodName,
+}
+
+func methodBudget(fullMethod string) string {
+	if budget, ok := methodBudgets[fullMethod]; ok {
+		return budget
+	}
+	return ratelimit.BudgetWrite
+}
+
+// rateLimitKey counts the requests by user when the caller sent a valid access token, and by client IP otherwise
+func (server *Server) rateLimitKey(ctx context.Context) string {
+	payload, err := server.authorizeUser(ctx)
+	if err == nil {
+		return ratelimit.UserKey(payload.Username)
+	}
+	return ratelimit.IPKey(server.extractMetadata(ctx).ClientIP)
+}
+
+// UnaryRateLimitInterceptor refuses the calls over the rate limit budget of their method
+func (server *Server) UnaryRateLimitInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	err := server.limiter.Allow(ctx, methodBudget(info.FullMethod), server.rateLimitKey(ctx))
+	if err != nil {
+		return nil, rateLimitError(err)
+	}
+
+	return handler(ctx, req)
+}
+
+// StreamRateLimitInterceptor refuses the streams over the rate limit budget of their method
+func (server *Server) StreamRateLimitInterceptor(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx := stream.Context()
+	err := server.limiter.Allow(ctx, methodBudget(info.FullMethod), server.rateLimitKey(ctx))
+	if err != nil {
+		return rateLimitError(err)
+	}
+
+	return handler(srv, stream)
+}
+
+// RateLimitHandler applies the rate limits to the requests of the HTTP gateway, which call the RPCs in process,
+// without going through the interceptors
+func (server *Server) RateLimitHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fullMethod, ok := gatewayMethods[r.URL.Path]
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := ratelimit.IPKey(hostOf(r.RemoteAddr))
+		fields := strings.Fields(r.Header.Get(authorizationHeader))
+		if len(fields) == 2 && strings.ToLower(fields[0]) == authorizationBearer {
+			if payload, err := server.tokenMaker.VerifyToken(fields[1]); err == nil {
+				key = ratelimit.UserKey(payload.Username)
+			}
+		}
+
+		err := server.limiter.Allow(r.Context(), methodBudget(fullMethod), key)
+		if err != nil {
+			writeGatewayError(w, rateLimitError(err))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func rateLimitError(err error) error {
+	var limited *ratelimit.LimitedError
+	if !errors.As(err, &limited) {
+		return status.Errorf(codes.Internal, "failed to check rate limit: %s", err)
+	}
+
+	statusLimited := status.New(codes.ResourceExhausted, limited.Error())
+	statusDetails, err := statusLimited.WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(limited.RetryAfter),
+	})
+	if err != nil {
+		return statusLimited.Err()
+	}
+
+	return statusDetails.Err()
+}
+
+// writeGatewayError writes the status error the way the gateway does, with a Retry-After header for retryable errors
+func writeGatewayError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	code := http.StatusInternalServerError
+	if st.Code() == codes.ResourceExhausted {
+		code = http.StatusTooManyRequests
+	}
+
+	for _, detail := range st.Details() {
+		if retryInfo, ok := detail.(*errdetails.RetryInfo); ok {
+			seconds := math.Ceil(retryInfo.GetRetryDelay().AsDuration().Seconds())
+			w.Header().Set("Retry-After", strconv.Itoa(int(seconds)))
+		}
+	}
+
+	body, err := protojson.Marshal(st.Proto())
+	if err != nil {
+		http.Error(w, st.Message(), code)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(body)
+}
+
+// hostOf strips the port from an address, so that all the connections of a client share its budget and its devices
+func hostOf(address string) string {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+	return host
+}
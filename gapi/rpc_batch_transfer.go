@@ -0,0 +1,191 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/pb"
+	"bitbucket.org/jessyw/go_simplebank/risk"
+	"bitbucket.org/jessyw/go_simplebank/validator"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const maxBatchTransferItems = 100
+
+func (server *Server) BatchTransfer(ctx context.Context, req *pb.BatchTransferRequest) (*pb.BatchTransferResponse, error) {
+	authPayload, err := server.authorizeUser(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateBatchTransferRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	fromAccount, err := server.validAccount(ctx, req.GetFromAccountId(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+
+	err = server.authorizeAccount(ctx, fromAccount, authPayload.Username, db.AccountPermissionTransact)
+	if err != nil {
+		return nil, err
+	}
+
+	arg := db.BatchTransferTxParams{
+		FromAccountID: req.GetFromAccountId(),
+		Items:         make([]db.BatchTransferItem, len(req.GetItems())),
+		AllOrNothing:  req.GetAllOrNothing(),
+	}
+	for i, item := range req.GetItems() {
+		arg.Items[i] = db.BatchTransferItem{
+			ToAccountID: item.GetToAccountId(),
+			Amount:      item.GetAmount(),
+		}
+	}
+
+	err = server.verifyStepUp(ctx, authPayload.Username, arg.Total(), req.GetMfaCode())
+	if err != nil {
+		return nil, err
+	}
+
+	err = server.screenBatchTransfer(ctx, fromAccount, arg, authPayload.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := server.store.BatchTransferTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrBatchTransferFailed) {
+			return nil, batchTransferFailedError(result)
+		}
+		if errors.Is(err, db.ErrAccountClosed) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "failed to transfer: %s", err)
+	}
+
+	rsp := &pb.BatchTransferResponse{
+		FromAccount: convertAccount(result.FromAccount),
+		Completed:   int32(result.Completed),
+		Failed:      int32(result.Failed),
+	}
+	for _, item := range result.Items {
+		itemResult := &pb.BatchTransferItemResult{
+			ToAccountId: item.ToAccountID,
+			Amount:      item.Amount,
+			Status:      item.Status,
+			Error:       item.Error,
+		}
+		if item.Status == db.BatchItemStatusCompleted {
+			itemResult.Transfer = convertTransfer(item.Transfer)
+		}
+		rsp.Items = append(rsp.Items, itemResult)
+	}
+	return rsp, nil
+}
+
+// screenBatchTransfer runs every item through the risk screening and the approval policy of the source account.
+// Batches can't be held for review or approval, the items that would be have to be sent as individual transfers.
+func (server *Server) screenBatchTransfer(ctx context.Context, fromAccount db.Account, arg db.BatchTransferTxParams, username string) error {
+	policy, hasPolicy, err := db.FindApprovalPolicy(ctx, server.store, fromAccount.ID)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to find approval policy: %s", err)
+	}
+
+	mtdt := server.extractMetadata(ctx)
+	var denied []string
+	var recipients []int64
+	for i, item := range arg.Items {
+		if hasPolicy && policy.RequiresApproval(item.Amount) {
+			return status.Errorf(codes.FailedPrecondition,
+				"item %d is above the approval threshold of %d, send it as an individual transfer", i, policy.Threshold)
+		}
+
+		screening, err := server.screener.Screen(ctx, risk.Transfer{
+			FromAccount: fromAccount,
+			// the recipient is validated by the batch transaction, rules only need its ID
+			ToAccount: db.Account{ID: item.ToAccountID},
+			Amount:    item.Amount,
+			Username:  username,
+			ClientIP:  mtdt.ClientIP,
+			UserAgent: mtdt.UserAgent,
+			// the fan out counts the new recipients of the whole batch
+			BatchRecipients: slices.Clip(recipients),
+		})
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to screen transfer: %s", err)
+		}
+
+		switch screening.Decision {
+		case risk.DecisionDeny:
+			for _, reason := range screening.Reasons {
+				denied = append(denied, fmt.Sprintf("item %d: %s", i, reason))
+			}
+		case risk.DecisionReview:
+			return status.Errorf(codes.FailedPrecondition,
+				"item %d needs a review, send it as an individual transfer", i)
+		}
+
+		if !slices.Contains(recipients, item.ToAccountID) {
+			recipients = append(recipients, item.ToAccountID)
+		}
+	}
+
+	if len(denied) > 0 {
+		return transferDeniedError(denied)
+	}
+	return nil
+}
+
+func batchTransferFailedError(result db.BatchTransferTxResult) error {
+	var violations []*errdetails.BadRequest_FieldViolation
+	for i, item := range result.Items {
+		if item.Status == db.BatchItemStatusFailed {
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{
+				Field:       fmt.Sprintf("items[%d]", i),
+				Description: item.Error,
+			})
+		}
+	}
+
+	statusFailed := status.New(codes.FailedPrecondition, db.ErrBatchTransferFailed.Error())
+	statusDetails, err := statusFailed.WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if err != nil {
+		return statusFailed.Err()
+	}
+
+	return statusDetails.Err()
+}
+
+func validateBatchTransferRequest(req *pb.BatchTransferRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validator.ValidateAccountID(req.GetFromAccountId()); err != nil {
+		violations = append(violations, fieldViolation("from_account_id", err))
+	}
+
+	if err := validator.ValidateCurrency(req.GetCurrency()); err != nil {
+		violations = append(violations, fieldViolation("currency", err))
+	}
+
+	if len(req.GetItems()) == 0 || len(req.GetItems()) > maxBatchTransferItems {
+		violations = append(violations, fieldViolation("items", fmt.Errorf("must contain from 1 to %d items", maxBatchTransferItems)))
+	}
+
+	for i, item := range req.GetItems() {
+		if err := validator.ValidateAccountID(item.GetToAccountId()); err != nil {
+			violations = append(violations, fieldViolation(fmt.Sprintf("items[%d].to_account_id", i), err))
+		}
+
+		if err := validator.ValidateAmount(item.GetAmount()); err != nil {
+			violations = append(violations, fieldViolation(fmt.Sprintf("items[%d].amount", i), err))
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,176 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/pb"
+	"bitbucket.org/jessyw/go_simplebank/risk"
+	"bitbucket.org/jessyw/go_simplebank/validator"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) CreateTransfer(ctx context.Context, req *pb.CreateTransferRequest) (*pb.CreateTransferResponse, error) {
+	authPayload, err := server.authorizeUser(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateCreateTransferRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	fromAccount, err := server.validAccount(ctx, req.GetFromAccountId(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+
+	err = server.authorizeAccount(ctx, fromAccount, authPayload.Username, db.AccountPermissionTransact)
+	if err != nil {
+		return nil, err
+	}
+
+	err = server.verifyStepUp(ctx, authPayload.Username, req.GetAmount(), req.GetMfaCode())
+	if err != nil {
+		return nil, err
+	}
+
+	toAccount, err := server.validAccount(ctx, req.GetToAccountId(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+
+	mtdt := server.extractMetadata(ctx)
+	screening, err := server.screener.Screen(ctx, risk.Transfer{
+		FromAccount: fromAccount,
+		ToAccount:   toAccount,
+		Amount:      req.GetAmount(),
+		Username:    authPayload.Username,
+		ClientIP:    mtdt.ClientIP,
+		UserAgent:   mtdt.UserAgent,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to screen transfer: %s", err)
+	}
+
+	switch screening.Decision {
+	case risk.DecisionDeny:
+		return nil, transferDeniedError(screening.Reasons)
+	case risk.DecisionReview:
+		review, err := server.store.CreateTransferReview(ctx, db.CreateTransferReviewParams{
+			FromAccountID: req.GetFromAccountId(),
+			ToAccountID:   req.GetToAccountId(),
+			Amount:        req.GetAmount(),
+			RequestedBy:   authPayload.Username,
+			Reasons:       screening.Reasons,
+		})
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to hold transfer for review: %s", err)
+		}
+
+		rsp := &pb.CreateTransferResponse{
+			Status:   review.Status,
+			ReviewId: review.ID,
+			Reasons:  review.Reasons,
+		}
+		return rsp, nil
+	}
+
+	arg := db.TransferTxParams{
+		FromAccountID: req.GetFromAccountId(),
+		ToAccountID:   req.GetToAccountId(),
+		Amount:        req.GetAmount(),
+	}
+
+	request, requested, err := db.RequestTransferApproval(ctx, server.store, db.RequestTransferApprovalParams{
+		TransferTxParams: arg,
+		RequestedBy:      authPayload.Username,
+		ExpiresAt:        time.Now().Add(server.config.TransferRequestDuration),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to request transfer approval: %s", err)
+	}
+
+	if requested {
+		rsp := &pb.CreateTransferResponse{
+			Status:            transferStatusPendingApproval,
+			TransferRequestId: request.ID,
+		}
+		return rsp, nil
+	}
+
+	result, err := server.store.TransferTx(ctx, arg)
+	if err != nil {
+		var limitErr *db.TransferLimitError
+		if errors.As(err, &limitErr) {
+			return nil, transferLimitError(limitErr)
+		}
+		if errors.Is(err, db.ErrAccountClosed) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "failed to transfer: %s", err)
+	}
+
+	rsp := &pb.CreateTransferResponse{
+		Transfer:    convertTransfer(result.Transfer),
+		FromAccount: convertAccount(result.FromAccount),
+		ToAccount:   convertAccount(result.ToAccount),
+		FromEntry:   convertEntry(result.FromEntry),
+		ToEntry:     convertEntry(result.ToEntry),
+		Status:      transferStatusCompleted,
+	}
+	if result.Fee > 0 {
+		rsp.FeeEntry = convertEntry(result.FeeEntry)
+	}
+	return rsp, nil
+}
+
+const (
+	transferStatusCompleted       = "completed"
+	transferStatusPendingApproval = "pending_approval"
+)
+
+func (server *Server) validAccount(ctx context.Context, accountID int64, currency string) (db.Account, error) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return account, status.Errorf(codes.NotFound, "account [%d] not found", accountID)
+		}
+		return account, status.Errorf(codes.Internal, "failed to find account [%d]", accountID)
+	}
+
+	if account.Currency != currency {
+		return account, status.Errorf(codes.InvalidArgument, "account [%d] currency mismatch: %s vs %s", accountID, account.Currency, currency)
+	}
+
+	if account.ClosedAt.Valid {
+		return account, status.Errorf(codes.FailedPrecondition, "account [%d] is closed", accountID)
+	}
+
+	return account, nil
+}
+
+func validateCreateTransferRequest(req *pb.CreateTransferRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validator.ValidateAccountID(req.GetFromAccountId()); err != nil {
+		violations = append(violations, fieldViolation("from_account_id", err))
+	}
+
+	if err := validator.ValidateAccountID(req.GetToAccountId()); err != nil {
+		violations = append(violations, fieldViolation("to_account_id", err))
+	}
+
+	if err := validator.ValidateAmount(req.GetAmount()); err != nil {
+		violations = append(violations, fieldViolation("amount", err))
+	}
+
+	if err := validator.ValidateCurrency(req.GetCurrency()); err != nil {
+		violations = append(violations, fieldViolation("currency", err))
+	}
+
+	return violations
+}
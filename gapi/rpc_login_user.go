@@ -3,14 +3,17 @@ package gapi
 import (
 	"context"
 	"errors"
+	"log/slog"
 
 	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/lockout"
 	"bitbucket.org/jessyw/go_simplebank/pb"
 	"bitbucket.org/jessyw/go_simplebank/util"
 	"bitbucket.org/jessyw/go_simplebank/validator"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -20,21 +23,69 @@ func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (
 		return nil, invalidArgumentError(violations)
 	}
 
+	mtdt := server.extractMetadata(ctx)
+	err := server.guard.Check(ctx, req.GetUsername(), mtdt.ClientIP)
+	if err != nil {
+		var throttled *lockout.ThrottledError
+		if errors.As(err, &throttled) {
+			return nil, throttledError(throttled)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to check failed logins")
+	}
+
+	needsRehash := false
 	user, err := server.store.GetUser(ctx, req.GetUsername())
 	if err != nil {
-		if errors.Is(err, db.ErrRecordNotFound) {
-			return nil, status.Errorf(codes.NotFound, "user not found")
+		if !errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.Internal, "failed to find user")
+		}
+		err = util.CheckPasswordOfUnknownUser(req.GetPassword())
+	} else {
+		needsRehash, err = util.CheckPassword(req.GetPassword(), user.HashedPassword)
+	}
+	if err != nil {
+		// the attempt reserved by Check stays counted as a failed login,
+		// the same error whether the username exists or not
+		return nil, status.Errorf(codes.Unauthenticated, "incorrect username or password")
+	}
+
+	if needsRehash {
+		server.rehashPassword(ctx, user, req.GetPassword())
+	}
+
+	mfaEnabled, err := server.authenticator.Enabled(ctx, user.Username)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to find second factor")
+	}
+
+	if mfaEnabled {
+		// the attempt reserved by Check is only given back once the code is checked too, wrong codes count as failed logins
+		challengeToken, expiresAt, err := server.authenticator.Challenge(ctx, user.Username)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to create login challenge")
 		}
-		return nil, status.Errorf(codes.Internal, "failed to find user")
+
+		rsp := &pb.LoginUserResponse{
+			MfaRequired:           true,
+			MfaChallengeToken:     challengeToken,
+			MfaChallengeExpiresAt: timestamppb.New(expiresAt),
+		}
+		return rsp, nil
 	}
 
-	err = util.CheckPassword(req.Password, user.HashedPassword)
+	err = server.guard.Succeed(ctx, user.Username, mtdt.ClientIP)
 	if err != nil {
-		return nil, status.Errorf(codes.NotFound, "incorrect password")
+		return nil, status.Errorf(codes.Internal, "failed to reset failed logins")
 	}
 
+	return server.createSession(ctx, user)
+}
+
+// createSession issues the access and refresh tokens of a user who is logged in
+func (server *Server) createSession(ctx context.Context, user db.User) (*pb.LoginUserResponse, error) {
 	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
 		user.Username,
+		user.Role,
 		server.config.AccessTokenDuration,
 	)
 	if err != nil {
@@ -43,6 +94,7 @@ func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (
 
 	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(
 		user.Username,
+		user.Role,
 		server.config.RefreshTokenDuration,
 	)
 	if err != nil {
@@ -74,6 +126,22 @@ func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (
 	return rsp, nil
 }
 
+// rehashPassword hashes again a password whose hash was made with an older algorithm or weaker parameters.
+// The login doesn't depend on it: a failure is only logged, the password will be rehashed at the next login.
+func (server *Server) rehashPassword(ctx context.Context, user db.User, password string) {
+	hashedPassword, err := util.HashPassword(password)
+	if err == nil {
+		err = server.store.RehashUserPassword(ctx, db.RehashUserPasswordParams{
+			HashedPassword:         hashedPassword,
+			Username:               user.Username,
+			PreviousHashedPassword: user.HashedPassword,
+		})
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "cannot rehash password", slog.String("username", user.Username), slog.Any("error", err))
+	}
+}
+
 func validateLoginUserRequest(req *pb.LoginUserRequest) (violations []*errdetails.BadRequest_FieldViolation) {
 	if err := validator.ValidateUsername(req.GetUsername()); err != nil {
 		violations = append(violations, fieldViolation("username", err))
@@ -85,3 +153,16 @@ func validateLoginUserRequest(req *pb.LoginUserRequest) (violations []*errdetail
 
 	return violations
 }
+
+// throttledError tells the client when to retry a login that was refused because of failed logins
+func throttledError(throttled *lockout.ThrottledError) error {
+	statusThrottled := status.New(codes.ResourceExhausted, throttled.Error())
+	statusDetails, err := statusThrottled.WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(throttled.RetryAfter),
+	})
+	if err != nil {
+		return statusThrottled.Err()
+	}
+
+	return statusDetails.Err()
+}
@@ -0,0 +1,139 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/mfa"
+	"bitbucket.org/jessyw/go_simplebank/pb"
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"bitbucket.org/jessyw/go_simplebank/validator"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// EnrollMFA starts the enrollment of the authenticated user in two-factor authentication.
+// The enrollment is confirmed with EnableMFA.
+func (server *Server) EnrollMFA(ctx context.Context, req *pb.EnrollMFARequest) (*pb.EnrollMFAResponse, error) {
+	authPayload, err := server.authorizeUser(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	if err := validator.ValidateUsername(req.GetUsername()); err != nil {
+		return nil, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{fieldViolation("username", err)})
+	}
+
+	if req.GetUsername() != authPayload.Username {
+		return nil, status.Errorf(codes.PermissionDenied, "cannot enroll other user in two-factor authentication")
+	}
+
+	enrollment, err := server.authenticator.Enroll(ctx, req.GetUsername())
+	if err != nil {
+		if errors.Is(err, mfa.ErrAlreadyEnabled) {
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "failed to enroll in two-factor authentication: %s", err)
+	}
+
+	rsp := &pb.EnrollMFAResponse{
+		Secret:          enrollment.Secret,
+		ProvisioningUri: enrollment.ProvisioningURI,
+	}
+	return rsp, nil
+}
+
+// EnableMFA confirms the enrollment of the authenticated user with a first code from their authenticator app
+func (server *Server) EnableMFA(ctx context.Context, req *pb.EnableMFARequest) (*pb.EnableMFAResponse, error) {
+	authPayload, err := server.authorizeUser(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateMFACodeRequest(req.GetUsername(), req.GetCode())
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	if req.GetUsername() != authPayload.Username {
+		return nil, status.Errorf(codes.PermissionDenied, "cannot enable two-factor authentication of other user")
+	}
+
+	recoveryCodes, err := server.authenticator.Enable(ctx, req.GetUsername(), req.GetCode())
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrMFANotPending), errors.Is(err, mfa.ErrAlreadyEnabled):
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		case errors.Is(err, mfa.ErrInvalidCode):
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		default:
+			return nil, status.Errorf(codes.Internal, "failed to enable two-factor authentication: %s", err)
+		}
+	}
+
+	return &pb.EnableMFAResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+// DisableMFA turns off two-factor authentication. Users give a code to turn off their own,
+// bankers can turn off the one of a user who lost their authenticator app and recovery codes.
+func (server *Server) DisableMFA(ctx context.Context, req *pb.DisableMFARequest) (*pb.DisableMFAResponse, error) {
+	authPayload, err := server.authorizeUser(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	if err := validator.ValidateUsername(req.GetUsername()); err != nil {
+		return nil, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{fieldViolation("username", err)})
+	}
+
+	switch {
+	case req.GetUsername() == authPayload.Username:
+		violations := validateMFACodeRequest(req.GetUsername(), req.GetCode())
+		if violations != nil {
+			return nil, invalidArgumentError(violations)
+		}
+
+		err = server.authenticator.Verify(ctx, req.GetUsername(), req.GetCode())
+		if err != nil {
+			switch {
+			case errors.Is(err, mfa.ErrNotEnabled):
+				return nil, status.Error(codes.FailedPrecondition, err.Error())
+			case errors.Is(err, mfa.ErrInvalidCode):
+				return nil, status.Error(codes.Unauthenticated, err.Error())
+			default:
+				return nil, status.Errorf(codes.Internal, "failed to verify two-factor authentication code: %s", err)
+			}
+		}
+	case authPayload.Role == util.BankerRole:
+		enabled, err := server.authenticator.Enabled(ctx, req.GetUsername())
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to find second factor: %s", err)
+		}
+		if !enabled {
+			return nil, status.Error(codes.FailedPrecondition, mfa.ErrNotEnabled.Error())
+		}
+	default:
+		return nil, status.Errorf(codes.PermissionDenied, "cannot disable two-factor authentication of other user")
+	}
+
+	err = server.authenticator.Disable(ctx, req.GetUsername(), authPayload.Username)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to disable two-factor authentication: %s", err)
+	}
+
+	return &pb.DisableMFAResponse{Message: "two-factor authentication is disabled"}, nil
+}
+
+func validateMFACodeRequest(username string, code string) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validator.ValidateUsername(username); err != nil {
+		violations = append(violations, fieldViolation("username", err))
+	}
+
+	if err := validator.ValidateString(code, 1, 20); err != nil {
+		violations = append(violations, fieldViolation("code", err))
+	}
+
+	return violations
+}
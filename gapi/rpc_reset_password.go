@@ -0,0 +1,74 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/pb"
+	"bitbucket.org/jessyw/go_simplebank/validator"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) RequestPasswordReset(ctx context.Context, req *pb.RequestPasswordResetRequest) (*pb.RequestPasswordResetResponse, error) {
+	violations := validateRequestPasswordResetRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	err := server.recoverer.RequestPasswordReset(ctx, req.GetEmail())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to request password reset: %s", err)
+	}
+
+	rsp := &pb.RequestPasswordResetResponse{
+		Message: "if the email is registered, a password reset token was sent to it",
+	}
+	return rsp, nil
+}
+
+func (server *Server) ResetPassword(ctx context.Context, req *pb.ResetPasswordRequest) (*pb.ResetPasswordResponse, error) {
+	violations := validateResetPasswordRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	result, err := server.recoverer.ResetPassword(ctx, req.GetToken(), req.GetPassword())
+	if err != nil {
+		if errors.Is(err, db.ErrPasswordResetInvalid) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		var passwordErr *validator.PasswordError
+		if errors.As(err, &passwordErr) {
+			return nil, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{fieldViolation("password", err)})
+		}
+		return nil, status.Errorf(codes.Internal, "failed to reset password: %s", err)
+	}
+
+	rsp := &pb.ResetPasswordResponse{
+		User: convertUser(result.User),
+	}
+	return rsp, nil
+}
+
+func validateRequestPasswordResetRequest(req *pb.RequestPasswordResetRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validator.ValidateEmail(req.GetEmail()); err != nil {
+		violations = append(violations, fieldViolation("email", err))
+	}
+
+	return violations
+}
+
+func validateResetPasswordRequest(req *pb.ResetPasswordRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validator.ValidateString(req.GetToken(), 1, 100); err != nil {
+		violations = append(violations, fieldViolation("token", err))
+	}
+
+	if err := validator.ValidateString(req.GetPassword(), 1, validator.PasswordMaxLength); err != nil {
+		violations = append(violations, fieldViolation("password", err))
+	}
+
+	return violations
+}
@@ -0,0 +1,149 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/lockout"
+	"bitbucket.org/jessyw/go_simplebank/pb"
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"bitbucket.org/jessyw/go_simplebank/validator"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UpdateUserResponse, error) {
+	authPayload, err := server.authorizeUser(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateUpdateUserRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	if req.GetUsername() != authPayload.Username && authPayload.Role != util.BankerRole {
+		return nil, status.Errorf(codes.PermissionDenied, "cannot update other user's info")
+	}
+
+	user, err := server.store.GetUser(ctx, req.GetUsername())
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "user not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to find user")
+	}
+
+	// a stolen access token must not be enough to change the email, then reset the password, or the password itself
+	if user.Username == authPayload.Username && (req.Email != nil || req.Password != nil) {
+		err = server.verifyCurrentPassword(ctx, user, req.GetCurrentPassword())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	arg := db.UpdateUserParams{
+		Username: user.Username,
+		FullName: pgtype.Text{
+			String: req.GetFullName(),
+			Valid:  req.FullName != nil,
+		},
+		Email: pgtype.Text{
+			String: req.GetEmail(),
+			Valid:  req.Email != nil,
+		},
+	}
+	if req.Email != nil {
+		user.Email = req.GetEmail()
+	}
+
+	if req.Password != nil {
+		err = server.passwordPolicy.Validate(req.GetPassword(), user.Username, user.Email)
+		if err != nil {
+			return nil, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{fieldViolation("password", err)})
+		}
+
+		hashedPassword, err := util.HashPassword(req.GetPassword())
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to hash password: %s", err)
+		}
+		arg.HashedPassword = pgtype.Text{
+			String: hashedPassword,
+			Valid:  true,
+		}
+	}
+
+	result, err := server.store.UpdateUserTx(ctx, db.UpdateUserTxParams{
+		UpdateUserParams: arg,
+		UpdatedBy:        authPayload.Username,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "user not found")
+		}
+		if db.ErrorCode(err) == db.UniqueViolation {
+			return nil, status.Errorf(codes.AlreadyExists, "email already exists")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to update user: %s", err)
+	}
+
+	rsp := &pb.UpdateUserResponse{
+		User: convertUser(result.User),
+	}
+	return rsp, nil
+}
+
+// verifyCurrentPassword checks the current password of the user before a sensitive change.
+// Wrong passwords count as failed logins, so that a stolen access token can't be used to guess it.
+func (server *Server) verifyCurrentPassword(ctx context.Context, user db.User, password string) error {
+	if password == "" {
+		return status.Errorf(codes.Unauthenticated, "the current password is required to change the email or the password")
+	}
+
+	mtdt := server.extractMetadata(ctx)
+	err := server.guard.Check(ctx, user.Username, mtdt.ClientIP)
+	if err != nil {
+		var throttled *lockout.ThrottledError
+		if errors.As(err, &throttled) {
+			return throttledError(throttled)
+		}
+		return status.Errorf(codes.Internal, "failed to check failed logins")
+	}
+
+	// the attempt reserved by Check stays counted as a failed login when the password is wrong
+	_, err = util.CheckPassword(password, user.HashedPassword)
+	if err != nil {
+		return status.Errorf(codes.Unauthenticated, "incorrect current password")
+	}
+
+	err = server.guard.Succeed(ctx, user.Username, mtdt.ClientIP)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to reset failed logins")
+	}
+
+	return nil
+}
+
+func validateUpdateUserRequest(req *pb.UpdateUserRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validator.ValidateUsername(req.GetUsername()); err != nil {
+		violations = append(violations, fieldViolation("username", err))
+	}
+
+	if req.FullName != nil {
+		if err := validator.ValidateFullName(req.GetFullName()); err != nil {
+			violations = append(violations, fieldViolation("full_name", err))
+		}
+	}
+
+	if req.Email != nil {
+		if err := validator.ValidateEmail(req.GetEmail()); err != nil {
+			violations = append(violations, fieldViolation("email", err))
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,110 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	"bitbucket.org/jessyw/go_simplebank/lockout"
+	"bitbucket.org/jessyw/go_simplebank/mfa"
+	"bitbucket.org/jessyw/go_simplebank/pb"
+	"bitbucket.org/jessyw/go_simplebank/validator"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) VerifyLoginMFA(ctx context.Context, req *pb.VerifyLoginMFARequest) (*pb.LoginUserResponse, error) {
+	violations := validateVerifyLoginMFARequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	mtdt := server.extractMetadata(ctx)
+	username, err := server.authenticator.VerifyChallenge(ctx, req.GetChallengeToken(), req.GetCode())
+	if err != nil {
+		if errors.Is(err, mfa.ErrInvalidCode) {
+			// a wrong code counts as a failed login of the user, like a wrong password
+			failErr := server.guard.Fail(ctx, username, mtdt.ClientIP)
+			if failErr != nil {
+				return nil, status.Errorf(codes.Internal, "failed to record failed login")
+			}
+		}
+		if errors.Is(err, mfa.ErrChallengeInvalid) || errors.Is(err, mfa.ErrInvalidCode) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "failed to verify login challenge: %s", err)
+	}
+
+	err = server.guard.Succeed(ctx, username, mtdt.ClientIP)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to reset failed logins")
+	}
+
+	user, err := server.store.GetUser(ctx, username)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to find user")
+	}
+
+	return server.createSession(ctx, user)
+}
+
+// verifyStepUp asks the user for a two-factor authentication code when the amount is above the step-up amount.
+// Users who didn't enable two-factor authentication can't move such amounts.
+// Wrong codes count as failed logins, so they are throttled and locked out like passwords.
+func (server *Server) verifyStepUp(ctx context.Context, username string, amount int64, code string) error {
+	if server.config.MFAStepUpAmount <= 0 || amount <= server.config.MFAStepUpAmount {
+		return nil
+	}
+
+	mtdt := server.extractMetadata(ctx)
+	err := server.guard.Check(ctx, username, mtdt.ClientIP)
+	if err != nil {
+		var throttled *lockout.ThrottledError
+		if errors.As(err, &throttled) {
+			return throttledError(throttled)
+		}
+		return status.Errorf(codes.Internal, "failed to check failed logins")
+	}
+
+	err = server.authenticator.Verify(ctx, username, code)
+	if err != nil {
+		switch {
+		case errors.Is(err, mfa.ErrNotEnabled):
+			releaseErr := server.guard.Release(ctx, username, mtdt.ClientIP)
+			if releaseErr != nil {
+				return status.Errorf(codes.Internal, "failed to release login attempt")
+			}
+			return status.Errorf(codes.PermissionDenied, "transfers above %d need two-factor authentication: %s", server.config.MFAStepUpAmount, err)
+		case errors.Is(err, mfa.ErrInvalidCode):
+			// a missing code only tells the client that one is needed, a wrong one keeps the attempt reserved by Check
+			if code == "" {
+				releaseErr := server.guard.Release(ctx, username, mtdt.ClientIP)
+				if releaseErr != nil {
+					return status.Errorf(codes.Internal, "failed to release login attempt")
+				}
+			}
+			return status.Errorf(codes.Unauthenticated, "transfers above %d need a two-factor authentication code: %s", server.config.MFAStepUpAmount, err)
+		default:
+			return status.Errorf(codes.Internal, "failed to verify two-factor authentication code: %s", err)
+		}
+	}
+
+	err = server.guard.Succeed(ctx, username, mtdt.ClientIP)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to reset failed logins")
+	}
+
+	return nil
+}
+
+func validateVerifyLoginMFARequest(req *pb.VerifyLoginMFARequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validator.ValidateString(req.GetChallengeToken(), 1, 100); err != nil {
+		violations = append(violations, fieldViolation("challenge_token", err))
+	}
+
+	if err := validator.ValidateString(req.GetCode(), 1, 20); err != nil {
+		violations = append(violations, fieldViolation("code", err))
+	}
+
+	return violations
+}
@@ -0,0 +1,80 @@
+package gapi
+
+import (
+	"errors"
+	"time"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/event"
+	"bitbucket.org/jessyw/go_simplebank/pb"
+	"bitbucket.org/jessyw/go_simplebank/validator"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// WatchAccount streams the current balance of an account, then every new entry and balance change
+func (server *Server) WatchAccount(req *pb.WatchAccountRequest, stream pb.SimpleBank_WatchAccountServer) error {
+	ctx := stream.Context()
+
+	authPayload, err := server.authorizeUser(ctx)
+	if err != nil {
+		return unauthenticatedError(err)
+	}
+
+	violations := validateWatchAccountRequest(req)
+	if violations != nil {
+		return invalidArgumentError(violations)
+	}
+
+	// subscribe before reading the balance snapshot so no change is missed in between
+	events, cancel := server.broker.Subscribe(req.GetAccountId())
+	defer cancel()
+
+	account, err := server.store.GetAccount(ctx, req.GetAccountId())
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return status.Errorf(codes.NotFound, "account not found")
+		}
+		return status.Errorf(codes.Internal, "failed to find account")
+	}
+
+	err = server.authorizeAccount(ctx, account, authPayload.Username, db.AccountPermissionView)
+	if err != nil {
+		return err
+	}
+
+	err = stream.Send(convertAccountEvent(&event.AccountEvent{
+		Type:      event.TypeBalance,
+		AccountID: account.ID,
+		Balance:   account.Balance,
+		CreatedAt: time.Now(),
+	}))
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-server.shutdown:
+			return status.Errorf(codes.Unavailable, "server is shutting down")
+		case accountEvent, ok := <-events:
+			if !ok {
+				return status.Errorf(codes.Unavailable, "account events stream closed")
+			}
+			if err := stream.Send(convertAccountEvent(accountEvent)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func validateWatchAccountRequest(req *pb.WatchAccountRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validator.ValidateAccountID(req.GetAccountId()); err != nil {
+		violations = append(violations, fieldViolation("account_id", err))
+	}
+
+	return violations
+}
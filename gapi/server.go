@@ -2,33 +2,81 @@ package gapi
 
 import (
 	"fmt"
+	"sync"
 
 	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/event"
+	"bitbucket.org/jessyw/go_simplebank/lockout"
+	"bitbucket.org/jessyw/go_simplebank/mail"
+	"bitbucket.org/jessyw/go_simplebank/mfa"
 	"bitbucket.org/jessyw/go_simplebank/pb"
+	"bitbucket.org/jessyw/go_simplebank/ratelimit"
+	"bitbucket.org/jessyw/go_simplebank/recovery"
+	"bitbucket.org/jessyw/go_simplebank/risk"
 	"bitbucket.org/jessyw/go_simplebank/token"
 	"bitbucket.org/jessyw/go_simplebank/util"
+	"bitbucket.org/jessyw/go_simplebank/validator"
 )
 
 // Server serve gRPC requests for our banking service.
 type Server struct {
 	pb.UnimplementedSimpleBankServer
-	config     util.Config
-	store      db.Store
-	tokenMaker token.Maker
+	config         util.Config
+	store          db.Store
+	tokenMaker     token.Maker
+	broker         event.Broker
+	screener       risk.Screener
+	recoverer      *recovery.Recoverer
+	authenticator  *mfa.Authenticator
+	guard          *lockout.Guard
+	passwordPolicy validator.PasswordPolicy
+	limiter        *ratelimit.Limiter
+	shutdown       chan struct{}
+	shutdownOnce   sync.Once
 }
 
 // NewServer create a new gRPC server.
-func NewServer(config util.Config, store db.Store) (*Server, error) {
+func NewServer(config util.Config, store db.Store, broker event.Broker, screener risk.Screener, mailer mail.Mailer) (*Server, error) {
 	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token maker: %w", err)
 	}
 
+	passwordPolicy, err := validator.NewPasswordPolicy(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create password policy: %w", err)
+	}
+
+	limits, err := ratelimit.LoadLimits(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load rate limits: %w", err)
+	}
+
 	server := &Server{
-		config:     config,
-		store:      store,
-		tokenMaker: tokenMaker,
+		config:        config,
+		store:         store,
+		tokenMaker:    tokenMaker,
+		broker:        broker,
+		screener:      screener,
+		recoverer:     recovery.NewRecoverer(store, mailer, config.PasswordResetTokenDuration, passwordPolicy),
+		authenticator: mfa.NewAuthenticator(store, config.MFAChallengeDuration),
+		guard: lockout.NewGuard(store, lockout.Policy{
+			MaxFailures:   config.LoginMaxFailures,
+			IPMaxFailures: config.LoginIPMaxFailures,
+			Duration:      config.LoginLockoutDuration,
+			BaseDelay:     config.LoginBaseDelay,
+		}),
+		passwordPolicy: passwordPolicy,
+		limiter:        ratelimit.NewLimiter(ratelimit.NewMemoryStore(), limits),
+		shutdown:       make(chan struct{}),
 	}
 
 	return server, nil
 }
+
+// Shutdown ends the account event streams, which would otherwise keep a graceful stop of the gRPC server waiting
+func (server *Server) Shutdown() {
+	server.shutdownOnce.Do(func() {
+		close(server.shutdown)
+	})
+}
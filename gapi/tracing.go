@@ -0,0 +1,21 @@
+package gapi
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// HttpTracer records a span for each request of the HTTP gateway, named after the RPC it calls,
+// in the trace of the traceparent header when the caller sent one.
+// The gateway calls the RPCs in process, without going through the gRPC stats handler.
+func HttpTracer(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "gateway",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			if method, ok := gatewayMethods[r.URL.Path]; ok {
+				return method
+			}
+			return r.Method + " unmatched"
+		}),
+	)
+}
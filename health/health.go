@@ -0,0 +1,147 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"bitbucket.org/jessyw/go_simplebank/pb"
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// checkTimeout bounds how long a readiness check waits for the database
+const checkTimeout = 2 * time.Second
+
+// ErrShuttingDown is returned by the readiness check once the servers are shutting down
+var ErrShuttingDown = errors.New("shutting down")
+
+// DB is what the readiness check needs from the database, *pgxpool.Pool implements it
+type DB interface {
+	Ping(ctx context.Context) error
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Checker tells whether the application is ready to serve requests: the database answers,
+// its schema is migrated to the version the application expects, and the servers aren't shutting down.
+// It reports it on HTTP and through the standard gRPC health service.
+type Checker struct {
+	db              DB
+	expectedVersion uint
+	shuttingDown    atomic.Bool
+	grpcHealth      *health.Server
+}
+
+// NewChecker creates a new Checker, expecting the schema to be migrated to expectedVersion
+func NewChecker(db DB, expectedVersion uint) *Checker {
+	return &Checker{
+		db:              db,
+		expectedVersion: expectedVersion,
+		grpcHealth:      health.NewServer(),
+	}
+}
+
+// Check returns why the application isn't ready, or nil when it is
+func (checker *Checker) Check(ctx context.Context) error {
+	if checker.shuttingDown.Load() {
+		return ErrShuttingDown
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	err := checker.db.Ping(ctx)
+	if err != nil {
+		return fmt.Errorf("database is unreachable: %w", err)
+	}
+
+	var version int64
+	var dirty bool
+	err = checker.db.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err != nil {
+		return fmt.Errorf("cannot read the migration version: %w", err)
+	}
+	if dirty {
+		return fmt.Errorf("migration %d failed, the database schema is dirty", version)
+	}
+	if version < int64(checker.expectedVersion) {
+		return fmt.Errorf("database schema is at version %d, %d expected", version, checker.expectedVersion)
+	}
+
+	return nil
+}
+
+// GrpcHealthServer returns the standard gRPC health service, kept up to date by Run
+func (checker *Checker) GrpcHealthServer() healthpb.HealthServer {
+	return checker.grpcHealth
+}
+
+// Run checks the readiness every interval and reports it through the gRPC health service, until ctx is done
+func (checker *Checker) Run(ctx context.Context, interval time.Duration) {
+	for {
+		checker.update(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (checker *Checker) update(ctx context.Context) {
+	if checker.shuttingDown.Load() {
+		return
+	}
+
+	status := healthpb.HealthCheckResponse_SERVING
+	if err := checker.Check(ctx); err != nil {
+		slog.WarnContext(ctx, "not ready", slog.Any("error", err))
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	// the empty service name is the health of the whole server
+	checker.grpcHealth.SetServingStatus("", status)
+	checker.grpcHealth.SetServingStatus(pb.SimpleBank_ServiceDesc.ServiceName, status)
+}
+
+// Shutdown makes the application not ready for good, so that load balancers stop sending it requests
+// while the servers drain the ones in flight
+func (checker *Checker) Shutdown() {
+	checker.shuttingDown.Store(true)
+	checker.grpcHealth.Shutdown()
+}
+
+type statusResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Healthz answers the liveness probes: the process is up and serving HTTP
+func (checker *Checker) Healthz(w http.ResponseWriter, r *http.Request) {
+	writeStatus(w, http.StatusOK, statusResponse{Status: "ok"})
+}
+
+// Readyz answers the readiness probes with 200 when the application is ready, and 503 with the reason otherwise
+func (checker *Checker) Readyz(w http.ResponseWriter, r *http.Request) {
+	err := checker.Check(r.Context())
+	if err != nil {
+		writeStatus(w, http.StatusServiceUnavailable, statusResponse{Status: "unavailable", Error: err.Error()})
+		return
+	}
+
+	writeStatus(w, http.StatusOK, statusResponse{Status: "ok"})
+}
+
+func writeStatus(w http.ResponseWriter, code int, rsp statusResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(rsp)
+}
@@ -0,0 +1,125 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bitbucket.org/jessyw/go_simplebank/pb"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type fakeRow struct {
+	version int64
+	dirty   bool
+	err     error
+}
+
+func (row fakeRow) Scan(dest ...any) error {
+	if row.err != nil {
+		return row.err
+	}
+	*dest[0].(*int64) = row.version
+	*dest[1].(*bool) = row.dirty
+	return nil
+}
+
+type fakeDB struct {
+	pingErr error
+	row     fakeRow
+}
+
+func (db *fakeDB) Ping(ctx context.Context) error {
+	return db.pingErr
+}
+
+func (db *fakeDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return db.row
+}
+
+func TestCheck(t *testing.T) {
+	testCases := []struct {
+		name  string
+		db    *fakeDB
+		ready bool
+	}{
+		{
+			name:  "Ready",
+			db:    &fakeDB{row: fakeRow{version: 17}},
+			ready: true,
+		},
+		{
+			name:  "NewerSchema",
+			db:    &fakeDB{row: fakeRow{version: 18}},
+			ready: true,
+		},
+		{
+			name: "Unreachable",
+			db:   &fakeDB{pingErr: errors.New("connection refused")},
+		},
+		{
+			name: "NotMigrated",
+			db:   &fakeDB{row: fakeRow{err: errors.New("relation schema_migrations does not exist")}},
+		},
+		{
+			name: "OldSchema",
+			db:   &fakeDB{row: fakeRow{version: 16}},
+		},
+		{
+			name: "Dirty",
+			db:   &fakeDB{row: fakeRow{version: 17, dirty: true}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			checker := NewChecker(tc.db, 17)
+
+			err := checker.Check(context.Background())
+			recorder := httptest.NewRecorder()
+			checker.Readyz(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if tc.ready {
+				require.NoError(t, err)
+				require.Equal(t, http.StatusOK, recorder.Code)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+				require.Contains(t, recorder.Body.String(), err.Error())
+			}
+		})
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	checker := NewChecker(&fakeDB{row: fakeRow{version: 17}}, 17)
+	ctx := context.Background()
+	request := &healthpb.HealthCheckRequest{Service: pb.SimpleBank_ServiceDesc.ServiceName}
+
+	checker.update(ctx)
+	rsp, err := checker.GrpcHealthServer().Check(ctx, request)
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, rsp.Status)
+
+	checker.Shutdown()
+	require.ErrorIs(t, checker.Check(ctx), ErrShuttingDown)
+
+	// the gRPC health service stays not serving, later checks don't bring it back
+	checker.update(ctx)
+	rsp, err = checker.GrpcHealthServer().Check(ctx, request)
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, rsp.Status)
+
+	recorder := httptest.NewRecorder()
+	checker.Readyz(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	// the process is still alive
+	recorder = httptest.NewRecorder()
+	checker.Healthz(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
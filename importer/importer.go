@@ -0,0 +1,140 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+)
+
+const (
+	// RowStatusAccepted is the status of a row recorded as an entry
+	RowStatusAccepted = "accepted"
+	// RowStatusRejected is the status of a row that couldn't be parsed or was already imported
+	RowStatusRejected = "rejected"
+)
+
+var (
+	// ErrInvalidFile is returned when the file can't be parsed at all, such as a CSV file without the required columns
+	ErrInvalidFile = errors.New("invalid file")
+	// ErrCurrencyMismatch is returned when the file is in another currency than the account
+	ErrCurrencyMismatch = errors.New("the file and the account have different currencies")
+)
+
+// RowResult is what happened to a row of the file
+type RowResult struct {
+	Row         int    `json:"row"`
+	ExternalRef string `json:"external_ref,omitempty"`
+	Amount      int64  `json:"amount,omitempty"`
+	Status      string `json:"status"`
+	// Reason explains why the row was rejected
+	Reason  string `json:"reason,omitempty"`
+	EntryID int64  `json:"entry_id,omitempty"`
+}
+
+// Report sums up the import of a file
+type Report struct {
+	AccountID int64  `json:"account_id"`
+	Format    string `json:"format"`
+	Accepted  int    `json:"accepted"`
+	Rejected  int    `json:"rejected"`
+	// Balance is the balance of the account once the file is imported
+	Balance int64       `json:"balance"`
+	Rows    []RowResult `json:"rows"`
+}
+
+// Importer records the transactions of external statements as entries of an account
+type Importer struct {
+	store db.Store
+}
+
+// NewImporter creates a new Importer
+func NewImporter(store db.Store) *Importer {
+	return &Importer{
+		store: store,
+	}
+}
+
+// Import parses the file and records its transactions on the account in a single database transaction.
+// Transactions are de-duplicated by their external reference, so importing the same file twice only records it once.
+// Rows that can't be parsed or were already imported are rejected and reported, the others are accepted.
+func (importer *Importer) Import(ctx context.Context, accountID int64, format string, r io.Reader) (Report, error) {
+	report := Report{
+		AccountID: accountID,
+		Format:    format,
+	}
+
+	account, err := importer.store.GetAccount(ctx, accountID)
+	if err != nil {
+		return report, err
+	}
+	report.Balance = account.Balance
+
+	statement, err := Parse(format, r)
+	if err != nil {
+		if errors.Is(err, ErrUnknownFormat) {
+			return report, err
+		}
+		return report, fmt.Errorf("%w: %s", ErrInvalidFile, err)
+	}
+
+	if statement.Currency != "" && statement.Currency != account.Currency {
+		return report, fmt.Errorf("%w: %s vs %s", ErrCurrencyMismatch, statement.Currency, account.Currency)
+	}
+
+	for _, rowErr := range statement.Errors {
+		report.reject(RowResult{Row: rowErr.Row}, rowErr.Reason)
+	}
+
+	if len(statement.Transactions) > 0 {
+		arg := db.ImportEntriesTxParams{
+			AccountID: accountID,
+			Entries:   make([]db.ImportEntry, len(statement.Transactions)),
+		}
+		for i, transaction := range statement.Transactions {
+			arg.Entries[i] = db.ImportEntry{
+				ExternalRef: transaction.ExternalRef,
+				Amount:      transaction.Amount,
+				PostedAt:    transaction.PostedAt,
+			}
+		}
+
+		result, err := importer.store.ImportEntriesTx(ctx, arg)
+		if err != nil {
+			return report, err
+		}
+		report.Balance = result.Account.Balance
+
+		for i, transaction := range statement.Transactions {
+			row := RowResult{
+				Row:         transaction.Row,
+				ExternalRef: transaction.ExternalRef,
+				Amount:      transaction.Amount,
+			}
+
+			entry := result.Entries[i]
+			if entry.ID == 0 {
+				report.reject(row, "duplicate external reference")
+				continue
+			}
+
+			row.Status = RowStatusAccepted
+			row.EntryID = entry.ID
+			report.Rows = append(report.Rows, row)
+			report.Accepted++
+		}
+	}
+
+	sort.SliceStable(report.Rows, func(i, j int) bool { return report.Rows[i].Row < report.Rows[j].Row })
+	return report, nil
+}
+
+func (report *Report) reject(row RowResult, reason string) {
+	row.Status = RowStatusRejected
+	row.Reason = reason
+	report.Rows = append(report.Rows, row)
+	report.Rejected++
+}
@@ -0,0 +1,122 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "bitbucket.org/jessyw/go_simplebank/db/mock"
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImport(t *testing.T) {
+	account := db.Account{ID: 1, Owner: util.RandomOwner(), Balance: 1_000, Currency: util.USD}
+	file := "date,amount,reference\n2024-01-15,1.00,A\n2024-01-15,oops,B\n2024-01-16,2.00,C\n"
+
+	testCases := []struct {
+		name       string
+		file       string
+		buildStubs func(store *mockdb.MockStore)
+		check      func(t *testing.T, report Report, err error)
+	}{
+		{
+			name: "OK",
+			file: file,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+				arg := db.ImportEntriesTxParams{
+					AccountID: account.ID,
+					Entries: []db.ImportEntry{
+						{ExternalRef: "A", Amount: 100, PostedAt: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+						{ExternalRef: "C", Amount: 200, PostedAt: time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)},
+					},
+				}
+				result := db.ImportEntriesTxResult{
+					Account:    db.Account{ID: account.ID, Balance: account.Balance + 100},
+					Entries:    []db.Entry{{ID: 10}, {}},
+					Duplicates: 1,
+				}
+				store.EXPECT().ImportEntriesTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+			},
+			check: func(t *testing.T, report Report, err error) {
+				require.NoError(t, err)
+				require.Equal(t, 1, report.Accepted)
+				require.Equal(t, 2, report.Rejected)
+				require.Equal(t, account.Balance+100, report.Balance)
+
+				require.Equal(t, []RowResult{
+					{Row: 2, ExternalRef: "A", Amount: 100, Status: RowStatusAccepted, EntryID: 10},
+					{Row: 3, Status: RowStatusRejected, Reason: `invalid amount "oops"`},
+					{Row: 4, ExternalRef: "C", Amount: 200, Status: RowStatusRejected, Reason: "duplicate external reference"},
+				}, report.Rows)
+			},
+		},
+		{
+			name: "NothingValid",
+			file: "date,amount,reference\n2024-01-15,oops,B\n",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ImportEntriesTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			check: func(t *testing.T, report Report, err error) {
+				require.NoError(t, err)
+				require.Zero(t, report.Accepted)
+				require.Equal(t, 1, report.Rejected)
+				require.Equal(t, account.Balance, report.Balance)
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			file: "date,amount,reference,currency\n2024-01-15,1,A,EUR\n",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ImportEntriesTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			check: func(t *testing.T, report Report, err error) {
+				require.ErrorIs(t, err, ErrCurrencyMismatch)
+			},
+		},
+		{
+			name: "AccountNotFound",
+			file: file,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, db.ErrRecordNotFound)
+			},
+			check: func(t *testing.T, report Report, err error) {
+				require.ErrorIs(t, err, db.ErrRecordNotFound)
+			},
+		},
+		{
+			name: "TxError",
+			file: file,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ImportEntriesTx(gomock.Any(), gomock.Any()).Times(1).Return(db.ImportEntriesTxResult{}, sql.ErrConnDone)
+			},
+			check: func(t *testing.T, report Report, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			report, err := NewImporter(store).Import(context.Background(), account.ID, FormatCSV, strings.NewReader(tc.file))
+			tc.check(t, report, err)
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// FormatCSV is a CSV file with a header row and at least the date, amount and reference columns
+	FormatCSV = "csv"
+	// FormatOFX is an OFX bank statement, either the SGML (1.x) or the XML (2.x) flavor
+	FormatOFX = "ofx"
+)
+
+// ErrUnknownFormat is returned when parsing a file in a format that isn't supported
+var ErrUnknownFormat = errors.New("unknown format, supported formats are csv and ofx")
+
+// Transaction is a transaction of a statement
+type Transaction struct {
+	// Row is the line in a CSV file, or the position of the transaction in an OFX file
+	Row         int       `json:"row"`
+	ExternalRef string    `json:"external_ref"`
+	Amount      int64     `json:"amount"`
+	PostedAt    time.Time `json:"posted_at"`
+}
+
+// RowError is a row of a statement that couldn't be parsed
+type RowError struct {
+	Row    int    `json:"row"`
+	Reason string `json:"reason"`
+}
+
+// Statement is the content of an imported file
+type Statement struct {
+	// Currency is empty when the file doesn't say
+	Currency     string
+	Transactions []Transaction
+	Errors       []RowError
+}
+
+// Parse reads a statement in the given format.
+// Rows that can't be parsed are reported in the statement, an error is only returned when the file can't be read at all.
+func Parse(format string, r io.Reader) (Statement, error) {
+	switch strings.ToLower(format) {
+	case FormatCSV:
+		return ParseCSV(r)
+	case FormatOFX:
+		return ParseOFX(r)
+	default:
+		return Statement{}, ErrUnknownFormat
+	}
+}
+
+// ParseCSV reads a CSV statement. The header row names the columns: date (YYYY-MM-DD), amount (in major units,
+// negative for debits) and reference are required, a currency column is optional and the others are ignored.
+func ParseCSV(r io.Reader) (Statement, error) {
+	var statement Statement
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return statement, errors.New("empty file")
+		}
+		return statement, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"date", "amount", "reference"} {
+		if _, ok := columns[name]; !ok {
+			return statement, fmt.Errorf("missing %s column", name)
+		}
+	}
+	currencyColumn, hasCurrency := columns["currency"]
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return statement, err
+			}
+			statement.Errors = append(statement.Errors, RowError{Row: parseErr.Line, Reason: parseErr.Err.Error()})
+			continue
+		}
+		row, _ := reader.FieldPos(0)
+
+		field := func(name string) string {
+			i := columns[name]
+			if i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		if hasCurrency && currencyColumn < len(record) {
+			currency := strings.ToUpper(strings.TrimSpace(record[currencyColumn]))
+			if statement.Currency == "" {
+				statement.Currency = currency
+			} else if currency != statement.Currency {
+				statement.Errors = append(statement.Errors, RowError{Row: row, Reason: fmt.Sprintf("currency %s differs from %s", currency, statement.Currency)})
+				continue
+			}
+		}
+
+		transaction, err := newTransaction(row, field("reference"), field("amount"), field("date"), parseCSVDate)
+		if err != nil {
+			statement.Errors = append(statement.Errors, RowError{Row: row, Reason: err.Error()})
+			continue
+		}
+		statement.Transactions = append(statement.Transactions, transaction)
+	}
+
+	return statement, nil
+}
+
+// ParseOFX reads the transactions (STMTTRN) of an OFX statement, identified by their FITID.
+// Closing tags are optional, as in the SGML flavor of OFX.
+func ParseOFX(r io.Reader) (Statement, error) {
+	var statement Statement
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return statement, err
+	}
+
+	start := bytes.IndexByte(data, '<')
+	if start < 0 || !bytes.Contains(bytes.ToUpper(data), []byte("<OFX>")) {
+		return statement, errors.New("not an OFX file")
+	}
+
+	var fields map[string]string
+	row := 0
+	for _, token := range strings.Split(string(data[start:]), "<")[1:] {
+		end := strings.IndexByte(token, '>')
+		if end < 0 {
+			continue
+		}
+		tag := strings.ToUpper(strings.TrimSpace(token[:end]))
+		value := strings.TrimSpace(token[end+1:])
+
+		switch {
+		case tag == "CURDEF":
+			statement.Currency = strings.ToUpper(value)
+		case tag == "STMTTRN":
+			row++
+			fields = map[string]string{}
+		case tag == "/STMTTRN" && fields != nil:
+			transaction, err := newTransaction(row, fields["FITID"], fields["TRNAMT"], fields["DTPOSTED"], parseOFXDate)
+			if err != nil {
+				statement.Errors = append(statement.Errors, RowError{Row: row, Reason: err.Error()})
+			} else {
+				statement.Transactions = append(statement.Transactions, transaction)
+			}
+			fields = nil
+		case fields != nil && !strings.HasPrefix(tag, "/"):
+			fields[tag] = value
+		}
+	}
+
+	if fields != nil {
+		statement.Errors = append(statement.Errors, RowError{Row: row, Reason: "transaction is not closed"})
+	}
+
+	return statement, nil
+}
+
+func newTransaction(row int, ref string, amount string, date string, parseDate func(string) (time.Time, error)) (Transaction, error) {
+	transaction := Transaction{Row: row, ExternalRef: ref}
+
+	if ref == "" {
+		return transaction, errors.New("missing reference")
+	}
+
+	var err error
+	transaction.Amount, err = parseAmount(amount)
+	if err != nil {
+		return transaction, err
+	}
+
+	transaction.PostedAt, err = parseDate(date)
+	if err != nil {
+		return transaction, fmt.Errorf("invalid date %q", date)
+	}
+
+	return transaction, nil
+}
+
+// parseAmount converts an amount in major units with up to 2 decimals, such as -12.34, to minor units
+func parseAmount(value string) (int64, error) {
+	if value == "" {
+		return 0, errors.New("missing amount")
+	}
+
+	negative := strings.HasPrefix(value, "-")
+	units, cents, hasCents := strings.Cut(strings.TrimLeft(value, "+-"), ".")
+	if len(value)-len(strings.TrimLeft(value, "+-")) > 1 || units == "" || len(cents) > 2 || (hasCents && cents == "") {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+
+	amount, err := strconv.ParseUint(units+(cents + "00")[:2], 10, 63)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	if amount == 0 {
+		return 0, errors.New("amount must not be zero")
+	}
+
+	if negative {
+		return -int64(amount), nil
+	}
+	return int64(amount), nil
+}
+
+func parseCSVDate(value string) (time.Time, error) {
+	return time.Parse("2006-01-02", value)
+}
+
+// parseOFXDate parses dates such as 20240115, 20240115120000 or 20240115120000.000[-5:EST]
+func parseOFXDate(value string) (time.Time, error) {
+	location := time.UTC
+	if i := strings.IndexByte(value, '['); i >= 0 {
+		offset, _, _ := strings.Cut(strings.TrimSuffix(value[i+1:], "]"), ":")
+		hours, err := strconv.ParseFloat(offset, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		location = time.FixedZone("", int(hours*3600))
+		value = value[:i]
+	}
+
+	if i := strings.IndexByte(value, '.'); i >= 0 {
+		value = value[:i]
+	}
+
+	switch len(value) {
+	case 8:
+		return time.ParseInLocation("20060102", value, location)
+	case 14:
+		return time.ParseInLocation("20060102150405", value, location)
+	default:
+		return time.Time{}, errors.New("invalid date")
+	}
+}
@@ -0,0 +1,139 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCSV(t *testing.T) {
+	file := `Date,Amount,Reference,Description,Currency
+2024-01-15,1250.00,REF-1,Salary,USD
+2024-01-16,-12.5,REF-2,Coffee,USD
+2024-01-17,abc,REF-3,Broken,USD
+2024-13-01,10,REF-4,Bad date,USD
+2024-01-18,10,,No reference,USD
+2024-01-19,10,REF-6,Other currency,EUR
+`
+	statement, err := ParseCSV(strings.NewReader(file))
+	require.NoError(t, err)
+	require.Equal(t, "USD", statement.Currency)
+
+	require.Equal(t, []Transaction{
+		{Row: 2, ExternalRef: "REF-1", Amount: 125_000, PostedAt: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{Row: 3, ExternalRef: "REF-2", Amount: -1_250, PostedAt: time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)},
+	}, statement.Transactions)
+
+	require.Len(t, statement.Errors, 4)
+	require.Equal(t, 4, statement.Errors[0].Row)
+	require.Contains(t, statement.Errors[0].Reason, "invalid amount")
+	require.Contains(t, statement.Errors[1].Reason, "invalid date")
+	require.Equal(t, "missing reference", statement.Errors[2].Reason)
+	require.Contains(t, statement.Errors[3].Reason, "currency EUR")
+}
+
+func TestParseCSVMissingColumn(t *testing.T) {
+	_, err := ParseCSV(strings.NewReader("date,amount\n2024-01-15,10\n"))
+	require.EqualError(t, err, "missing reference column")
+
+	_, err = ParseCSV(strings.NewReader(""))
+	require.Error(t, err)
+}
+
+func TestParseOFX(t *testing.T) {
+	sgml := `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>CAD
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20240115120000.000[-5:EST]
+<TRNAMT>100.25
+<FITID>F1
+<NAME>Deposit
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20240116
+<TRNAMT>-3
+<FITID>F2
+</STMTTRN>
+<STMTTRN>
+<DTPOSTED>20240117
+<TRNAMT>-3
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+	statement, err := ParseOFX(strings.NewReader(sgml))
+	require.NoError(t, err)
+	require.Equal(t, "CAD", statement.Currency)
+
+	require.Len(t, statement.Transactions, 2)
+	require.Equal(t, "F1", statement.Transactions[0].ExternalRef)
+	require.Equal(t, int64(10_025), statement.Transactions[0].Amount)
+	require.True(t, time.Date(2024, 1, 15, 17, 0, 0, 0, time.UTC).Equal(statement.Transactions[0].PostedAt))
+	require.Equal(t, Transaction{Row: 2, ExternalRef: "F2", Amount: -300, PostedAt: time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)}, statement.Transactions[1])
+
+	require.Equal(t, []RowError{{Row: 3, Reason: "missing reference"}}, statement.Errors)
+}
+
+func TestParseOFXXML(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220"?>
+<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS><CURDEF>USD</CURDEF><BANKTRANLIST>
+<STMTTRN><DTPOSTED>20240201</DTPOSTED><TRNAMT>7.10</TRNAMT><FITID>X1</FITID></STMTTRN>
+</BANKTRANLIST></STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>`
+
+	statement, err := ParseOFX(strings.NewReader(xml))
+	require.NoError(t, err)
+	require.Equal(t, "USD", statement.Currency)
+	require.Equal(t, []Transaction{{Row: 1, ExternalRef: "X1", Amount: 710, PostedAt: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)}}, statement.Transactions)
+	require.Empty(t, statement.Errors)
+
+	_, err = ParseOFX(strings.NewReader("date,amount,reference\n"))
+	require.Error(t, err)
+}
+
+func TestParseAmount(t *testing.T) {
+	testCases := []struct {
+		value  string
+		amount int64
+		valid  bool
+	}{
+		{"12.34", 1234, true},
+		{"-12.3", -1230, true},
+		{"+5", 500, true},
+		{"0.01", 1, true},
+		{"0", 0, false},
+		{"", 0, false},
+		{"1.234", 0, false},
+		{"1.", 0, false},
+		{".5", 0, false},
+		{"--1", 0, false},
+		{"1,000.00", 0, false},
+		{"1.-5", 0, false},
+	}
+
+	for _, tc := range testCases {
+		amount, err := parseAmount(tc.value)
+		if !tc.valid {
+			require.Error(t, err, tc.value)
+			continue
+		}
+		require.NoError(t, err, tc.value)
+		require.Equal(t, tc.amount, amount, tc.value)
+	}
+}
+
+func TestParseUnknownFormat(t *testing.T) {
+	_, err := Parse("qif", strings.NewReader(""))
+	require.ErrorIs(t, err, ErrUnknownFormat)
+}
@@ -0,0 +1,141 @@
+package interest
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Job accrues the daily interest of the accounts whose product has an interest rate,
+// and posts the interest accrued over a month on its last day
+type Job struct {
+	store db.Store
+}
+
+// NewJob creates a new Job
+func NewJob(store db.Store) *Job {
+	return &Job{
+		store: store,
+	}
+}
+
+// RunResult summarizes what a run accrued and posted
+type RunResult struct {
+	Day time.Time `json:"day"`
+	// Accrued is the number of accounts that accrued interest for the day
+	Accrued int `json:"accrued"`
+	// Skipped is the number of accounts that had already accrued interest for the day
+	Skipped int                  `json:"skipped"`
+	Posted  []db.InterestPosting `json:"posted"`
+}
+
+// Run accrues the interest of a day, the UTC calendar day of the given time.
+// Each account accrues on its balance at the end of the day, so a past day can be run again later.
+// Running a day again doesn't accrue nor post anything twice, it only catches up on what is missing.
+func (job *Job) Run(ctx context.Context, day time.Time) (RunResult, error) {
+	day = StartOfDay(day)
+	dayEnd := day.AddDate(0, 0, 1)
+	result := RunResult{
+		Day:    day,
+		Posted: []db.InterestPosting{},
+	}
+
+	accounts, err := job.store.ListInterestBearingAccounts(ctx, dayEnd)
+	if err != nil {
+		return result, err
+	}
+
+	for _, account := range accounts {
+		created, err := job.store.CreateInterestAccrual(ctx, db.CreateInterestAccrualParams{
+			AccountID:     account.ID,
+			AccrualDate:   pgtype.Date{Time: day, Valid: true},
+			Balance:       account.Balance,
+			AnnualRateBps: account.AnnualRateBps,
+			AmountMicros:  util.DailyInterestMicros(account.Balance, account.AnnualRateBps),
+		})
+		if err != nil {
+			return result, err
+		}
+
+		if created == 0 {
+			result.Skipped++
+		} else {
+			result.Accrued++
+		}
+	}
+
+	if dayEnd.Day() != 1 {
+		return result, nil
+	}
+
+	result.Posted, err = job.post(ctx, time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC))
+	return result, err
+}
+
+// post pays the interest accrued over the month starting at period
+func (job *Job) post(ctx context.Context, period time.Time) ([]db.InterestPosting, error) {
+	postings := []db.InterestPosting{}
+
+	accountIDs, err := job.store.ListUnpostedInterestAccounts(ctx, db.ListUnpostedInterestAccountsParams{
+		PeriodStart: pgtype.Date{Time: period, Valid: true},
+		PeriodEnd:   pgtype.Date{Time: period.AddDate(0, 1, 0), Valid: true},
+	})
+	if err != nil {
+		return postings, err
+	}
+
+	for _, accountID := range accountIDs {
+		result, err := job.store.PostInterestTx(ctx, db.PostInterestTxParams{
+			AccountID: accountID,
+			Period:    period,
+		})
+		if errors.Is(err, db.ErrInterestAlreadyPosted) {
+			continue
+		}
+		if err != nil {
+			return postings, err
+		}
+
+		postings = append(postings, result.Posting)
+	}
+
+	return postings, nil
+}
+
+// Start runs the job for the previous day shortly after every UTC midnight, until the context is done.
+// Days missed while the server is down are not run automatically, run them again through the API.
+func (job *Job) Start(ctx context.Context) error {
+	for {
+		now := time.Now().UTC()
+		next := StartOfDay(now).AddDate(0, 0, 1)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(next.Sub(now)):
+		}
+
+		result, err := job.Run(ctx, next.AddDate(0, 0, -1))
+		if err != nil {
+			slog.ErrorContext(ctx, "interest run failed", slog.String("day", result.Day.Format(time.DateOnly)), slog.Any("error", err))
+			continue
+		}
+		slog.InfoContext(ctx, "interest run done",
+			slog.String("day", result.Day.Format(time.DateOnly)),
+			slog.Int("accrued", result.Accrued),
+			slog.Int("skipped", result.Skipped),
+			slog.Int("posted", len(result.Posted)),
+		)
+	}
+}
+
+// StartOfDay returns midnight UTC of the calendar day of t
+func StartOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
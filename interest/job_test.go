@@ -0,0 +1,175 @@
+package interest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mockdb "bitbucket.org/jessyw/go_simplebank/db/mock"
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobRun(t *testing.T) {
+	account1 := db.ListInterestBearingAccountsRow{ID: 1, Currency: util.USD, AnnualRateBps: 150, Balance: 100_000}
+	account2 := db.ListInterestBearingAccountsRow{ID: 2, Currency: util.EUR, AnnualRateBps: 150, Balance: -10}
+
+	testCases := []struct {
+		name       string
+		day        time.Time
+		buildStubs func(store *mockdb.MockStore)
+		check      func(t *testing.T, result RunResult, err error)
+	}{
+		{
+			name: "MidMonth",
+			day:  time.Date(2024, time.March, 15, 18, 30, 0, 0, time.UTC),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListInterestBearingAccounts(gomock.Any(), gomock.Eq(time.Date(2024, time.March, 16, 0, 0, 0, 0, time.UTC))).
+					Times(1).
+					Return([]db.ListInterestBearingAccountsRow{account1, account2}, nil)
+
+				store.EXPECT().
+					CreateInterestAccrual(gomock.Any(), gomock.Eq(db.CreateInterestAccrualParams{
+						AccountID:     account1.ID,
+						AccrualDate:   pgtype.Date{Time: time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC), Valid: true},
+						Balance:       account1.Balance,
+						AnnualRateBps: account1.AnnualRateBps,
+						AmountMicros:  4_109_589,
+					})).
+					Times(1).
+					Return(int64(1), nil)
+
+				store.EXPECT().
+					CreateInterestAccrual(gomock.Any(), gomock.Eq(db.CreateInterestAccrualParams{
+						AccountID:     account2.ID,
+						AccrualDate:   pgtype.Date{Time: time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC), Valid: true},
+						Balance:       account2.Balance,
+						AnnualRateBps: account2.AnnualRateBps,
+						AmountMicros:  0,
+					})).
+					Times(1).
+					Return(int64(1), nil)
+
+				store.EXPECT().
+					ListUnpostedInterestAccounts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			check: func(t *testing.T, result RunResult, err error) {
+				require.NoError(t, err)
+				require.Equal(t, 2, result.Accrued)
+				require.Zero(t, result.Skipped)
+				require.Empty(t, result.Posted)
+			},
+		},
+		{
+			name: "MonthEnd",
+			day:  time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListInterestBearingAccounts(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.ListInterestBearingAccountsRow{account1}, nil)
+
+				store.EXPECT().
+					CreateInterestAccrual(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(1), nil)
+
+				period := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+				store.EXPECT().
+					ListUnpostedInterestAccounts(gomock.Any(), gomock.Eq(db.ListUnpostedInterestAccountsParams{
+						PeriodStart: pgtype.Date{Time: period, Valid: true},
+						PeriodEnd:   pgtype.Date{Time: time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+					})).
+					Times(1).
+					Return([]int64{account1.ID}, nil)
+
+				store.EXPECT().
+					PostInterestTx(gomock.Any(), gomock.Eq(db.PostInterestTxParams{AccountID: account1.ID, Period: period})).
+					Times(1).
+					Return(db.PostInterestTxResult{Posting: db.InterestPosting{ID: 1, AccountID: account1.ID, Amount: 119}}, nil)
+			},
+			check: func(t *testing.T, result RunResult, err error) {
+				require.NoError(t, err)
+				require.Equal(t, 1, result.Accrued)
+				require.Len(t, result.Posted, 1)
+				require.Equal(t, int64(119), result.Posted[0].Amount)
+			},
+		},
+		{
+			name: "RunAgain",
+			day:  time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListInterestBearingAccounts(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.ListInterestBearingAccountsRow{account1}, nil)
+
+				store.EXPECT().
+					CreateInterestAccrual(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), nil)
+
+				store.EXPECT().
+					ListUnpostedInterestAccounts(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]int64{account1.ID}, nil)
+
+				// posted by a concurrent run in the meantime
+				store.EXPECT().
+					PostInterestTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PostInterestTxResult{}, db.ErrInterestAlreadyPosted)
+			},
+			check: func(t *testing.T, result RunResult, err error) {
+				require.NoError(t, err)
+				require.Zero(t, result.Accrued)
+				require.Equal(t, 1, result.Skipped)
+				require.Empty(t, result.Posted)
+			},
+		},
+		{
+			name: "InternalError",
+			day:  time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListInterestBearingAccounts(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.ListInterestBearingAccountsRow{}, context.DeadlineExceeded)
+
+				store.EXPECT().
+					CreateInterestAccrual(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			check: func(t *testing.T, result RunResult, err error) {
+				require.ErrorIs(t, err, context.DeadlineExceeded)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			result, err := NewJob(store).Run(context.Background(), tc.day)
+			tc.check(t, result, err)
+		})
+	}
+}
+
+func TestStartOfDay(t *testing.T) {
+	cet := time.FixedZone("CET", 60*60)
+
+	day := StartOfDay(time.Date(2024, time.January, 1, 0, 30, 0, 0, cet))
+	require.Equal(t, time.Date(2023, time.December, 31, 0, 0, 0, 0, time.UTC), day)
+}
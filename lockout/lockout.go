@@ -0,0 +1,222 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+)
+
+// Policy decides how failed logins are throttled
+type Policy struct {
+	// MaxFailures is the number of failed logins in a row after which a username is locked out, 0 never locks it
+	MaxFailures int
+	// IPMaxFailures is the same for a client IP. It is usually higher, since many users may share an IP.
+	IPMaxFailures int
+	// Duration is how long a lockout lasts. Failed logins older than that are forgotten.
+	Duration time.Duration
+	// BaseDelay is how long to wait after the second failed login in a row, it doubles with each following one
+	BaseDelay time.Duration
+}
+
+// ThrottledError is returned when logins are refused until RetryAfter has passed
+type ThrottledError struct {
+	RetryAfter time.Duration
+	// Locked tells whether the username or the client IP reached the maximum number of failures,
+	// otherwise it is only waiting for the delay between two attempts
+	Locked bool
+}
+
+func (err *ThrottledError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, try again in %s", err.RetryAfter.Round(time.Second))
+}
+
+// Guard tracks failed logins by username and by client IP, and refuses logins that come too fast after a failure.
+// Usernames that don't exist are tracked like the others, so that the guard reveals nothing about them.
+type Guard struct {
+	store  db.Store
+	policy Policy
+}
+
+// NewGuard creates a new Guard
+func NewGuard(store db.Store, policy Policy) *Guard {
+	return &Guard{
+		store:  store,
+		policy: policy,
+	}
+}
+
+// maxReserveAttempts is how many times Check reads the throttles again after a concurrent login changed them
+const maxReserveAttempts = 5
+
+// errContended is returned when concurrent logins kept changing the throttles while Check tried to reserve an attempt
+var errContended = errors.New("too many concurrent login attempts")
+
+// Check returns a ThrottledError when the username or the client IP can't try to log in yet.
+// Otherwise it reserves the attempt, counting it as a failed login until Succeed or Release gives it back,
+// so that concurrent logins can't all pass the check before any of their failures is recorded.
+// It is called before checking the password, so that throttled attempts don't cost a password hash.
+func (guard *Guard) Check(ctx context.Context, username string, clientIP string) error {
+	keys := guard.keys(username, clientIP)
+
+	for attempt := 1; ; attempt++ {
+		throttles := make([]*db.LoginThrottle, len(keys))
+		var throttled *ThrottledError
+
+		for i, key := range keys {
+			throttle, err := guard.store.GetLoginThrottle(ctx, db.GetLoginThrottleParams{
+				Kind: key.kind,
+				Key:  key.value,
+			})
+			if err != nil {
+				if errors.Is(err, db.ErrRecordNotFound) {
+					continue
+				}
+				return err
+			}
+			throttles[i] = &throttle
+
+			retryAfter, locked := guard.wait(throttle, key.maxFailures, time.Now())
+			if retryAfter > 0 && (throttled == nil || retryAfter > throttled.RetryAfter) {
+				throttled = &ThrottledError{RetryAfter: retryAfter, Locked: locked}
+			}
+		}
+
+		if throttled != nil {
+			return throttled
+		}
+
+		reserved, err := guard.reserve(ctx, keys, throttles)
+		if err != nil || reserved {
+			return err
+		}
+		if attempt == maxReserveAttempts {
+			return errContended
+		}
+	}
+}
+
+// reserve counts an attempt against each key, provided that its throttle is still the one Check read.
+// It returns false, without counting anything, when a concurrent login changed one of them in between.
+func (guard *Guard) reserve(ctx context.Context, keys []throttleKey, throttles []*db.LoginThrottle) (bool, error) {
+	for i, key := range keys {
+		var err error
+		if throttles[i] == nil {
+			_, err = guard.store.CreateLoginThrottle(ctx, db.CreateLoginThrottleParams{
+				Kind: key.kind,
+				Key:  key.value,
+			})
+		} else {
+			_, err = guard.store.ReserveLoginAttempt(ctx, db.ReserveLoginAttemptParams{
+				ResetBefore:  time.Now().Add(-guard.policy.Duration),
+				Kind:         key.kind,
+				Key:          key.value,
+				Failures:     throttles[i].Failures,
+				LastFailedAt: throttles[i].LastFailedAt,
+			})
+		}
+		if err != nil {
+			if errors.Is(err, db.ErrRecordNotFound) {
+				return false, guard.release(ctx, keys[:i])
+			}
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Fail records a failed login of the username from the client IP, for the attempts Check didn't reserve,
+// such as the codes given for a login challenge, which limits its attempts itself
+func (guard *Guard) Fail(ctx context.Context, username string, clientIP string) error {
+	for _, key := range guard.keys(username, clientIP) {
+		_, err := guard.store.RecordLoginFailure(ctx, db.RecordLoginFailureParams{
+			Kind:        key.kind,
+			Key:         key.value,
+			ResetBefore: time.Now().Add(-guard.policy.Duration),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Succeed forgets the failed logins of the username once it logged in, and gives back the attempt reserved
+// for the client IP. The other failures of the client IP are kept, so that logging in to one account
+// doesn't allow guessing the password of others.
+func (guard *Guard) Succeed(ctx context.Context, username string, clientIP string) error {
+	_, err := guard.store.DeleteLoginThrottle(ctx, db.DeleteLoginThrottleParams{
+		Kind: db.LoginThrottleKindUsername,
+		Key:  username,
+	})
+	if err != nil {
+		return err
+	}
+
+	return guard.release(ctx, guard.keys(username, clientIP)[1:])
+}
+
+// Release gives back the attempt reserved by Check when nothing was checked, e.g. when no code was given
+func (guard *Guard) Release(ctx context.Context, username string, clientIP string) error {
+	return guard.release(ctx, guard.keys(username, clientIP))
+}
+
+func (guard *Guard) release(ctx context.Context, keys []throttleKey) error {
+	for _, key := range keys {
+		err := guard.store.ReleaseLoginAttempt(ctx, db.ReleaseLoginAttemptParams{
+			Kind: key.kind,
+			Key:  key.value,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type throttleKey struct {
+	kind        string
+	value       string
+	maxFailures int
+}
+
+func (guard *Guard) keys(username string, clientIP string) []throttleKey {
+	keys := []throttleKey{{kind: db.LoginThrottleKindUsername, value: username, maxFailures: guard.policy.MaxFailures}}
+	if clientIP != "" {
+		keys = append(keys, throttleKey{kind: db.LoginThrottleKindIP, value: clientIP, maxFailures: guard.policy.IPMaxFailures})
+	}
+	return keys
+}
+
+// wait returns how long to wait before the next attempt, and whether it is because of a lockout
+func (guard *Guard) wait(throttle db.LoginThrottle, maxFailures int, now time.Time) (time.Duration, bool) {
+	failures := int(throttle.Failures)
+	if now.Sub(throttle.LastFailedAt) >= guard.policy.Duration {
+		return 0, false
+	}
+
+	if maxFailures > 0 && failures >= maxFailures {
+		return throttle.LastFailedAt.Add(guard.policy.Duration).Sub(now), true
+	}
+
+	delay := guard.delay(failures)
+	return throttle.LastFailedAt.Add(delay).Sub(now), false
+}
+
+// delay is BaseDelay after 2 failures, doubled for each following one and capped at Duration
+func (guard *Guard) delay(failures int) time.Duration {
+	if failures < 2 || guard.policy.BaseDelay <= 0 {
+		return 0
+	}
+
+	delay := guard.policy.BaseDelay
+	for i := 2; i < failures && delay < guard.policy.Duration; i++ {
+		delay *= 2
+	}
+	if delay > guard.policy.Duration {
+		delay = guard.policy.Duration
+	}
+	return delay
+}
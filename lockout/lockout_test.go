@@ -0,0 +1,232 @@
+package lockout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mockdb "bitbucket.org/jessyw/go_simplebank/db/mock"
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+var testPolicy = Policy{
+	MaxFailures:   5,
+	IPMaxFailures: 20,
+	Duration:      15 * time.Minute,
+	BaseDelay:     time.Second,
+}
+
+func TestDelay(t *testing.T) {
+	guard := NewGuard(nil, testPolicy)
+
+	require.Zero(t, guard.delay(0))
+	require.Zero(t, guard.delay(1))
+	require.Equal(t, time.Second, guard.delay(2))
+	require.Equal(t, 2*time.Second, guard.delay(3))
+	require.Equal(t, 4*time.Second, guard.delay(4))
+	require.Equal(t, testPolicy.Duration, guard.delay(100))
+}
+
+func TestCheck(t *testing.T) {
+	username := util.RandomOwner()
+	clientIP := "10.0.0.1"
+
+	testCases := []struct {
+		name       string
+		username   db.LoginThrottle
+		ip         db.LoginThrottle
+		retryAfter time.Duration
+		locked     bool
+	}{
+		{
+			name:     "FirstFailure",
+			username: db.LoginThrottle{Failures: 1, LastFailedAt: time.Now()},
+		},
+		{
+			name:       "Delayed",
+			username:   db.LoginThrottle{Failures: 3, LastFailedAt: time.Now()},
+			retryAfter: 2 * time.Second,
+		},
+		{
+			name:     "DelayPassed",
+			username: db.LoginThrottle{Failures: 3, LastFailedAt: time.Now().Add(-3 * time.Second)},
+		},
+		{
+			name:       "UsernameLocked",
+			username:   db.LoginThrottle{Failures: 5, LastFailedAt: time.Now().Add(-5 * time.Minute)},
+			retryAfter: 10 * time.Minute,
+			locked:     true,
+		},
+		{
+			name:     "LockoutExpired",
+			username: db.LoginThrottle{Failures: 5, LastFailedAt: time.Now().Add(-15 * time.Minute)},
+		},
+		{
+			name:       "IPLocked",
+			username:   db.LoginThrottle{Failures: 1, LastFailedAt: time.Now()},
+			ip:         db.LoginThrottle{Failures: 20, LastFailedAt: time.Now()},
+			retryAfter: 15 * time.Minute,
+			locked:     true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetLoginThrottle(gomock.Any(), gomock.Eq(db.GetLoginThrottleParams{Kind: db.LoginThrottleKindUsername, Key: username})).
+				Times(1).
+				Return(tc.username, nil)
+			ipErr := error(nil)
+			if tc.ip.Failures == 0 {
+				ipErr = db.ErrRecordNotFound
+			}
+			store.EXPECT().
+				GetLoginThrottle(gomock.Any(), gomock.Eq(db.GetLoginThrottleParams{Kind: db.LoginThrottleKindIP, Key: clientIP})).
+				Times(1).
+				Return(tc.ip, ipErr)
+
+			if tc.retryAfter == 0 {
+				// the attempt is reserved against the throttles that were read
+				store.EXPECT().
+					ReserveLoginAttempt(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.ReserveLoginAttemptParams) (db.LoginThrottle, error) {
+						require.Equal(t, db.LoginThrottleKindUsername, arg.Kind)
+						require.Equal(t, tc.username.Failures, arg.Failures)
+						require.Equal(t, tc.username.LastFailedAt, arg.LastFailedAt)
+						require.WithinDuration(t, time.Now().Add(-testPolicy.Duration), arg.ResetBefore, time.Second)
+						return db.LoginThrottle{Kind: arg.Kind, Key: arg.Key, Failures: arg.Failures + 1}, nil
+					})
+				store.EXPECT().
+					CreateLoginThrottle(gomock.Any(), gomock.Eq(db.CreateLoginThrottleParams{Kind: db.LoginThrottleKindIP, Key: clientIP})).
+					Times(1)
+			}
+
+			err := NewGuard(store, testPolicy).Check(context.Background(), username, clientIP)
+			if tc.retryAfter == 0 {
+				require.NoError(t, err)
+				return
+			}
+
+			var throttled *ThrottledError
+			require.ErrorAs(t, err, &throttled)
+			require.InDelta(t, tc.retryAfter, throttled.RetryAfter, float64(time.Second))
+			require.Equal(t, tc.locked, throttled.Locked)
+		})
+	}
+}
+
+func TestCheckConcurrentLogin(t *testing.T) {
+	username := util.RandomOwner()
+	clientIP := "10.0.0.1"
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().GetLoginThrottle(gomock.Any(), gomock.Any()).Times(2).Return(db.LoginThrottle{}, db.ErrRecordNotFound),
+		store.EXPECT().CreateLoginThrottle(gomock.Any(), gomock.Eq(db.CreateLoginThrottleParams{Kind: db.LoginThrottleKindUsername, Key: username})).Times(1),
+		// another login created the throttle of the IP in between, the username reservation is given back
+		store.EXPECT().CreateLoginThrottle(gomock.Any(), gomock.Eq(db.CreateLoginThrottleParams{Kind: db.LoginThrottleKindIP, Key: clientIP})).
+			Times(1).
+			Return(db.LoginThrottle{}, db.ErrRecordNotFound),
+		store.EXPECT().ReleaseLoginAttempt(gomock.Any(), gomock.Eq(db.ReleaseLoginAttemptParams{Kind: db.LoginThrottleKindUsername, Key: username})).Times(1),
+		// and the throttles are read again, now counting the failure of the other login
+		store.EXPECT().GetLoginThrottle(gomock.Any(), gomock.Eq(db.GetLoginThrottleParams{Kind: db.LoginThrottleKindUsername, Key: username})).
+			Times(1).
+			Return(db.LoginThrottle{}, db.ErrRecordNotFound),
+		store.EXPECT().GetLoginThrottle(gomock.Any(), gomock.Eq(db.GetLoginThrottleParams{Kind: db.LoginThrottleKindIP, Key: clientIP})).
+			Times(1).
+			Return(db.LoginThrottle{Failures: 1, LastFailedAt: time.Now()}, nil),
+		store.EXPECT().CreateLoginThrottle(gomock.Any(), gomock.Eq(db.CreateLoginThrottleParams{Kind: db.LoginThrottleKindUsername, Key: username})).Times(1),
+		store.EXPECT().ReserveLoginAttempt(gomock.Any(), gomock.Any()).Times(1),
+	)
+
+	err := NewGuard(store, testPolicy).Check(context.Background(), username, clientIP)
+	require.NoError(t, err)
+}
+
+func TestCheckContended(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// every reservation loses against another login
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetLoginThrottle(gomock.Any(), gomock.Any()).Times(maxReserveAttempts).Return(db.LoginThrottle{}, db.ErrRecordNotFound)
+	store.EXPECT().CreateLoginThrottle(gomock.Any(), gomock.Any()).Times(maxReserveAttempts).Return(db.LoginThrottle{}, db.ErrRecordNotFound)
+
+	err := NewGuard(store, testPolicy).Check(context.Background(), util.RandomOwner(), "")
+	require.ErrorIs(t, err, errContended)
+}
+
+func TestSucceed(t *testing.T) {
+	username := util.RandomOwner()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// the failures of the username are forgotten, only the attempt reserved for the IP is given back
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		DeleteLoginThrottle(gomock.Any(), gomock.Eq(db.DeleteLoginThrottleParams{Kind: db.LoginThrottleKindUsername, Key: username})).
+		Times(1)
+	store.EXPECT().
+		ReleaseLoginAttempt(gomock.Any(), gomock.Eq(db.ReleaseLoginAttemptParams{Kind: db.LoginThrottleKindIP, Key: "10.0.0.1"})).
+		Times(1)
+
+	err := NewGuard(store, testPolicy).Succeed(context.Background(), username, "10.0.0.1")
+	require.NoError(t, err)
+}
+
+func TestRelease(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ReleaseLoginAttempt(gomock.Any(), gomock.Any()).Times(2)
+	store.EXPECT().DeleteLoginThrottle(gomock.Any(), gomock.Any()).Times(0)
+
+	err := NewGuard(store, testPolicy).Release(context.Background(), util.RandomOwner(), "10.0.0.1")
+	require.NoError(t, err)
+}
+
+func TestFail(t *testing.T) {
+	username := util.RandomOwner()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	for _, kind := range []string{db.LoginThrottleKindUsername, db.LoginThrottleKindIP} {
+		store.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, arg db.RecordLoginFailureParams) (db.LoginThrottle, error) {
+				require.Equal(t, kind, arg.Kind)
+				require.WithinDuration(t, time.Now().Add(-testPolicy.Duration), arg.ResetBefore, time.Second)
+				return db.LoginThrottle{Kind: arg.Kind, Key: arg.Key, Failures: 1}, nil
+			})
+	}
+
+	err := NewGuard(store, testPolicy).Fail(context.Background(), username, "10.0.0.1")
+	require.NoError(t, err)
+}
+
+func TestFailWithoutClientIP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(1)
+
+	err := NewGuard(store, testPolicy).Fail(context.Background(), util.RandomOwner(), "")
+	require.NoError(t, err)
+}
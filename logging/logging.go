@@ -0,0 +1,140 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader is the HTTP header and gRPC metadata key carrying the request ID
+const RequestIDHeader = "x-request-id"
+
+// maxRequestIDLength bounds the request IDs accepted from callers, longer ones are replaced
+const maxRequestIDLength = 128
+
+// New creates the logger of the application: text in development, JSON otherwise.
+// Every record logged with a context carries the request ID and the user of the request, and the trace being recorded.
+func New(config util.Config, w io.Writer) (*slog.Logger, error) {
+	var level slog.Level
+	if config.LogLevel != "" {
+		err := level.UnmarshalText([]byte(config.LogLevel))
+		if err != nil {
+			return nil, fmt.Errorf("invalid log level %q: %w", config.LogLevel, err)
+		}
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	if config.Environment == "development" {
+		handler = slog.NewTextHandler(w, options)
+	} else {
+		handler = slog.NewJSONHandler(w, options)
+	}
+
+	return slog.New(contextHandler{handler}), nil
+}
+
+// contextHandler adds the request attributes and the trace found in the context to the records
+type contextHandler struct {
+	slog.Handler
+}
+
+func (handler contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if info := requestInfoFrom(ctx); info != nil {
+		record.AddAttrs(slog.String("request_id", info.id))
+		if user := info.User(); user != "" {
+			record.AddAttrs(slog.String("user", user))
+		}
+	}
+	if ctx != nil {
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+			record.AddAttrs(
+				slog.String("trace_id", spanContext.TraceID().String()),
+				slog.String("span_id", spanContext.SpanID().String()),
+			)
+		}
+	}
+	return handler.Handler.Handle(ctx, record)
+}
+
+func (handler contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{handler.Handler.WithAttrs(attrs)}
+}
+
+func (handler contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{handler.Handler.WithGroup(name)}
+}
+
+type requestInfoKey struct{}
+
+// requestInfo is what the logs know about the request being served.
+// The user is only known once the request is authorized, after the request info is added to the context.
+type requestInfo struct {
+	id   string
+	mu   sync.Mutex
+	user string
+}
+
+func (info *requestInfo) User() string {
+	info.mu.Lock()
+	defer info.mu.Unlock()
+	return info.user
+}
+
+func requestInfoFrom(ctx context.Context) *requestInfo {
+	if ctx == nil {
+		return nil
+	}
+	info, _ := ctx.Value(requestInfoKey{}).(*requestInfo)
+	return info
+}
+
+// NewRequestID returns the request ID given by the caller, or a new one when it is missing or invalid
+func NewRequestID(given string) string {
+	if given != "" && len(given) <= maxRequestIDLength && !strings.ContainsFunc(given, isInvalidRequestIDRune) {
+		return given
+	}
+	return uuid.NewString()
+}
+
+func isInvalidRequestIDRune(r rune) bool {
+	return r < '!' || r > '~'
+}
+
+// WithRequest returns a context of the request with this ID, whose logs carry the ID
+func WithRequest(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, &requestInfo{id: requestID})
+}
+
+// RequestID returns the ID of the request of the context, or an empty string outside of a request
+func RequestID(ctx context.Context) string {
+	if info := requestInfoFrom(ctx); info != nil {
+		return info.id
+	}
+	return ""
+}
+
+// SetUser records the authenticated user of the request of the context, so that its logs carry the username
+func SetUser(ctx context.Context, username string) {
+	if info := requestInfoFrom(ctx); info != nil {
+		info.mu.Lock()
+		info.user = username
+		info.mu.Unlock()
+	}
+}
+
+// User returns the authenticated user of the request of the context, if any
+func User(ctx context.Context) string {
+	if info := requestInfoFrom(ctx); info != nil {
+		return info.User()
+	}
+	return ""
+}
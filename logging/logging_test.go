@@ -0,0 +1,87 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNewRequestID(t *testing.T) {
+	require.Equal(t, "abc-123", NewRequestID("abc-123"))
+
+	for _, given := range []string{"", "with space", "new\nline", strings.Repeat("a", maxRequestIDLength+1)} {
+		requestID := NewRequestID(given)
+		require.NotEqual(t, given, requestID)
+		require.Len(t, requestID, 36)
+	}
+}
+
+func TestLoggerRequestAttributes(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(util.Config{Environment: "production", LogLevel: "info"}, &buf)
+	require.NoError(t, err)
+
+	ctx := WithRequest(context.Background(), "request-id")
+	SetUser(ctx, "user")
+	require.Equal(t, "request-id", RequestID(ctx))
+	require.Equal(t, "user", User(ctx))
+
+	logger.InfoContext(ctx, "message")
+	record := map[string]any{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	require.Equal(t, "message", record["msg"])
+	require.Equal(t, "request-id", record["request_id"])
+	require.Equal(t, "user", record["user"])
+
+	// records logged outside of a request don't have the attributes
+	buf.Reset()
+	logger.Info("message")
+	record = map[string]any{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	require.NotContains(t, record, "request_id")
+	require.NotContains(t, record, "user")
+
+	// records below the level are dropped
+	buf.Reset()
+	logger.DebugContext(ctx, "message")
+	require.Zero(t, buf.Len())
+}
+
+func TestNewLoggerLevel(t *testing.T) {
+	_, err := New(util.Config{LogLevel: "loud"}, &bytes.Buffer{})
+	require.Error(t, err)
+
+	var buf bytes.Buffer
+	logger, err := New(util.Config{Environment: "development", LogLevel: "debug"}, &buf)
+	require.NoError(t, err)
+
+	logger.Debug("message")
+	require.Contains(t, buf.String(), "msg=message")
+}
+
+func TestLoggerTraceAttributes(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(util.Config{}, &buf)
+	require.NoError(t, err)
+
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	require.NoError(t, err)
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	logger.InfoContext(ctx, "message")
+	record := map[string]any{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	require.Equal(t, traceID.String(), record["trace_id"])
+	require.Equal(t, spanID.String(), record["span_id"])
+}
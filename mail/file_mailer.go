@@ -0,0 +1,41 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// FileMailer writes each email to its own file in a directory instead of delivering it, for local development
+type FileMailer struct {
+	dir   string
+	count atomic.Int64
+}
+
+// NewFileMailer creates a new FileMailer writing to dir, which is created when it doesn't exist
+func NewFileMailer(dir string) (*FileMailer, error) {
+	err := os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create mail directory: %w", err)
+	}
+
+	return &FileMailer{
+		dir: dir,
+	}, nil
+}
+
+// Send implements Mailer.
+// Files are named after the time the email was sent so that they sort in order.
+func (mailer *FileMailer) Send(ctx context.Context, email Email) error {
+	now := time.Now().UTC()
+	name := fmt.Sprintf("%s-%d.eml", now.Format("20060102T150405.000000000"), mailer.count.Add(1))
+
+	content := fmt.Sprintf("Date: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n",
+		now.Format(time.RFC1123Z), email.To, email.Subject, email.Body)
+
+	// emails can carry secrets such as reset tokens, only the owner can read them
+	return os.WriteFile(filepath.Join(mailer.dir, name), []byte(content), 0o600)
+}
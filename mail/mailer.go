@@ -0,0 +1,16 @@
+package mail
+
+import "context"
+
+// Email is a plain text email
+type Email struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Mailer delivers emails to users
+type Mailer interface {
+	// Send delivers the email, or returns an error when it can't be delivered
+	Send(ctx context.Context, email Email) error
+}
@@ -0,0 +1,46 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryMailer(t *testing.T) {
+	mailer := NewMemoryMailer()
+	require.Empty(t, mailer.Emails())
+
+	email1 := Email{To: "alice@email.com", Subject: "first", Body: "hello"}
+	email2 := Email{To: "bob@email.com", Subject: "second", Body: "world"}
+	require.NoError(t, mailer.Send(context.Background(), email1))
+	require.NoError(t, mailer.Send(context.Background(), email2))
+
+	require.Equal(t, []Email{email1, email2}, mailer.Emails())
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+
+	mailer, err := NewFileMailer(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, mailer.Send(context.Background(), Email{To: "alice@email.com", Subject: "first", Body: "hello"}))
+	require.NoError(t, mailer.Send(context.Background(), Email{To: "bob@email.com", Subject: "second", Body: "world"}))
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	data, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+	require.Contains(t, string(data), "To: alice@email.com\r\n")
+	require.Contains(t, string(data), "Subject: first\r\n")
+	require.Contains(t, string(data), "\r\n\r\nhello\r\n")
+
+	info, err := files[1].Info()
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
@@ -0,0 +1,34 @@
+package mail
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryMailer keeps the emails in memory instead of delivering them, for tests
+type MemoryMailer struct {
+	mutex  sync.Mutex
+	emails []Email
+}
+
+// NewMemoryMailer creates a new MemoryMailer
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+// Send implements Mailer.
+func (mailer *MemoryMailer) Send(ctx context.Context, email Email) error {
+	mailer.mutex.Lock()
+	defer mailer.mutex.Unlock()
+
+	mailer.emails = append(mailer.emails, email)
+	return nil
+}
+
+// Emails returns the emails sent so far, oldest first
+func (mailer *MemoryMailer) Emails() []Email {
+	mailer.mutex.Lock()
+	defer mailer.mutex.Unlock()
+
+	return append([]Email{}, mailer.emails...)
+}
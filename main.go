@@ -2,67 +2,292 @@ package main
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"bitbucket.org/jessyw/go_simplebank/api"
+	"bitbucket.org/jessyw/go_simplebank/db/migration"
 	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
 	_ "bitbucket.org/jessyw/go_simplebank/doc/statik"
+	"bitbucket.org/jessyw/go_simplebank/event"
 	"bitbucket.org/jessyw/go_simplebank/gapi"
+	"bitbucket.org/jessyw/go_simplebank/health"
+	"bitbucket.org/jessyw/go_simplebank/interest"
+	"bitbucket.org/jessyw/go_simplebank/logging"
+	"bitbucket.org/jessyw/go_simplebank/mail"
+	"bitbucket.org/jessyw/go_simplebank/metrics"
 	"bitbucket.org/jessyw/go_simplebank/pb"
+	"bitbucket.org/jessyw/go_simplebank/risk"
+	"bitbucket.org/jessyw/go_simplebank/tracing"
 	"bitbucket.org/jessyw/go_simplebank/util"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rakyll/statik/fs"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 	"google.golang.org/protobuf/encoding/protojson"
 )
 
+// healthCheckInterval is how often the readiness reported by the gRPC health service is checked
+const healthCheckInterval = 5 * time.Second
+
+// interruptSignals are the signals asking the servers to shut down gracefully
+var interruptSignals = []os.Signal{
+	os.Interrupt,
+	syscall.SIGTERM,
+}
+
 func main() {
 	config, err := util.LoadConfig(".")
 	if err != nil {
-		log.Fatal("cannot load config:", err)
+		fatal("cannot load config", err)
+	}
+
+	logger, err := logging.New(config, os.Stderr)
+	if err != nil {
+		fatal("cannot create logger", err)
+	}
+	slog.SetDefault(logger)
+
+	shutdownTracing, err := tracing.Setup(config)
+	if err != nil {
+		fatal("cannot set up tracing", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	passwordHasher, err := util.NewPasswordHasher(config)
+	if err != nil {
+		fatal("cannot create password hasher", err)
 	}
+	util.SetPasswordHasher(passwordHasher)
 
-	connPool, err := pgxpool.New(context.Background(), config.DBSource)
+	poolConfig, err := pgxpool.ParseConfig(config.DBSource)
 	if err != nil {
-		log.Fatal("cannot connect to db:", err)
+		fatal("cannot parse db source", err)
+	}
+	poolConfig.ConnConfig.Tracer = db.QueryTracer{}
+
+	connPool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+	if err != nil {
+		fatal("cannot connect to db", err)
+	}
+
+	err = metrics.RegisterPool(connPool)
+	if err != nil {
+		fatal("cannot register pool metrics", err)
 	}
 
 	store := db.NewStore(connPool)
-	go runGatewayServer(config, store)
-	runGrpcServer(config, store)
 
+	ctx, stop := signal.NotifyContext(context.Background(), interruptSignals...)
+	defer stop()
+
+	err = newRootCommand(config, connPool, store).ExecuteContext(ctx)
+	connPool.Close()
+	if err != nil {
+		shutdownTracing(context.Background())
+		fatal("command failed", err)
+	}
+}
+
+// migrateUp applies the migrations not applied yet. Replicas starting together take turns thanks to the advisory lock
+// of the migrator, the ones coming after the first find the schema already migrated.
+func migrateUp(dbSource string) error {
+	migrator, err := migration.NewMigrator(dbSource)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	err = migrator.Up()
+	if err != nil {
+		return err
+	}
+
+	version, _, err := migrator.Version()
+	if err != nil {
+		return err
+	}
+	slog.Info("db is migrated", slog.Uint64("version", uint64(version)))
+	return nil
 }
 
-func runGrpcServer(config util.Config, store db.Store) {
-	server, err := gapi.NewServer(config, store)
+// runServers runs the servers and the background workers until ctx is done or one of the servers fails.
+// The servers stop first, letting the requests in flight, transfers included, finish within the shutdown timeout,
+// then the background workers are stopped, so the database is only closed once nothing uses it anymore.
+func runServers(ctx context.Context, config util.Config, connPool *pgxpool.Pool, store db.Store) error {
+	schemaVersion, err := migration.LatestVersion()
+	if err != nil {
+		return fmt.Errorf("cannot read migrations: %w", err)
+	}
+
+	mailer, err := mail.NewFileMailer(config.MailDir)
 	if err != nil {
-		log.Fatal("cannot create server:", err)
+		return fmt.Errorf("cannot create mailer: %w", err)
 	}
 
-	grpcServer := grpc.NewServer()
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	workers, workerCtx := errgroup.WithContext(workerCtx)
+
+	listener := event.NewPGListener(connPool)
+	workers.Go(func() error {
+		return ignoreCanceled(listener.Listen(workerCtx))
+	})
+
+	interestJob := interest.NewJob(store)
+	workers.Go(func() error {
+		return ignoreCanceled(interestJob.Start(workerCtx))
+	})
+
+	checker := health.NewChecker(connPool, schemaVersion)
+	workers.Go(func() error {
+		checker.Run(workerCtx, healthCheckInterval)
+		return nil
+	})
+
+	screener := risk.NewRuleEngine(store)
+
+	ctx, stopServers := context.WithCancel(ctx)
+	defer stopServers()
+	servers, ctx := errgroup.WithContext(ctx)
+
+	// the application isn't ready anymore as soon as the servers start stopping
+	servers.Go(func() error {
+		<-ctx.Done()
+		checker.Shutdown()
+		return nil
+	})
+
+	err = runGrpcServer(ctx, servers, config, store, listener, screener, mailer, checker)
+	if err == nil {
+		err = runGatewayServer(ctx, servers, config, store, listener, screener, mailer, checker)
+	}
+	if err == nil && config.MetricsServerAddress != "" {
+		runMetricsServer(ctx, servers, config)
+	}
+	if err != nil {
+		// stop the server that already started
+		stopServers()
+	}
+
+	if serversErr := servers.Wait(); err == nil {
+		err = serversErr
+	}
+
+	slog.Info("stop background workers")
+	stopWorkers()
+	if workersErr := workers.Wait(); err == nil {
+		err = workersErr
+	}
+
+	return err
+}
+
+// ignoreCanceled hides the error of a worker stopped on purpose
+func ignoreCanceled(err error) error {
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// runGrpcServer starts the gRPC server in the wait group, and stops it gracefully once ctx is done.
+// The calls still running after the shutdown timeout are cut.
+func runGrpcServer(
+	ctx context.Context,
+	waitGroup *errgroup.Group,
+	config util.Config,
+	store db.Store,
+	broker event.Broker,
+	screener risk.Screener,
+	mailer mail.Mailer,
+	checker *health.Checker,
+) error {
+	server, err := gapi.NewServer(config, store, broker, screener, mailer)
+	if err != nil {
+		return fmt.Errorf("cannot create server: %w", err)
+	}
+
+	grpcServer := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(gapi.GrpcLogger, gapi.GrpcMetrics, server.UnaryRateLimitInterceptor),
+		grpc.ChainStreamInterceptor(gapi.StreamGrpcLogger, gapi.StreamGrpcMetrics, server.StreamRateLimitInterceptor),
+	)
 	pb.RegisterSimpleBankServer(grpcServer, server)
+	healthpb.RegisterHealthServer(grpcServer, checker.GrpcHealthServer())
 	reflection.Register(grpcServer)
 
 	listener, err := net.Listen("tcp", config.GRPCServerAddress)
 	if err != nil {
-		log.Fatal("cannot create listener:", err)
+		return fmt.Errorf("cannot create listener: %w", err)
 	}
 
-	log.Printf("start gRPC server at %s", listener.Addr().String())
-	err = grpcServer.Serve(listener)
+	waitGroup.Go(func() error {
+		slog.Info("start gRPC server", slog.String("address", listener.Addr().String()))
+		err := grpcServer.Serve(listener)
+		if err != nil {
+			return fmt.Errorf("gRPC server failed: %w", err)
+		}
+		return nil
+	})
+
+	waitGroup.Go(func() error {
+		<-ctx.Done()
+		slog.Info("graceful shutdown gRPC server")
+
+		server.Shutdown()
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-time.After(config.ShutdownTimeout):
+			slog.Warn("gRPC calls still running after the shutdown timeout are cut")
+			grpcServer.Stop()
+		}
+
+		slog.Info("gRPC server is stopped")
+		return nil
+	})
+
+	return nil
+}
+
+// runGatewayServer starts the HTTP gateway server in the wait group, and shuts it down once ctx is done.
+// The requests still running after the shutdown timeout are cut.
+func runGatewayServer(
+	ctx context.Context,
+	waitGroup *errgroup.Group,
+	config util.Config,
+	store db.Store,
+	broker event.Broker,
+	screener risk.Screener,
+	mailer mail.Mailer,
+	checker *health.Checker,
+) error {
+	server, err := gapi.NewServer(config, store, broker, screener, mailer)
 	if err != nil {
-		log.Fatal("cannot start gRPC server:", err)
+		return fmt.Errorf("cannot create gateway server: %w", err)
 	}
-}
 
-func runGatewayServer(config util.Config, store db.Store) {
-	server, err := gapi.NewServer(config, store)
+	// the routes of the HTTP API which have no RPC, the account event stream among them, are served by gin
+	ginServer, err := api.NewServer(config, store, broker, screener, mailer)
 	if err != nil {
-		log.Fatal("cannot create gateway server:", err)
+		return fmt.Errorf("cannot create HTTP API server: %w", err)
 	}
 
 	jsonOption := runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
@@ -74,46 +299,100 @@ func runGatewayServer(config util.Config, store db.Store) {
 		},
 	})
 
-	grpcMux := runtime.NewServeMux(jsonOption)
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	grpcMux := runtime.NewServeMux(jsonOption, runtime.WithMetadata(gapi.GatewayMetadata))
 
 	err = pb.RegisterSimpleBankHandlerServer(ctx, grpcMux, server)
 	if err != nil {
-		log.Fatal("cannot register handler server:", err)
+		return fmt.Errorf("cannot register handler server: %w", err)
 	}
 
 	mux := http.NewServeMux()
-	mux.Handle("/", grpcMux)
+	mux.Handle("/v1/", gapi.HttpTracer(gapi.HttpLogger(gapi.HttpMetrics(server.RateLimitHandler(grpcMux)))))
+	mux.Handle("/", ginServer.Handler())
+	mux.HandleFunc("/healthz", checker.Healthz)
+	mux.HandleFunc("/readyz", checker.Readyz)
 
 	statikFS, err := fs.New()
 	if err != nil {
-		log.Fatal("cannot create statik fs:", err)
+		return fmt.Errorf("cannot create statik fs: %w", err)
 	}
 
 	swaggerHandler := http.StripPrefix("/swagger/", http.FileServer(statikFS))
 	mux.Handle("/swagger/", swaggerHandler)
 
-	listener, err := net.Listen("tcp", config.HTTPServerAddress)
-	if err != nil {
-		log.Fatal("cannot create listener:", err)
+	httpServer := &http.Server{
+		Handler: mux,
+		Addr:    config.HTTPServerAddress,
 	}
 
-	log.Printf("start HTTP server at %s", listener.Addr().String())
-	err = http.Serve(listener, mux)
-	if err != nil {
-		log.Fatal("cannot start HTTP Gateway Server:", err)
-	}
+	waitGroup.Go(func() error {
+		slog.Info("start HTTP gateway server", slog.String("address", httpServer.Addr))
+		err := httpServer.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("HTTP gateway server failed: %w", err)
+		}
+		return nil
+	})
+
+	waitGroup.Go(func() error {
+		<-ctx.Done()
+		slog.Info("graceful shutdown HTTP gateway server")
+		ginServer.Shutdown()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+		defer cancel()
+
+		err := httpServer.Shutdown(shutdownCtx)
+		if err != nil {
+			slog.Warn("HTTP requests still running after the shutdown timeout are cut", slog.Any("error", err))
+			httpServer.Close()
+		}
+
+		slog.Info("HTTP gateway server is stopped")
+		return nil
+	})
+
+	return nil
 }
 
-func runGinServer(config util.Config, store db.Store) {
-	server, err := api.NewServer(config, store)
-	if err != nil {
-		log.Fatal("cannot create server:", err)
-	}
+// runMetricsServer starts the server of the Prometheus metrics in the wait group, and shuts it down once ctx is done.
+// It listens apart from the HTTP gateway server, so that the metrics are only reachable from the internal network.
+func runMetricsServer(ctx context.Context, waitGroup *errgroup.Group, config util.Config) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
 
-	err = server.Start(config.HTTPServerAddress)
-	if err != nil {
-		log.Fatal("cannot start server:", err)
+	metricsServer := &http.Server{
+		Handler: mux,
+		Addr:    config.MetricsServerAddress,
 	}
+
+	waitGroup.Go(func() error {
+		slog.Info("start metrics server", slog.String("address", metricsServer.Addr))
+		err := metricsServer.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("metrics server failed: %w", err)
+		}
+		return nil
+	})
+
+	waitGroup.Go(func() error {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+		defer cancel()
+
+		err := metricsServer.Shutdown(shutdownCtx)
+		if err != nil {
+			metricsServer.Close()
+		}
+
+		slog.Info("metrics server is stopped")
+		return nil
+	})
+}
+
+// fatal logs the error that keeps the application from running, and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, slog.Any("error", err))
+	os.Exit(1)
 }
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "simplebank"
+
+var (
+	// Requests counts the requests served, by protocol (http or grpc), method and status code
+	Requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "requests_total",
+		Help:      "Number of requests served, by protocol, method and status code.",
+	}, []string{"protocol", "method", "code"})
+
+	// RequestDuration observes how long the requests take to be served, by protocol and method
+	RequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "request_duration_seconds",
+		Help:      "Time taken to serve the requests, by protocol and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"protocol", "method"})
+
+	// TxRetries counts the database transactions run again after Postgres aborted them, by SQLSTATE code
+	TxRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "tx_retries_total",
+		Help:      "Number of database transactions run again after a serialization failure or a deadlock, by SQLSTATE code.",
+	}, []string{"code"})
+
+	// Transfers counts the transfers committed, by currency
+	Transfers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transfers_total",
+		Help:      "Number of transfers committed, by currency.",
+	}, []string{"currency"})
+
+	// TransferVolume sums the amounts of the transfers committed in minor units, by currency
+	TransferVolume = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transfer_volume_total",
+		Help:      "Total amount of the transfers committed in minor units, by currency.",
+	}, []string{"currency"})
+)
+
+func init() {
+	prometheus.MustRegister(Requests, RequestDuration, TxRetries, Transfers, TransferVolume)
+}
+
+// ObserveRequest records a request served in the request counter and latency histogram
+func ObserveRequest(protocol string, method string, code string, duration time.Duration) {
+	Requests.WithLabelValues(protocol, method, code).Inc()
+	RequestDuration.WithLabelValues(protocol, method).Observe(duration.Seconds())
+}
+
+// ObserveTransfer records a committed transfer in the business counters
+func ObserveTransfer(currency string, amount int64) {
+	Transfers.WithLabelValues(currency).Inc()
+	TransferVolume.WithLabelValues(currency).Add(float64(amount))
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
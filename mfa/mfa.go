@@ -0,0 +1,278 @@
+package mfa
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/totp"
+)
+
+const (
+	// Issuer is the name authenticator apps show next to the codes
+	Issuer = "Simple Bank"
+	// RecoveryCodeCount is the number of recovery codes given when two-factor authentication is enabled
+	RecoveryCodeCount = 10
+	// MaxChallengeAttempts is the number of codes that can be tried with a login challenge
+	MaxChallengeAttempts = 5
+
+	challengeTokenBytes = 32
+	recoveryCodeBytes   = 10
+)
+
+var (
+	// ErrNotEnabled is returned when a code is checked for a user who doesn't use two-factor authentication
+	ErrNotEnabled = errors.New("two-factor authentication is not enabled")
+	// ErrAlreadyEnabled is returned when enrolling a user who already uses two-factor authentication
+	ErrAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrInvalidCode is returned when a code is wrong, or was already used
+	ErrInvalidCode = errors.New("invalid two-factor authentication code")
+	// ErrChallengeInvalid is returned when a login challenge is unknown, already used, expired or was tried too many times.
+	// The cases aren't told apart so that the error reveals nothing about the challenges.
+	ErrChallengeInvalid = errors.New("the login challenge is invalid or has expired")
+)
+
+// Enrollment is what a user needs to add the bank to their authenticator app
+type Enrollment struct {
+	Secret string `json:"secret"`
+	// ProvisioningURI is the otpauth URI to show as a QR code
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// Authenticator manages the second factor of users: TOTP codes from an authenticator app, or single-use recovery codes
+type Authenticator struct {
+	store             db.Store
+	challengeDuration time.Duration
+}
+
+// NewAuthenticator creates a new Authenticator whose login challenges are valid for challengeDuration
+func NewAuthenticator(store db.Store, challengeDuration time.Duration) *Authenticator {
+	return &Authenticator{
+		store:             store,
+		challengeDuration: challengeDuration,
+	}
+}
+
+// Enroll generates a new TOTP secret for the user. It only takes effect once confirmed with Enable,
+// enrolling again before that replaces the secret.
+func (authenticator *Authenticator) Enroll(ctx context.Context, username string) (Enrollment, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return Enrollment{}, err
+	}
+
+	_, err = authenticator.store.CreateTotpSecret(ctx, db.CreateTotpSecretParams{
+		Username: username,
+		Secret:   secret,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return Enrollment{}, ErrAlreadyEnabled
+		}
+		return Enrollment{}, err
+	}
+
+	return Enrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(Issuer, username, secret),
+	}, nil
+}
+
+// Enable confirms the enrollment with a first code from the authenticator app and returns the recovery codes,
+// which are only shown this once.
+// It returns db.ErrMFANotPending when the user didn't enroll and ErrAlreadyEnabled when it is already enabled.
+func (authenticator *Authenticator) Enable(ctx context.Context, username string, code string) ([]string, error) {
+	secret, err := authenticator.store.GetTotpSecret(ctx, username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, db.ErrMFANotPending
+		}
+		return nil, err
+	}
+	if secret.EnabledAt.Valid {
+		return nil, ErrAlreadyEnabled
+	}
+
+	step, ok := totp.Validate(secret.Secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+
+	codes := make([]string, RecoveryCodeCount)
+	arg := db.EnableMFATxParams{
+		Username:           username,
+		Step:               step,
+		RecoveryCodeHashes: make([]string, RecoveryCodeCount),
+	}
+	for i := range codes {
+		codes[i], err = newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		arg.RecoveryCodeHashes[i] = hash(normalizeRecoveryCode(codes[i]))
+	}
+
+	_, err = authenticator.store.EnableMFATx(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Enabled tells whether the user has to give a second factor
+func (authenticator *Authenticator) Enabled(ctx context.Context, username string) (bool, error) {
+	secret, err := authenticator.store.GetTotpSecret(ctx, username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return secret.EnabledAt.Valid, nil
+}
+
+// Verify checks a code of the user, either a TOTP code or one of their recovery codes.
+// Each code is only accepted once: TOTP codes can't be replayed and recovery codes are used up.
+// It returns ErrNotEnabled when the user doesn't use two-factor authentication and ErrInvalidCode when the code is wrong.
+func (authenticator *Authenticator) Verify(ctx context.Context, username string, code string) error {
+	secret, err := authenticator.store.GetTotpSecret(ctx, username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return ErrNotEnabled
+		}
+		return err
+	}
+	if !secret.EnabledAt.Valid {
+		return ErrNotEnabled
+	}
+	if strings.TrimSpace(code) == "" {
+		return ErrInvalidCode
+	}
+
+	if step, ok := totp.Validate(secret.Secret, code, time.Now()); ok {
+		_, err = authenticator.store.UseTotpStep(ctx, db.UseTotpStepParams{
+			Step:     step,
+			Username: username,
+		})
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return ErrInvalidCode
+		}
+		return err
+	}
+
+	_, err = authenticator.store.UseRecoveryCode(ctx, db.UseRecoveryCodeParams{
+		Username: username,
+		CodeHash: hash(normalizeRecoveryCode(code)),
+	})
+	if errors.Is(err, db.ErrRecordNotFound) {
+		return ErrInvalidCode
+	}
+	return err
+}
+
+// Disable turns off the two-factor authentication of the user, disabledBy is who asked for it
+func (authenticator *Authenticator) Disable(ctx context.Context, username string, disabledBy string) error {
+	return authenticator.store.DisableMFATx(ctx, db.DisableMFATxParams{
+		Username:   username,
+		DisabledBy: disabledBy,
+	})
+}
+
+// Challenge starts the second step of the login of a user whose password was checked.
+// It returns the token to give back with a code to VerifyChallenge, and when the token expires.
+func (authenticator *Authenticator) Challenge(ctx context.Context, username string) (string, time.Time, error) {
+	token, err := newChallengeToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	challenge, err := authenticator.store.CreateLoginChallenge(ctx, db.CreateLoginChallengeParams{
+		Username:  username,
+		TokenHash: hash(token),
+		ExpiresAt: time.Now().Add(authenticator.challengeDuration),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, challenge.ExpiresAt, nil
+}
+
+// VerifyChallenge checks the code given for a login challenge and returns the user who can be logged in.
+// A challenge can be used once, and only MaxChallengeAttempts codes can be tried with it.
+// It returns ErrChallengeInvalid when the challenge can't be used and ErrInvalidCode when the code is wrong,
+// the user of the challenge is returned along with ErrInvalidCode so that the failure can be counted against them.
+func (authenticator *Authenticator) VerifyChallenge(ctx context.Context, token string, code string) (string, error) {
+	challenge, err := authenticator.store.AttemptLoginChallenge(ctx, hash(token))
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return "", ErrChallengeInvalid
+		}
+		return "", err
+	}
+	if challenge.UsedAt.Valid || time.Now().After(challenge.ExpiresAt) || challenge.Attempts > MaxChallengeAttempts {
+		return "", ErrChallengeInvalid
+	}
+
+	err = authenticator.Verify(ctx, challenge.Username, code)
+	if err != nil {
+		if errors.Is(err, ErrNotEnabled) {
+			// two-factor authentication was turned off since the password was checked
+			return "", ErrChallengeInvalid
+		}
+		if errors.Is(err, ErrInvalidCode) {
+			return challenge.Username, err
+		}
+		return "", err
+	}
+
+	_, err = authenticator.store.UseLoginChallenge(ctx, challenge.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return "", ErrChallengeInvalid
+		}
+		return "", err
+	}
+
+	return challenge.Username, nil
+}
+
+func hash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+func newChallengeToken() (string, error) {
+	data := make([]byte, challengeTokenBytes)
+	_, err := rand.Read(data)
+	if err != nil {
+		return "", fmt.Errorf("cannot generate challenge token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// newRecoveryCode returns a code such as abcde-fghij, easy to write down
+func newRecoveryCode() (string, error) {
+	data := make([]byte, recoveryCodeBytes)
+	_, err := rand.Read(data)
+	if err != nil {
+		return "", fmt.Errorf("cannot generate recovery code: %w", err)
+	}
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(data))
+	return code[:5] + "-" + code[5:10], nil
+}
+
+// normalizeRecoveryCode ignores the case, the dashes and the spaces users may type
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+}
@@ -0,0 +1,276 @@
+package mfa
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mockdb "bitbucket.org/jessyw/go_simplebank/db/mock"
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/totp"
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func enabledSecret(t *testing.T, username string) db.TotpSecret {
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	return db.TotpSecret{
+		Username:  username,
+		Secret:    secret,
+		EnabledAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	}
+}
+
+func TestEnroll(t *testing.T) {
+	username := util.RandomOwner()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().CreateTotpSecret(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, arg db.CreateTotpSecretParams) (db.TotpSecret, error) {
+			require.Equal(t, username, arg.Username)
+			return db.TotpSecret{Username: arg.Username, Secret: arg.Secret}, nil
+		})
+
+	enrollment, err := NewAuthenticator(store, time.Minute).Enroll(context.Background(), username)
+	require.NoError(t, err)
+	require.Len(t, enrollment.Secret, 32)
+	require.Contains(t, enrollment.ProvisioningURI, "secret="+enrollment.Secret)
+}
+
+func TestEnrollAlreadyEnabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().CreateTotpSecret(gomock.Any(), gomock.Any()).Times(1).Return(db.TotpSecret{}, db.ErrRecordNotFound)
+
+	_, err := NewAuthenticator(store, time.Minute).Enroll(context.Background(), util.RandomOwner())
+	require.ErrorIs(t, err, ErrAlreadyEnabled)
+}
+
+func TestEnable(t *testing.T) {
+	secret := enabledSecret(t, util.RandomOwner())
+	secret.EnabledAt = pgtype.Timestamptz{}
+	code, err := totp.Code(secret.Secret, time.Now())
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var hashes []string
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Eq(secret.Username)).Times(1).Return(secret, nil)
+	store.EXPECT().EnableMFATx(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, arg db.EnableMFATxParams) (db.EnableMFATxResult, error) {
+			require.Equal(t, totp.Step(time.Now()), arg.Step)
+			hashes = arg.RecoveryCodeHashes
+			return db.EnableMFATxResult{}, nil
+		})
+
+	// a code from an hour ago is too old
+	oldCode, err := totp.Code(secret.Secret, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+
+	authenticator := NewAuthenticator(store, time.Minute)
+	_, err = authenticator.Enable(context.Background(), secret.Username, oldCode)
+	require.ErrorIs(t, err, ErrInvalidCode)
+
+	store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Eq(secret.Username)).Times(1).Return(secret, nil)
+	codes, err := authenticator.Enable(context.Background(), secret.Username, code)
+	require.NoError(t, err)
+	require.Len(t, codes, RecoveryCodeCount)
+	require.Len(t, hashes, RecoveryCodeCount)
+
+	// only the hashes of the recovery codes are stored
+	for i, code := range codes {
+		require.Regexp(t, "^[a-z2-7]{5}-[a-z2-7]{5}$", code)
+		require.Equal(t, hash(normalizeRecoveryCode(code)), hashes[i])
+	}
+}
+
+func TestVerify(t *testing.T) {
+	secret := enabledSecret(t, util.RandomOwner())
+	code, err := totp.Code(secret.Secret, time.Now())
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name       string
+		code       string
+		buildStubs func(store *mockdb.MockStore)
+		check      func(t *testing.T, err error)
+	}{
+		{
+			name: "TOTPCode",
+			code: code,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Eq(secret.Username)).Times(1).Return(secret, nil)
+				store.EXPECT().UseTotpStep(gomock.Any(), gomock.Eq(db.UseTotpStepParams{
+					Step:     totp.Step(time.Now()),
+					Username: secret.Username,
+				})).Times(1).Return(secret, nil)
+				store.EXPECT().UseRecoveryCode(gomock.Any(), gomock.Any()).Times(0)
+			},
+			check: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "ReplayedTOTPCode",
+			code: code,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Any()).Times(1).Return(secret, nil)
+				store.EXPECT().UseTotpStep(gomock.Any(), gomock.Any()).Times(1).Return(db.TotpSecret{}, db.ErrRecordNotFound)
+			},
+			check: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidCode)
+			},
+		},
+		{
+			name: "RecoveryCode",
+			code: "ABCDE-FGHIJ",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Any()).Times(1).Return(secret, nil)
+				store.EXPECT().UseRecoveryCode(gomock.Any(), gomock.Eq(db.UseRecoveryCodeParams{
+					Username: secret.Username,
+					CodeHash: hash("abcdefghij"),
+				})).Times(1).Return(db.RecoveryCode{}, nil)
+			},
+			check: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "InvalidCode",
+			code: "abcde-fghij",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Any()).Times(1).Return(secret, nil)
+				store.EXPECT().UseRecoveryCode(gomock.Any(), gomock.Any()).Times(1).Return(db.RecoveryCode{}, db.ErrRecordNotFound)
+			},
+			check: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidCode)
+			},
+		},
+		{
+			name: "NotEnabled",
+			code: code,
+			buildStubs: func(store *mockdb.MockStore) {
+				pending := secret
+				pending.EnabledAt = pgtype.Timestamptz{}
+				store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Any()).Times(1).Return(pending, nil)
+				store.EXPECT().UseTotpStep(gomock.Any(), gomock.Any()).Times(0)
+			},
+			check: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrNotEnabled)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			err := NewAuthenticator(store, time.Minute).Verify(context.Background(), secret.Username, tc.code)
+			tc.check(t, err)
+		})
+	}
+}
+
+func TestChallenge(t *testing.T) {
+	secret := enabledSecret(t, util.RandomOwner())
+	code, err := totp.Code(secret.Secret, time.Now())
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var challenge db.LoginChallenge
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().CreateLoginChallenge(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, arg db.CreateLoginChallengeParams) (db.LoginChallenge, error) {
+			require.WithinDuration(t, time.Now().Add(5*time.Minute), arg.ExpiresAt, time.Second)
+			challenge = db.LoginChallenge{ID: 1, Username: arg.Username, TokenHash: arg.TokenHash, ExpiresAt: arg.ExpiresAt}
+			return challenge, nil
+		})
+
+	authenticator := NewAuthenticator(store, 5*time.Minute)
+	token, expiresAt, err := authenticator.Challenge(context.Background(), secret.Username)
+	require.NoError(t, err)
+	require.Equal(t, challenge.ExpiresAt, expiresAt)
+	require.Equal(t, hash(token), challenge.TokenHash)
+
+	attempted := challenge
+	attempted.Attempts = 1
+	store.EXPECT().AttemptLoginChallenge(gomock.Any(), gomock.Eq(challenge.TokenHash)).Times(1).Return(attempted, nil)
+	store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Any()).Times(1).Return(secret, nil)
+	store.EXPECT().UseTotpStep(gomock.Any(), gomock.Any()).Times(1).Return(secret, nil)
+	store.EXPECT().UseLoginChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(attempted, nil)
+
+	username, err := authenticator.VerifyChallenge(context.Background(), token, code)
+	require.NoError(t, err)
+	require.Equal(t, secret.Username, username)
+}
+
+func TestVerifyChallengeInvalidCode(t *testing.T) {
+	secret := enabledSecret(t, util.RandomOwner())
+	challenge := db.LoginChallenge{
+		ID:        1,
+		Username:  secret.Username,
+		Attempts:  1,
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().AttemptLoginChallenge(gomock.Any(), gomock.Any()).Times(1).Return(challenge, nil)
+	store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Eq(secret.Username)).Times(1).Return(secret, nil)
+	store.EXPECT().UseRecoveryCode(gomock.Any(), gomock.Any()).Times(1).Return(db.RecoveryCode{}, db.ErrRecordNotFound)
+	store.EXPECT().UseLoginChallenge(gomock.Any(), gomock.Any()).Times(0)
+
+	// the user of the challenge comes with the error, to count the failure against them
+	username, err := NewAuthenticator(store, time.Minute).VerifyChallenge(context.Background(), "token", "abcde-fghij")
+	require.ErrorIs(t, err, ErrInvalidCode)
+	require.Equal(t, secret.Username, username)
+}
+
+func TestVerifyChallengeInvalid(t *testing.T) {
+	challenge := db.LoginChallenge{
+		ID:        1,
+		Username:  util.RandomOwner(),
+		Attempts:  1,
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+
+	expired := challenge
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+	used := challenge
+	used.UsedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	tooManyAttempts := challenge
+	tooManyAttempts.Attempts = MaxChallengeAttempts + 1
+
+	for _, got := range []db.LoginChallenge{expired, used, tooManyAttempts} {
+		ctrl := gomock.NewController(t)
+
+		store := mockdb.NewMockStore(ctrl)
+		store.EXPECT().AttemptLoginChallenge(gomock.Any(), gomock.Any()).Times(1).Return(got, nil)
+		store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := NewAuthenticator(store, time.Minute).VerifyChallenge(context.Background(), "token", "123456")
+		require.ErrorIs(t, err, ErrChallengeInvalid)
+		ctrl.Finish()
+	}
+}
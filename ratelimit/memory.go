@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// pruneInterval is how often MemoryStore forgets the buckets that are full again
+const pruneInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+// refill adds the tokens earned since the last update
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.updated)
+	if elapsed <= 0 {
+		return
+	}
+
+	b.tokens += float64(elapsed) / float64(b.limit.interval())
+	if b.tokens > float64(b.limit.Requests) {
+		b.tokens = float64(b.limit.Requests)
+	}
+	b.updated = now
+}
+
+// MemoryStore keeps the token buckets in memory, each replica has its own
+type MemoryStore struct {
+	mu         sync.Mutex
+	buckets    map[string]*bucket
+	lastPruned time.Time
+	now        func() time.Time
+}
+
+// NewMemoryStore creates a new MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Take takes a token from the bucket of the key, a new bucket starts full
+func (store *MemoryStore) Take(_ context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := store.now()
+	store.prune(now)
+
+	b, ok := store.buckets[key]
+	if !ok || b.limit != limit {
+		b = &bucket{tokens: float64(limit.Requests), updated: now, limit: limit}
+		store.buckets[key] = b
+	}
+	b.refill(now)
+
+	if b.tokens < 1 {
+		retryAfter := time.Duration((1 - b.tokens) * float64(limit.interval()))
+		return false, retryAfter, nil
+	}
+
+	b.tokens--
+	return true, 0, nil
+}
+
+// prune forgets the buckets that are full again, a new bucket would be the same
+func (store *MemoryStore) prune(now time.Time) {
+	if now.Sub(store.lastPruned) < pruneInterval {
+		return
+	}
+
+	for key, b := range store.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Requests) {
+			delete(store.buckets, key)
+		}
+	}
+	store.lastPruned = now
+}
@@ -0,0 +1,143 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"bitbucket.org/jessyw/go_simplebank/util"
+)
+
+// Budgets group the endpoints sharing a rate limit
+const (
+	// BudgetLogin is for the endpoints reachable without logging in: login, user creation and password resets
+	BudgetLogin = "login"
+	// BudgetTransfer is for the endpoints moving money
+	BudgetTransfer = "transfer"
+	// BudgetRead is for the endpoints only reading data
+	BudgetRead = "read"
+	// BudgetWrite is for the other endpoints changing data
+	BudgetWrite = "write"
+)
+
+// Limit is a token bucket: it holds up to Requests tokens, refilled at the rate of Requests per Period,
+// and each request takes one
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseLimit parses a limit written as requests/period, such as 10/1m. An empty string is no limit.
+func ParseLimit(value string) (Limit, error) {
+	if value == "" {
+		return Limit{}, nil
+	}
+
+	requests, period, ok := strings.Cut(value, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, must be requests/period such as 10/1m", value)
+	}
+
+	var limit Limit
+	var err error
+	limit.Requests, err = strconv.Atoi(requests)
+	if err != nil || limit.Requests <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, requests must be a positive integer", value)
+	}
+	limit.Period, err = time.ParseDuration(period)
+	if err != nil || limit.Period <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, period must be a positive duration", value)
+	}
+
+	return limit, nil
+}
+
+// Unlimited tells whether the limit lets every request through
+func (limit Limit) Unlimited() bool {
+	return limit.Requests <= 0
+}
+
+// interval is how long it takes to refill one token
+func (limit Limit) interval() time.Duration {
+	return limit.Period / time.Duration(limit.Requests)
+}
+
+// Store keeps the token buckets. MemoryStore keeps them in the process,
+// a store shared by all the replicas, such as Redis, makes the limits global.
+type Store interface {
+	// Take takes a token from the bucket of the key, and returns how long to wait for one when it is empty
+	Take(ctx context.Context, key string, limit Limit) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// LimitedError is returned when a request is over its budget, it can be retried after RetryAfter
+type LimitedError struct {
+	Budget     string
+	RetryAfter time.Duration
+}
+
+func (err *LimitedError) Error() string {
+	return fmt.Sprintf("too many requests, try again in %s", err.RetryAfter.Round(time.Second))
+}
+
+// Limiter takes each request from the token bucket of its budget and caller
+type Limiter struct {
+	store  Store
+	limits map[string]Limit
+}
+
+// NewLimiter creates a new Limiter, budgets without a limit are unlimited
+func NewLimiter(store Store, limits map[string]Limit) *Limiter {
+	return &Limiter{
+		store:  store,
+		limits: limits,
+	}
+}
+
+// LoadLimits reads the limit of each budget from the configuration
+func LoadLimits(config util.Config) (map[string]Limit, error) {
+	limits := make(map[string]Limit)
+	for budget, value := range map[string]string{
+		BudgetLogin:    config.RateLimitLogin,
+		BudgetTransfer: config.RateLimitTransfer,
+		BudgetRead:     config.RateLimitRead,
+		BudgetWrite:    config.RateLimitWrite,
+	} {
+		limit, err := ParseLimit(value)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load %s rate limit: %w", budget, err)
+		}
+		limits[budget] = limit
+	}
+
+	return limits, nil
+}
+
+// UserKey is the key of the requests of an authenticated user
+func UserKey(username string) string {
+	return "user:" + username
+}
+
+// IPKey is the key of the requests of an anonymous client
+func IPKey(clientIP string) string {
+	return "ip:" + clientIP
+}
+
+// Allow takes a request of the key from the budget, and returns a LimitedError when the budget is spent
+func (limiter *Limiter) Allow(ctx context.Context, budget string, key string) error {
+	limit := limiter.limits[budget]
+	if limit.Unlimited() {
+		return nil
+	}
+
+	allowed, retryAfter, err := limiter.store.Take(ctx, budget+":"+key, limit)
+	if err != nil {
+		return fmt.Errorf("cannot check rate limit: %w", err)
+	}
+	if !allowed {
+		return &LimitedError{Budget: budget, RetryAfter: retryAfter}
+	}
+
+	return nil
+}
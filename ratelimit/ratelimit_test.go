@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("10/1m")
+	require.NoError(t, err)
+	require.Equal(t, Limit{Requests: 10, Period: time.Minute}, limit)
+	require.False(t, limit.Unlimited())
+
+	limit, err = ParseLimit("")
+	require.NoError(t, err)
+	require.True(t, limit.Unlimited())
+
+	for _, value := range []string{"10", "0/1m", "-1/1m", "ten/1m", "10/forever", "10/0s"} {
+		_, err = ParseLimit(value)
+		require.Error(t, err, value)
+	}
+}
+
+func TestLoadLimits(t *testing.T) {
+	limits, err := LoadLimits(util.Config{RateLimitLogin: "5/1m", RateLimitRead: "100/10s"})
+	require.NoError(t, err)
+	require.Equal(t, Limit{Requests: 5, Period: time.Minute}, limits[BudgetLogin])
+	require.Equal(t, Limit{Requests: 100, Period: 10 * time.Second}, limits[BudgetRead])
+	require.True(t, limits[BudgetTransfer].Unlimited())
+
+	_, err = LoadLimits(util.Config{RateLimitWrite: "fast"})
+	require.Error(t, err)
+}
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	limit := Limit{Requests: 3, Period: 3 * time.Second}
+	ctx := context.Background()
+
+	// a new bucket starts full
+	for i := 0; i < 3; i++ {
+		allowed, _, err := store.Take(ctx, "key", limit)
+		require.NoError(t, err)
+		require.True(t, allowed)
+	}
+
+	allowed, retryAfter, err := store.Take(ctx, "key", limit)
+	require.NoError(t, err)
+	require.False(t, allowed)
+	require.Equal(t, time.Second, retryAfter)
+
+	// other keys have their own bucket
+	allowed, _, err = store.Take(ctx, "other", limit)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	// a token is refilled every second
+	now = now.Add(500 * time.Millisecond)
+	allowed, retryAfter, err = store.Take(ctx, "key", limit)
+	require.NoError(t, err)
+	require.False(t, allowed)
+	require.Equal(t, 500*time.Millisecond, retryAfter)
+
+	now = now.Add(500 * time.Millisecond)
+	allowed, _, err = store.Take(ctx, "key", limit)
+	require.NoError(t, err)
+	require.True(t, allowed)
+}
+
+func TestMemoryStorePrune(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	limit := Limit{Requests: 1, Period: time.Second}
+	_, _, err := store.Take(context.Background(), "key", limit)
+	require.NoError(t, err)
+	require.Len(t, store.buckets, 1)
+
+	now = now.Add(pruneInterval)
+	_, _, err = store.Take(context.Background(), "other", limit)
+	require.NoError(t, err)
+	require.Len(t, store.buckets, 1)
+	require.Contains(t, store.buckets, "other")
+}
+
+func TestLimiter(t *testing.T) {
+	limiter := NewLimiter(NewMemoryStore(), map[string]Limit{
+		BudgetLogin: {Requests: 1, Period: time.Minute},
+	})
+	ctx := context.Background()
+
+	require.NoError(t, limiter.Allow(ctx, BudgetLogin, IPKey("10.0.0.1")))
+
+	err := limiter.Allow(ctx, BudgetLogin, IPKey("10.0.0.1"))
+	var limited *LimitedError
+	require.ErrorAs(t, err, &limited)
+	require.Equal(t, BudgetLogin, limited.Budget)
+	require.Equal(t, time.Minute, limited.RetryAfter.Round(time.Second))
+
+	// budgets and keys are counted apart
+	require.NoError(t, limiter.Allow(ctx, BudgetLogin, IPKey("10.0.0.2")))
+	require.NoError(t, limiter.Allow(ctx, BudgetLogin, UserKey("10.0.0.1")))
+
+	// budgets without a limit are unlimited
+	for i := 0; i < 10; i++ {
+		require.NoError(t, limiter.Allow(ctx, BudgetRead, IPKey("10.0.0.1")))
+	}
+}
@@ -0,0 +1,125 @@
+package recovery
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/mail"
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"bitbucket.org/jessyw/go_simplebank/validator"
+)
+
+// tokenBytes is the number of random bytes of a reset token
+const tokenBytes = 32
+
+// Recoverer lets users who lost their password set a new one with a single-use token sent by email
+type Recoverer struct {
+	store         db.Store
+	mailer        mail.Mailer
+	tokenDuration time.Duration
+	policy        validator.PasswordPolicy
+}
+
+// NewRecoverer creates a new Recoverer whose tokens are valid for tokenDuration, new passwords must follow the policy
+func NewRecoverer(store db.Store, mailer mail.Mailer, tokenDuration time.Duration, policy validator.PasswordPolicy) *Recoverer {
+	return &Recoverer{
+		store:         store,
+		mailer:        mailer,
+		tokenDuration: tokenDuration,
+		policy:        policy,
+	}
+}
+
+// RequestPasswordReset emails a reset token to the user with this email.
+// Nothing is sent when no user has the email, and no error is returned either, so that callers can't tell
+// which emails are registered.
+func (recoverer *Recoverer) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := recoverer.store.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.ErasedAt.Valid {
+		return nil
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+
+	reset, err := recoverer.store.CreatePasswordReset(ctx, db.CreatePasswordResetParams{
+		Username:  user.Username,
+		TokenHash: HashToken(token),
+		ExpiresAt: time.Now().Add(recoverer.tokenDuration),
+	})
+	if err != nil {
+		return err
+	}
+
+	return recoverer.mailer.Send(ctx, mail.Email{
+		To:      user.Email,
+		Subject: "Reset your Simple Bank password",
+		Body: fmt.Sprintf("Hello %s,\n\n"+
+			"Use this token to choose a new password, it can be used once until %s:\n\n%s\n\n"+
+			"If you didn't ask to reset your password, you can ignore this email.\n",
+			user.FullName, reset.ExpiresAt.UTC().Format(time.RFC1123), token),
+	})
+}
+
+// ResetPassword sets the new password of the user the token was sent to, and blocks all their sessions.
+// It returns db.ErrPasswordResetInvalid when the token is unknown, already used or expired,
+// and a *validator.PasswordError when the password doesn't follow the policy.
+func (recoverer *Recoverer) ResetPassword(ctx context.Context, token string, password string) (db.ResetPasswordTxResult, error) {
+	reset, err := recoverer.store.GetPasswordReset(ctx, HashToken(token))
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return db.ResetPasswordTxResult{}, db.ErrPasswordResetInvalid
+		}
+		return db.ResetPasswordTxResult{}, err
+	}
+
+	user, err := recoverer.store.GetUser(ctx, reset.Username)
+	if err != nil {
+		return db.ResetPasswordTxResult{}, err
+	}
+
+	err = recoverer.policy.Validate(password, user.Username, user.Email)
+	if err != nil {
+		return db.ResetPasswordTxResult{}, err
+	}
+
+	hashedPassword, err := util.HashPassword(password)
+	if err != nil {
+		return db.ResetPasswordTxResult{}, err
+	}
+
+	return recoverer.store.ResetPasswordTx(ctx, db.ResetPasswordTxParams{
+		TokenHash:      HashToken(token),
+		HashedPassword: hashedPassword,
+	})
+}
+
+// HashToken returns the hash a reset token is stored as
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newToken() (string, error) {
+	data := make([]byte, tokenBytes)
+	_, err := rand.Read(data)
+	if err != nil {
+		return "", fmt.Errorf("cannot generate reset token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
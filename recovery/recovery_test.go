@@ -0,0 +1,172 @@
+package recovery
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "bitbucket.org/jessyw/go_simplebank/db/mock"
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/mail"
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"bitbucket.org/jessyw/go_simplebank/validator"
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+var testPolicy = validator.PasswordPolicy{MinLength: 8, MaxLength: 100, MinCharacterClasses: 3}
+
+func randomUser() db.User {
+	return db.User{
+		Username: util.RandomOwner(),
+		FullName: util.RandomOwner(),
+		Email:    util.RandomEmail(),
+	}
+}
+
+func TestRequestPasswordReset(t *testing.T) {
+	user := randomUser()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var tokenHash string
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(user, nil)
+	store.EXPECT().CreatePasswordReset(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, arg db.CreatePasswordResetParams) (db.PasswordReset, error) {
+			require.Equal(t, user.Username, arg.Username)
+			require.WithinDuration(t, time.Now().Add(30*time.Minute), arg.ExpiresAt, time.Second)
+			tokenHash = arg.TokenHash
+			return db.PasswordReset{ID: 1, Username: arg.Username, TokenHash: arg.TokenHash, ExpiresAt: arg.ExpiresAt}, nil
+		})
+
+	mailer := mail.NewMemoryMailer()
+	recoverer := NewRecoverer(store, mailer, 30*time.Minute, testPolicy)
+
+	err := recoverer.RequestPasswordReset(context.Background(), user.Email)
+	require.NoError(t, err)
+
+	emails := mailer.Emails()
+	require.Len(t, emails, 1)
+	require.Equal(t, user.Email, emails[0].To)
+
+	// the email carries the token, only its hash is stored
+	lines := strings.Split(emails[0].Body, "\n")
+	token := lines[4]
+	require.Len(t, token, 43)
+	require.NotContains(t, emails[0].Body, tokenHash)
+	require.Equal(t, HashToken(token), tokenHash)
+}
+
+func TestRequestPasswordResetUnknownEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, db.ErrRecordNotFound)
+	store.EXPECT().CreatePasswordReset(gomock.Any(), gomock.Any()).Times(0)
+
+	mailer := mail.NewMemoryMailer()
+	err := NewRecoverer(store, mailer, time.Minute, testPolicy).RequestPasswordReset(context.Background(), util.RandomEmail())
+	require.NoError(t, err)
+	require.Empty(t, mailer.Emails())
+}
+
+func TestRequestPasswordResetErasedUser(t *testing.T) {
+	user := randomUser()
+	user.ErasedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+	store.EXPECT().CreatePasswordReset(gomock.Any(), gomock.Any()).Times(0)
+
+	mailer := mail.NewMemoryMailer()
+	err := NewRecoverer(store, mailer, time.Minute, testPolicy).RequestPasswordReset(context.Background(), user.Email)
+	require.NoError(t, err)
+	require.Empty(t, mailer.Emails())
+}
+
+func TestRequestPasswordResetStoreError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrConnDone)
+
+	err := NewRecoverer(store, mail.NewMemoryMailer(), time.Minute, testPolicy).RequestPasswordReset(context.Background(), util.RandomEmail())
+	require.ErrorIs(t, err, sql.ErrConnDone)
+}
+
+func TestResetPassword(t *testing.T) {
+	user := randomUser()
+	token := "some-token"
+	password := util.RandomPassword()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetPasswordReset(gomock.Any(), gomock.Eq(HashToken(token))).Times(1).
+		Return(db.PasswordReset{ID: 1, Username: user.Username, TokenHash: HashToken(token)}, nil)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+	store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, arg db.ResetPasswordTxParams) (db.ResetPasswordTxResult, error) {
+			require.Equal(t, HashToken(token), arg.TokenHash)
+			_, err := util.CheckPassword(password, arg.HashedPassword)
+			require.NoError(t, err)
+			return db.ResetPasswordTxResult{User: user, BlockedSessions: 1}, nil
+		})
+
+	result, err := NewRecoverer(store, mail.NewMemoryMailer(), time.Minute, testPolicy).ResetPassword(context.Background(), token, password)
+	require.NoError(t, err)
+	require.Equal(t, user, result.User)
+}
+
+func TestResetPasswordInvalidToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetPasswordReset(gomock.Any(), gomock.Any()).Times(1).Return(db.PasswordReset{}, db.ErrRecordNotFound)
+	store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := NewRecoverer(store, mail.NewMemoryMailer(), time.Minute, testPolicy).ResetPassword(context.Background(), "unknown", util.RandomPassword())
+	require.ErrorIs(t, err, db.ErrPasswordResetInvalid)
+}
+
+func TestResetPasswordWeakPassword(t *testing.T) {
+	user := randomUser()
+	token := "some-token"
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetPasswordReset(gomock.Any(), gomock.Any()).Times(1).
+		Return(db.PasswordReset{ID: 1, Username: user.Username, TokenHash: HashToken(token)}, nil)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+	store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := NewRecoverer(store, mail.NewMemoryMailer(), time.Minute, testPolicy).ResetPassword(context.Background(), token, "Aa1!"+user.Username)
+	var passwordErr *validator.PasswordError
+	require.ErrorAs(t, err, &passwordErr)
+}
+
+func TestHashToken(t *testing.T) {
+	token1, err := newToken()
+	require.NoError(t, err)
+	token2, err := newToken()
+	require.NoError(t, err)
+
+	require.NotEqual(t, token1, token2)
+	require.Equal(t, HashToken(token1), HashToken(token1))
+	require.NotEqual(t, HashToken(token1), HashToken(token2))
+	require.Len(t, HashToken(token1), 64)
+}
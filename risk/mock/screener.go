@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: bitbucket.org/jessyw/go_simplebank/risk (interfaces: Screener)
+
+// Package mockrisk is a generated GoMock package.
+package mockrisk
+
+import (
+	context "context"
+	reflect "reflect"
+
+	risk "bitbucket.org/jessyw/go_simplebank/risk"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockScreener is a mock of Screener interface.
+type MockScreener struct {
+	ctrl     *gomock.Controller
+	recorder *MockScreenerMockRecorder
+}
+
+// MockScreenerMockRecorder is the mock recorder for MockScreener.
+type MockScreenerMockRecorder struct {
+	mock *MockScreener
+}
+
+// NewMockScreener creates a new mock instance.
+func NewMockScreener(ctrl *gomock.Controller) *MockScreener {
+	mock := &MockScreener{ctrl: ctrl}
+	mock.recorder = &MockScreenerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockScreener) EXPECT() *MockScreenerMockRecorder {
+	return m.recorder
+}
+
+// Screen mocks base method.
+func (m *MockScreener) Screen(arg0 context.Context, arg1 risk.Transfer) (risk.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Screen", arg0, arg1)
+	ret0, _ := ret[0].(risk.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Screen indicates an expected call of Screen.
+func (mr *MockScreenerMockRecorder) Screen(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Screen", reflect.TypeOf((*MockScreener)(nil).Screen), arg0, arg1)
+}
@@ -0,0 +1,64 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+)
+
+// Verdict is the decision of a single rule
+type Verdict struct {
+	Decision Decision
+	Reason   string
+}
+
+// Rule is a single fraud screening check
+type Rule interface {
+	Name() string
+	Evaluate(ctx context.Context, store db.Querier, transfer Transfer) (Verdict, error)
+}
+
+// RuleEngine screens transfers against a set of rules, the strictest verdict wins
+type RuleEngine struct {
+	store db.Querier
+	rules []Rule
+}
+
+// NewRuleEngine creates a screener running the given rules, or the default rules if none are given
+func NewRuleEngine(store db.Querier, rules ...Rule) Screener {
+	if len(rules) == 0 {
+		rules = DefaultRules()
+	}
+
+	return &RuleEngine{
+		store: store,
+		rules: rules,
+	}
+}
+
+// Screen evaluates every rule and returns the strictest decision along with the reasons of every rule that didn't allow the transfer
+func (engine *RuleEngine) Screen(ctx context.Context, transfer Transfer) (Result, error) {
+	result := Result{
+		Decision: DecisionAllow,
+		Reasons:  []string{},
+	}
+
+	for _, rule := range engine.rules {
+		verdict, err := rule.Evaluate(ctx, engine.store, transfer)
+		if err != nil {
+			return result, fmt.Errorf("risk rule %s: %w", rule.Name(), err)
+		}
+
+		if verdict.Decision == DecisionAllow {
+			continue
+		}
+
+		result.Reasons = append(result.Reasons, verdict.Reason)
+		if verdict.Decision.severity() > result.Decision.severity() {
+			result.Decision = verdict.Decision
+		}
+	}
+
+	return result, nil
+}
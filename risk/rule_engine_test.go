@@ -0,0 +1,83 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	mockdb "bitbucket.org/jessyw/go_simplebank/db/mock"
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+type staticRule struct {
+	verdict Verdict
+	err     error
+}
+
+func (rule staticRule) Name() string {
+	return "static"
+}
+
+func (rule staticRule) Evaluate(ctx context.Context, store db.Querier, transfer Transfer) (Verdict, error) {
+	return rule.verdict, rule.err
+}
+
+func TestRuleEngine(t *testing.T) {
+	allow := staticRule{verdict: Verdict{Decision: DecisionAllow}}
+	review := staticRule{verdict: Verdict{Decision: DecisionReview, Reason: "review"}}
+	deny := staticRule{verdict: Verdict{Decision: DecisionDeny, Reason: "deny"}}
+
+	testCases := []struct {
+		name     string
+		rules    []Rule
+		decision Decision
+		reasons  []string
+	}{
+		{
+			name:     "Allow",
+			rules:    []Rule{allow, allow},
+			decision: DecisionAllow,
+			reasons:  []string{},
+		},
+		{
+			name:     "Review",
+			rules:    []Rule{allow, review},
+			decision: DecisionReview,
+			reasons:  []string{"review"},
+		},
+		{
+			name:     "StrictestWins",
+			rules:    []Rule{deny, review, allow},
+			decision: DecisionDeny,
+			reasons:  []string{"deny", "review"},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			engine := NewRuleEngine(mockdb.NewMockStore(ctrl), tc.rules...)
+			result, err := engine.Screen(context.Background(), Transfer{})
+			require.NoError(t, err)
+			require.Equal(t, tc.decision, result.Decision)
+			require.Equal(t, tc.reasons, result.Reasons)
+		})
+	}
+}
+
+func TestRuleEngineError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ruleErr := errors.New("boom")
+	engine := NewRuleEngine(mockdb.NewMockStore(ctrl), staticRule{err: ruleErr})
+
+	_, err := engine.Screen(context.Background(), Transfer{})
+	require.ErrorIs(t, err, ruleErr)
+}
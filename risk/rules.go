@@ -0,0 +1,161 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+)
+
+// DefaultRules returns the rules used when a RuleEngine is created without any
+func DefaultRules() []Rule {
+	return []Rule{
+		&NewDeviceRule{
+			Grace: 24 * time.Hour,
+		},
+		&UnusualAmountRule{
+			Lookback:   90 * 24 * time.Hour,
+			MinHistory: 5,
+			Factor:     5,
+		},
+		&FanOutRule{
+			Window:    time.Hour,
+			ReviewAt:  3,
+			DenyAbove: 5,
+		},
+	}
+}
+
+// NewDeviceRule holds transfers requested from a client IP, the host without its port, and user agent
+// that the user never logged in from before the grace period
+type NewDeviceRule struct {
+	Grace time.Duration
+}
+
+func (rule *NewDeviceRule) Name() string {
+	return "new_device"
+}
+
+func (rule *NewDeviceRule) Evaluate(ctx context.Context, store db.Querier, transfer Transfer) (Verdict, error) {
+	stats, err := store.GetSessionDeviceStats(ctx, db.GetSessionDeviceStatsParams{
+		ClientIp:      transfer.ClientIP,
+		UserAgent:     transfer.UserAgent,
+		Username:      transfer.Username,
+		CreatedBefore: time.Now().Add(-rule.Grace),
+	})
+	if err != nil {
+		return Verdict{}, err
+	}
+
+	// a user without any older session has nothing to compare with
+	if stats.Sessions == 0 || stats.DeviceSessions > 0 {
+		return Verdict{Decision: DecisionAllow}, nil
+	}
+
+	return Verdict{
+		Decision: DecisionReview,
+		Reason:   fmt.Sprintf("transfer requested from a new device (%s, %s)", transfer.ClientIP, transfer.UserAgent),
+	}, nil
+}
+
+// UnusualAmountRule holds transfers much larger than what the account usually sends
+type UnusualAmountRule struct {
+	Lookback time.Duration
+	// MinHistory is the number of past transfers needed before amounts are compared
+	MinHistory int64
+	// Factor is how many times the average amount a transfer must exceed to be unusual
+	Factor int64
+}
+
+func (rule *UnusualAmountRule) Name() string {
+	return "unusual_amount"
+}
+
+func (rule *UnusualAmountRule) Evaluate(ctx context.Context, store db.Querier, transfer Transfer) (Verdict, error) {
+	history, err := store.GetAccountTransferHistory(ctx, db.GetAccountTransferHistoryParams{
+		AccountID: transfer.FromAccount.ID,
+		Since:     time.Now().Add(-rule.Lookback),
+	})
+	if err != nil {
+		return Verdict{}, err
+	}
+
+	if history.Count < rule.MinHistory ||
+		transfer.Amount <= history.Maximum ||
+		transfer.Amount <= history.Average*rule.Factor {
+		return Verdict{Decision: DecisionAllow}, nil
+	}
+
+	return Verdict{
+		Decision: DecisionReview,
+		Reason: fmt.Sprintf("amount %d %s is more than %d times the average of %d %s",
+			transfer.Amount, transfer.FromAccount.Currency, rule.Factor, history.Average, transfer.FromAccount.Currency),
+	}, nil
+}
+
+// FanOutRule catches accounts sending money to many new counterparties in a short time
+type FanOutRule struct {
+	Window time.Duration
+	// ReviewAt is the number of new counterparties within the window, this transfer and the rest of its batch included,
+	// from which transfers are held
+	ReviewAt int64
+	// DenyAbove is the number of new counterparties within the window above which transfers are refused
+	DenyAbove int64
+}
+
+func (rule *FanOutRule) Name() string {
+	return "fan_out"
+}
+
+func (rule *FanOutRule) Evaluate(ctx context.Context, store db.Querier, transfer Transfer) (Verdict, error) {
+	previous, err := store.CountTransfersBetween(ctx, db.CountTransfersBetweenParams{
+		FromAccountID: transfer.FromAccount.ID,
+		ToAccountID:   transfer.ToAccount.ID,
+	})
+	if err != nil {
+		return Verdict{}, err
+	}
+
+	// sending to a known counterparty never fans out
+	if previous > 0 {
+		return Verdict{Decision: DecisionAllow}, nil
+	}
+
+	count, err := store.CountNewCounterparties(ctx, db.CountNewCounterpartiesParams{
+		FromAccountID: transfer.FromAccount.ID,
+		Since:         time.Now().Add(-rule.Window),
+	})
+	if err != nil {
+		return Verdict{}, err
+	}
+
+	// the new recipients of the same batch fan out as much as if they had been sent one by one
+	for _, recipientID := range transfer.BatchRecipients {
+		if recipientID == transfer.ToAccount.ID {
+			continue
+		}
+
+		previous, err := store.CountTransfersBetween(ctx, db.CountTransfersBetweenParams{
+			FromAccountID: transfer.FromAccount.ID,
+			ToAccountID:   recipientID,
+		})
+		if err != nil {
+			return Verdict{}, err
+		}
+		if previous == 0 {
+			count++
+		}
+	}
+	count++
+
+	reason := fmt.Sprintf("%d new counterparties within %s", count, rule.Window)
+	switch {
+	case count > rule.DenyAbove:
+		return Verdict{Decision: DecisionDeny, Reason: reason}, nil
+	case count >= rule.ReviewAt:
+		return Verdict{Decision: DecisionReview, Reason: reason}, nil
+	default:
+		return Verdict{Decision: DecisionAllow}, nil
+	}
+}
@@ -0,0 +1,227 @@
+package risk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mockdb "bitbucket.org/jessyw/go_simplebank/db/mock"
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func randomTransfer() Transfer {
+	owner := util.RandomOwner()
+	return Transfer{
+		FromAccount: db.Account{ID: util.RandomInt(1, 1000), Owner: owner, Currency: util.USD},
+		ToAccount:   db.Account{ID: util.RandomInt(1001, 2000), Owner: util.RandomOwner(), Currency: util.USD},
+		Amount:      util.RandomMoney(),
+		Username:    owner,
+		ClientIP:    "127.0.0.1",
+		UserAgent:   "grpc-go",
+	}
+}
+
+func TestNewDeviceRule(t *testing.T) {
+	transfer := randomTransfer()
+	rule := &NewDeviceRule{Grace: time.Hour}
+
+	testCases := []struct {
+		name     string
+		stats    db.GetSessionDeviceStatsRow
+		decision Decision
+	}{
+		{
+			name:     "KnownDevice",
+			stats:    db.GetSessionDeviceStatsRow{Sessions: 3, DeviceSessions: 1},
+			decision: DecisionAllow,
+		},
+		{
+			name:     "NewDevice",
+			stats:    db.GetSessionDeviceStatsRow{Sessions: 3, DeviceSessions: 0},
+			decision: DecisionReview,
+		},
+		{
+			name:     "NewUser",
+			stats:    db.GetSessionDeviceStatsRow{},
+			decision: DecisionAllow,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetSessionDeviceStats(gomock.Any(), gomock.Any()).Times(1).
+				DoAndReturn(func(ctx context.Context, arg db.GetSessionDeviceStatsParams) (db.GetSessionDeviceStatsRow, error) {
+					require.Equal(t, transfer.Username, arg.Username)
+					require.Equal(t, transfer.ClientIP, arg.ClientIp)
+					require.Equal(t, transfer.UserAgent, arg.UserAgent)
+					require.WithinDuration(t, time.Now().Add(-rule.Grace), arg.CreatedBefore, time.Second)
+					return tc.stats, nil
+				})
+
+			verdict, err := rule.Evaluate(context.Background(), store, transfer)
+			require.NoError(t, err)
+			require.Equal(t, tc.decision, verdict.Decision)
+		})
+	}
+}
+
+func TestUnusualAmountRule(t *testing.T) {
+	transfer := randomTransfer()
+	transfer.Amount = 1000
+	rule := &UnusualAmountRule{Lookback: 24 * time.Hour, MinHistory: 5, Factor: 5}
+
+	testCases := []struct {
+		name     string
+		history  db.GetAccountTransferHistoryRow
+		decision Decision
+	}{
+		{
+			name:     "Usual",
+			history:  db.GetAccountTransferHistoryRow{Count: 10, Average: 500, Maximum: 900},
+			decision: DecisionAllow,
+		},
+		{
+			name:     "Unusual",
+			history:  db.GetAccountTransferHistoryRow{Count: 10, Average: 100, Maximum: 300},
+			decision: DecisionReview,
+		},
+		{
+			name:     "BelowMaximum",
+			history:  db.GetAccountTransferHistoryRow{Count: 10, Average: 100, Maximum: 1000},
+			decision: DecisionAllow,
+		},
+		{
+			name:     "NotEnoughHistory",
+			history:  db.GetAccountTransferHistoryRow{Count: 4, Average: 1, Maximum: 1},
+			decision: DecisionAllow,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetAccountTransferHistory(gomock.Any(), gomock.Any()).Times(1).Return(tc.history, nil)
+
+			verdict, err := rule.Evaluate(context.Background(), store, transfer)
+			require.NoError(t, err)
+			require.Equal(t, tc.decision, verdict.Decision)
+		})
+	}
+}
+
+func TestFanOutRule(t *testing.T) {
+	transfer := randomTransfer()
+	rule := &FanOutRule{Window: time.Hour, ReviewAt: 3, DenyAbove: 5}
+
+	testCases := []struct {
+		name            string
+		batchRecipients []int64
+		buildStubs      func(store *mockdb.MockStore)
+		decision        Decision
+	}{
+		{
+			name: "KnownCounterparty",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CountTransfersBetween(gomock.Any(), gomock.Any()).Times(1).Return(int64(1), nil)
+				store.EXPECT().CountNewCounterparties(gomock.Any(), gomock.Any()).Times(0)
+			},
+			decision: DecisionAllow,
+		},
+		{
+			name: "FewNewCounterparties",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CountTransfersBetween(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().CountNewCounterparties(gomock.Any(), gomock.Any()).Times(1).Return(int64(1), nil)
+			},
+			decision: DecisionAllow,
+		},
+		{
+			name: "Review",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CountTransfersBetween(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().CountNewCounterparties(gomock.Any(), gomock.Any()).Times(1).Return(int64(2), nil)
+			},
+			decision: DecisionReview,
+		},
+		{
+			name: "Deny",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CountTransfersBetween(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().CountNewCounterparties(gomock.Any(), gomock.Any()).Times(1).Return(int64(5), nil)
+			},
+			decision: DecisionDeny,
+		},
+		{
+			name:            "NewRecipientsInBatch",
+			batchRecipients: []int64{transfer.ToAccount.ID + 1, transfer.ToAccount.ID + 2, transfer.ToAccount.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CountTransfersBetween(gomock.Any(), gomock.Eq(db.CountTransfersBetweenParams{
+						FromAccountID: transfer.FromAccount.ID,
+						ToAccountID:   transfer.ToAccount.ID,
+					})).
+					Times(1).
+					Return(int64(0), nil)
+				store.EXPECT().CountNewCounterparties(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+				// one of the other recipients of the batch is already known, the recipient itself is counted once
+				store.EXPECT().
+					CountTransfersBetween(gomock.Any(), gomock.Eq(db.CountTransfersBetweenParams{
+						FromAccountID: transfer.FromAccount.ID,
+						ToAccountID:   transfer.ToAccount.ID + 1,
+					})).
+					Times(1).
+					Return(int64(0), nil)
+				store.EXPECT().
+					CountTransfersBetween(gomock.Any(), gomock.Eq(db.CountTransfersBetweenParams{
+						FromAccountID: transfer.FromAccount.ID,
+						ToAccountID:   transfer.ToAccount.ID + 2,
+					})).
+					Times(1).
+					Return(int64(1), nil)
+			},
+			decision: DecisionAllow,
+		},
+		{
+			name:            "ReviewInBatch",
+			batchRecipients: []int64{transfer.ToAccount.ID + 1, transfer.ToAccount.ID + 2},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CountTransfersBetween(gomock.Any(), gomock.Any()).Times(3).Return(int64(0), nil)
+				store.EXPECT().CountNewCounterparties(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+			},
+			decision: DecisionReview,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			batchTransfer := transfer
+			batchTransfer.BatchRecipients = tc.batchRecipients
+
+			verdict, err := rule.Evaluate(context.Background(), store, batchTransfer)
+			require.NoError(t, err)
+			require.Equal(t, tc.decision, verdict.Decision)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package risk
+
+import (
+	"context"
+
+	db "bitbucket.org/jessyw/go_simplebank/db/sqlc"
+)
+
+// Decision is the outcome of screening a transfer
+type Decision string
+
+const (
+	// DecisionAllow lets the transfer go through
+	DecisionAllow Decision = "allow"
+	// DecisionReview holds the transfer until a banker approves or rejects it
+	DecisionReview Decision = "review"
+	// DecisionDeny refuses the transfer
+	DecisionDeny Decision = "deny"
+)
+
+// severity orders decisions so that the strictest one wins
+func (decision Decision) severity() int {
+	switch decision {
+	case DecisionDeny:
+		return 2
+	case DecisionReview:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Transfer is a transfer about to be executed, along with where it was requested from
+type Transfer struct {
+	FromAccount db.Account
+	ToAccount   db.Account
+	Amount      int64
+	Username    string
+	ClientIP    string
+	UserAgent   string
+	// BatchRecipients are the distinct recipients of the items screened before this one in the same batch,
+	// which are not recorded as transfers yet
+	BatchRecipients []int64
+}
+
+// Result is the decision taken for a transfer and the reasons behind it
+type Result struct {
+	Decision Decision `json:"decision"`
+	Reasons  []string `json:"reasons"`
+}
+
+// Screener decides whether a transfer can be executed before it is committed
+type Screener interface {
+	Screen(ctx context.Context, transfer Transfer) (Result, error)
+}
+
+// NopScreener allows every transfer
+type NopScreener struct{}
+
+// NewNopScreener creates a screener that allows every transfer
+func NewNopScreener() Screener {
+	return NopScreener{}
+}
+
+func (NopScreener) Screen(ctx context.Context, transfer Transfer) (Result, error) {
+	return Result{Decision: DecisionAllow}, nil
+}
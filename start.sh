@@ -2,8 +2,5 @@
 
 set -e
 
-echo "run db migration"
-/app/migrate -path /app/db/migration -database  "$DB_SOURCE" -verbose up
-
 echo "start the app"
 exec "$@"
\ No newline at end of file
@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+const (
+	// ExporterNone doesn't record any span, the trace context is still propagated
+	ExporterNone = "none"
+	// ExporterStdout writes the spans to the standard output
+	ExporterStdout = "stdout"
+	// ExporterFile appends the spans to the file set in TracingFile
+	ExporterFile = "file"
+)
+
+// ServiceName is the name of the application in the traces
+const ServiceName = "simplebank"
+
+// Setup installs the tracer provider of the exporter set in the config, and the W3C trace context
+// propagator reading and writing the traceparent headers.
+// The returned function flushes the spans not exported yet, it must be called before exiting.
+func Setup(config util.Config) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var w io.Writer
+	var file *os.File
+	switch config.TracingExporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		w = os.Stdout
+	case ExporterFile:
+		if config.TracingFile == "" {
+			return nil, fmt.Errorf("the %s tracing exporter needs a file", ExporterFile)
+		}
+		err = os.MkdirAll(filepath.Dir(config.TracingFile), 0o755)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create tracing directory: %w", err)
+		}
+		file, err = os.OpenFile(config.TracingFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open tracing file: %w", err)
+		}
+		w = file
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q, use %s, %s or %s",
+			config.TracingExporter, ExporterNone, ExporterStdout, ExporterFile)
+	}
+
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(w))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create tracing exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(ServiceName),
+			semconv.DeploymentEnvironment(config.Environment),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if file != nil {
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}, nil
+}
@@ -0,0 +1,40 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetupFileExporter(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "traces", "traces.json")
+	shutdown, err := Setup(util.Config{TracingExporter: ExporterFile, TracingFile: file})
+	require.NoError(t, err)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "test-span")
+	span.End()
+
+	require.NoError(t, shutdown(context.Background()))
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"Name":"test-span"`)
+	require.Contains(t, string(data), ServiceName)
+}
+
+func TestSetupInvalidExporter(t *testing.T) {
+	_, err := Setup(util.Config{TracingExporter: "jaeger"})
+	require.Error(t, err)
+
+	_, err = Setup(util.Config{TracingExporter: ExporterFile})
+	require.Error(t, err)
+
+	shutdown, err := Setup(util.Config{})
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+}
@@ -16,6 +16,61 @@ type Config struct {
 	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
 	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
 	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	// TransferRequestDuration is how long a transfer waiting for approvals stays open
+	TransferRequestDuration time.Duration `mapstructure:"TRANSFER_REQUEST_DURATION"`
+	// PasswordResetTokenDuration is how long a password reset token sent by email can be used
+	PasswordResetTokenDuration time.Duration `mapstructure:"PASSWORD_RESET_TOKEN_DURATION"`
+	// MailDir is the directory the emails are written to instead of being delivered
+	MailDir string `mapstructure:"MAIL_DIR"`
+	// MFAChallengeDuration is how long a user has to give their second factor once their password is checked
+	MFAChallengeDuration time.Duration `mapstructure:"MFA_CHALLENGE_DURATION"`
+	// MFAStepUpAmount is the amount above which a transfer needs a two-factor authentication code, 0 never asks for one
+	MFAStepUpAmount int64 `mapstructure:"MFA_STEP_UP_AMOUNT"`
+	// LoginMaxFailures is the number of failed logins in a row after which a username is locked out, 0 never locks it
+	LoginMaxFailures int `mapstructure:"LOGIN_MAX_FAILURES"`
+	// LoginIPMaxFailures is the number of failed logins in a row after which a client IP is locked out, 0 never locks it
+	LoginIPMaxFailures int `mapstructure:"LOGIN_IP_MAX_FAILURES"`
+	// LoginLockoutDuration is how long a lockout lasts, failed logins older than that are forgotten
+	LoginLockoutDuration time.Duration `mapstructure:"LOGIN_LOCKOUT_DURATION"`
+	// LoginBaseDelay is how long to wait after the second failed login in a row, it doubles with each following one
+	LoginBaseDelay time.Duration `mapstructure:"LOGIN_BASE_DELAY"`
+	// PasswordHashAlgorithm is the algorithm new passwords are hashed with, argon2id or bcrypt
+	PasswordHashAlgorithm string `mapstructure:"PASSWORD_HASH_ALGORITHM"`
+	// Argon2Memory is the memory used by Argon2id in KiB
+	Argon2Memory uint32 `mapstructure:"ARGON2_MEMORY"`
+	// Argon2Iterations is the number of passes of Argon2id over the memory
+	Argon2Iterations uint32 `mapstructure:"ARGON2_ITERATIONS"`
+	// Argon2Parallelism is the number of threads used by Argon2id
+	Argon2Parallelism uint8 `mapstructure:"ARGON2_PARALLELISM"`
+	// BcryptCost is the cost of bcrypt, when it is the chosen algorithm
+	BcryptCost int `mapstructure:"BCRYPT_COST"`
+	// PasswordMinLength is the minimum length of a new password
+	PasswordMinLength int `mapstructure:"PASSWORD_MIN_LENGTH"`
+	// PasswordMinCharacterClasses is how many of lowercase letters, uppercase letters, digits and symbols a new password must mix
+	PasswordMinCharacterClasses int `mapstructure:"PASSWORD_MIN_CHARACTER_CLASSES"`
+	// BreachedPasswordsFile replaces the bundled list of breached passwords, in the format of the Pwned Passwords downloads
+	BreachedPasswordsFile string `mapstructure:"BREACHED_PASSWORDS_FILE"`
+	// RateLimitLogin is the rate limit of the endpoints reachable without logging in, by client IP, such as 10/1m.
+	// The rate limits are written as requests/period, an empty one is no limit.
+	RateLimitLogin string `mapstructure:"RATE_LIMIT_LOGIN"`
+	// RateLimitTransfer is the rate limit of the endpoints moving money, by user
+	RateLimitTransfer string `mapstructure:"RATE_LIMIT_TRANSFER"`
+	// RateLimitRead is the rate limit of the endpoints only reading data, by user
+	RateLimitRead string `mapstructure:"RATE_LIMIT_READ"`
+	// RateLimitWrite is the rate limit of the other endpoints changing data, by user
+	RateLimitWrite string `mapstructure:"RATE_LIMIT_WRITE"`
+	// LogLevel is the minimum level of the logs: debug, info, warn or error
+	LogLevel string `mapstructure:"LOG_LEVEL"`
+	// TracingExporter is where the spans are exported: none, stdout, or file to append them to TracingFile
+	TracingExporter string `mapstructure:"TRACING_EXPORTER"`
+	TracingFile     string `mapstructure:"TRACING_FILE"`
+	// MetricsServerAddress is where the Prometheus metrics are served, apart from the public HTTP server.
+	// They aren't served when it's empty.
+	MetricsServerAddress string `mapstructure:"METRICS_SERVER_ADDRESS"`
+	// ShutdownTimeout is how long the servers wait for the requests in flight once asked to stop, before cutting them
+	ShutdownTimeout time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+	// MigrateOnStartup applies the migrations not applied yet before starting the servers
+	MigrateOnStartup bool `mapstructure:"MIGRATE_ON_STARTUP"`
 }
 
 // LoadConfig reads configuration from file or environment variables.
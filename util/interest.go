@@ -0,0 +1,35 @@
+package util
+
+import "math/big"
+
+// Interest is accrued in micros, millionths of a minor unit, so that the daily interest of small balances
+// doesn't round down to nothing. Amounts are only rounded when they become money:
+//   - the interest of a day is balance * annual rate / 365, truncated to whole micros
+//   - a posting pays the accrued micros truncated to whole minor units and carries the remainder to the next posting
+//
+// Truncating never pays more than what was accrued, and the carry makes sure no fraction is lost over time.
+const (
+	MicrosPerUnit = 1_000_000
+	DaysPerYear   = 365
+
+	basisPointsPerUnit = 10_000
+)
+
+// DailyInterestMicros returns the interest a balance in minor units accrues in one day at an annual rate in basis points.
+// Balances that are zero or negative accrue nothing.
+func DailyInterestMicros(balance int64, annualRateBps int64) int64 {
+	if balance <= 0 || annualRateBps <= 0 {
+		return 0
+	}
+
+	// big.Int keeps large balances from overflowing before the division
+	micros := new(big.Int).Mul(big.NewInt(balance), big.NewInt(annualRateBps))
+	micros.Mul(micros, big.NewInt(MicrosPerUnit))
+	micros.Quo(micros, big.NewInt(basisPointsPerUnit*DaysPerYear))
+	return micros.Int64()
+}
+
+// SplitInterestMicros splits accrued micros into the whole minor units to post and the micros to carry over.
+func SplitInterestMicros(micros int64) (amount int64, carry int64) {
+	return micros / MicrosPerUnit, micros % MicrosPerUnit
+}
@@ -0,0 +1,81 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDailyInterestMicros(t *testing.T) {
+	testCases := []struct {
+		name          string
+		balance       int64
+		annualRateBps int64
+		micros        int64
+	}{
+		{
+			name:          "Exact",
+			balance:       365_000,
+			annualRateBps: 100,
+			micros:        10 * MicrosPerUnit,
+		},
+		{
+			name:          "Truncated",
+			balance:       1,
+			annualRateBps: 150,
+			// 1 * 0.015 / 365 = 41.0958... micros
+			micros: 41,
+		},
+		{
+			name:          "ZeroBalance",
+			balance:       0,
+			annualRateBps: 150,
+			micros:        0,
+		},
+		{
+			name:          "NegativeBalance",
+			balance:       -1000,
+			annualRateBps: 150,
+			micros:        0,
+		},
+		{
+			name:          "ZeroRate",
+			balance:       1000,
+			annualRateBps: 0,
+			micros:        0,
+		},
+		{
+			name:          "LargeBalance",
+			balance:       1_000_000_000_000,
+			annualRateBps: 10_000,
+			micros:        2_739_726_027_397_260,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.micros, DailyInterestMicros(tc.balance, tc.annualRateBps))
+		})
+	}
+}
+
+func TestSplitInterestMicros(t *testing.T) {
+	amount, carry := SplitInterestMicros(12_345_678)
+	require.Equal(t, int64(12), amount)
+	require.Equal(t, int64(345_678), carry)
+
+	amount, carry = SplitInterestMicros(999_999)
+	require.Zero(t, amount)
+	require.Equal(t, int64(999_999), carry)
+
+	// daily truncation loses less than a micro per day: 1.5% of 100.00 over three years is 4.50
+	var accrued int64
+	for day := 0; day < 3*DaysPerYear; day++ {
+		accrued += DailyInterestMicros(10_000, 150)
+	}
+	amount, carry = SplitInterestMicros(accrued)
+	require.Equal(t, int64(449), amount)
+	require.Less(t, carry, int64(MicrosPerUnit))
+}
@@ -1,21 +1,248 @@
 package util
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// HashPassword returns the bcrypt hash of the password
-func HashPassword(password string) (string, error) {
-	hashPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+const (
+	// PasswordHashArgon2id hashes passwords with Argon2id, the default
+	PasswordHashArgon2id = "argon2id"
+	// PasswordHashBcrypt hashes passwords with bcrypt
+	PasswordHashBcrypt = "bcrypt"
+)
+
+var (
+	// ErrPasswordMismatch is returned when a password doesn't match its hash
+	ErrPasswordMismatch = errors.New("password doesn't match")
+	// ErrUnknownPasswordHash is returned when checking a password against a hash in an unknown format
+	ErrUnknownPasswordHash = errors.New("unknown password hash format")
+)
+
+// Argon2idParams are the cost parameters of Argon2id
+type Argon2idParams struct {
+	// Memory is in KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2idParams follow the recommendations of RFC 9106 for memory-constrained environments
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// PasswordHasher hashes new passwords with one algorithm, and checks passwords against hashes of any supported algorithm.
+// Hashes are self-describing: they tell the algorithm and the parameters they were made with.
+type PasswordHasher interface {
+	// Hash returns the hash of the password
+	Hash(password string) (string, error)
+	// Check returns ErrPasswordMismatch when the password doesn't match the hash.
+	// needsRehash is true when the hash was made with another algorithm or other parameters than the hasher's,
+	// the password should then be hashed again.
+	Check(password string, hashedPassword string) (needsRehash bool, err error)
+}
+
+// Argon2idHasher hashes passwords with Argon2id
+type Argon2idHasher struct {
+	Params Argon2idParams
+}
+
+// Hash returns the hash of the password in the PHC string format, such as $argon2id$v=19$m=65536,t=3,p=2$salt$key
+func (hasher Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, hasher.Params.SaltLength)
+	_, err := rand.Read(salt)
 	if err != nil {
 		return "", fmt.Errorf("failed to hash password: %w", err)
 	}
-	return string(hashPassword), nil
+
+	params := hasher.Params
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, params.Memory, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
 }
 
-// CheckPassword checks if the provided password is correct or not
-func CheckPassword(password string, hashedPassword string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+// Check checks the password, the hash needs to be made again unless it is an Argon2id hash with the same parameters
+func (hasher Argon2idHasher) Check(password string, hashedPassword string) (bool, error) {
+	info, err := verifyPassword(password, hashedPassword)
+	if err != nil {
+		return false, err
+	}
+
+	return info.algorithm != PasswordHashArgon2id || info.argon2id != hasher.Params, nil
+}
+
+// BcryptHasher hashes passwords with bcrypt
+type BcryptHasher struct {
+	Cost int
+}
+
+// Hash returns the bcrypt hash of the password
+func (hasher BcryptHasher) Hash(password string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), hasher.Cost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hashedPassword), nil
+}
+
+// Check checks the password, the hash needs to be made again unless it is a bcrypt hash with the same cost
+func (hasher BcryptHasher) Check(password string, hashedPassword string) (bool, error) {
+	info, err := verifyPassword(password, hashedPassword)
+	if err != nil {
+		return false, err
+	}
+
+	return info.algorithm != PasswordHashBcrypt || info.bcryptCost != hasher.Cost, nil
+}
+
+// NewPasswordHasher creates the hasher chosen by the configuration, parameters that aren't set take their default value
+func NewPasswordHasher(config Config) (PasswordHasher, error) {
+	switch config.PasswordHashAlgorithm {
+	case "", PasswordHashArgon2id:
+		params := DefaultArgon2idParams
+		if config.Argon2Memory > 0 {
+			params.Memory = config.Argon2Memory
+		}
+		if config.Argon2Iterations > 0 {
+			params.Iterations = config.Argon2Iterations
+		}
+		if config.Argon2Parallelism > 0 {
+			params.Parallelism = config.Argon2Parallelism
+		}
+		return Argon2idHasher{Params: params}, nil
+	case PasswordHashBcrypt:
+		cost := config.BcryptCost
+		if cost == 0 {
+			cost = bcrypt.DefaultCost
+		}
+		if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost must be from %d to %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+		return BcryptHasher{Cost: cost}, nil
+	default:
+		return nil, fmt.Errorf("unknown password hash algorithm %q, supported algorithms are %s and %s",
+			config.PasswordHashAlgorithm, PasswordHashArgon2id, PasswordHashBcrypt)
+	}
+}
+
+var passwordHasher PasswordHasher = Argon2idHasher{Params: DefaultArgon2idParams}
+
+// SetPasswordHasher replaces the hasher used by HashPassword and CheckPassword, Argon2id with the default parameters.
+// It is meant to be called once at startup, before any password is hashed.
+func SetPasswordHasher(hasher PasswordHasher) {
+	passwordHasher = hasher
+	unknownUserHashOnce = sync.Once{}
+}
+
+// HashPassword returns the hash of the password
+func HashPassword(password string) (string, error) {
+	return passwordHasher.Hash(password)
+}
+
+// CheckPassword checks if the provided password is correct or not.
+// needsRehash tells that the password is correct but its hash is outdated, it should be hashed again.
+func CheckPassword(password string, hashedPassword string) (needsRehash bool, err error) {
+	return passwordHasher.Check(password, hashedPassword)
+}
+
+var (
+	unknownUserHashOnce sync.Once
+	unknownUserHash     string
+)
+
+// CheckPasswordOfUnknownUser always fails, but takes as long as CheckPassword,
+// so that a login takes the same time whether the username exists or not
+func CheckPasswordOfUnknownUser(password string) error {
+	unknownUserHashOnce.Do(func() {
+		unknownUserHash, _ = HashPassword(RandomString(32))
+	})
+
+	_, err := CheckPassword(password, unknownUserHash)
+	if err == nil {
+		return errors.New("unknown user")
+	}
+	return err
+}
+
+// passwordHashInfo is what a hash tells about how it was made
+type passwordHashInfo struct {
+	algorithm  string
+	argon2id   Argon2idParams
+	bcryptCost int
+}
+
+// verifyPassword checks the password against a hash of any supported algorithm
+func verifyPassword(password string, hashedPassword string) (passwordHashInfo, error) {
+	if strings.HasPrefix(hashedPassword, "$argon2id$") {
+		return verifyArgon2id(password, hashedPassword)
+	}
+
+	info := passwordHashInfo{algorithm: PasswordHashBcrypt}
+	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return info, ErrPasswordMismatch
+		}
+		return info, fmt.Errorf("%w: %s", ErrUnknownPasswordHash, err)
+	}
+
+	info.bcryptCost, err = bcrypt.Cost([]byte(hashedPassword))
+	return info, err
+}
+
+func verifyArgon2id(password string, hashedPassword string) (passwordHashInfo, error) {
+	info := passwordHashInfo{algorithm: PasswordHashArgon2id}
+
+	// "", "argon2id", "v=19", "m=65536,t=3,p=2", salt, key
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 {
+		return info, ErrUnknownPasswordHash
+	}
+
+	var version int
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return info, fmt.Errorf("%w: unsupported argon2 version %s", ErrUnknownPasswordHash, parts[2])
+	}
+
+	params := &info.argon2id
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil {
+		return info, fmt.Errorf("%w: invalid argon2 parameters %s", ErrUnknownPasswordHash, parts[3])
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return info, fmt.Errorf("%w: invalid salt", ErrUnknownPasswordHash)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return info, fmt.Errorf("%w: invalid key", ErrUnknownPasswordHash)
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	otherKey := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	if subtle.ConstantTimeCompare(key, otherKey) != 1 {
+		return info, ErrPasswordMismatch
+	}
+
+	return info, nil
 }
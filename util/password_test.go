@@ -13,14 +13,16 @@ func TestPassword(t *testing.T) {
 	hashedPassword, err := HashPassword(password)
 	require.NoError(t, err)
 	require.NotEmpty(t, hashedPassword)
+	require.Regexp(t, `^\$argon2id\$v=19\$m=65536,t=3,p=2\$`, hashedPassword)
 
-	err = CheckPassword(password, hashedPassword)
+	needsRehash, err := CheckPassword(password, hashedPassword)
 	require.NoError(t, err)
+	require.False(t, needsRehash)
 
 	wrongPassword := RandomString(6)
-	err = CheckPassword(wrongPassword, hashedPassword)
+	_, err = CheckPassword(wrongPassword, hashedPassword)
 	require.Error(t, err)
-	require.EqualError(t, err, bcrypt.ErrMismatchedHashAndPassword.Error())
+	require.ErrorIs(t, err, ErrPasswordMismatch)
 
 	hashedPassword2, err := HashPassword(password)
 	require.NoError(t, err)
@@ -28,3 +30,86 @@ func TestPassword(t *testing.T) {
 	require.NotEqual(t, hashedPassword, hashedPassword2)
 
 }
+
+func TestBcryptHasher(t *testing.T) {
+	password := RandomString(6)
+	hasher := BcryptHasher{Cost: bcrypt.MinCost}
+
+	hashedPassword, err := hasher.Hash(password)
+	require.NoError(t, err)
+
+	needsRehash, err := hasher.Check(password, hashedPassword)
+	require.NoError(t, err)
+	require.False(t, needsRehash)
+
+	_, err = hasher.Check(RandomString(6), hashedPassword)
+	require.ErrorIs(t, err, ErrPasswordMismatch)
+
+	// a higher cost makes the hash outdated
+	needsRehash, err = BcryptHasher{Cost: bcrypt.MinCost + 1}.Check(password, hashedPassword)
+	require.NoError(t, err)
+	require.True(t, needsRehash)
+}
+
+func TestPasswordRehash(t *testing.T) {
+	password := RandomString(6)
+	argon2idHasher := Argon2idHasher{Params: Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}}
+
+	bcryptHash, err := BcryptHasher{Cost: bcrypt.MinCost}.Hash(password)
+	require.NoError(t, err)
+
+	// bcrypt hashes are still accepted, but must be upgraded
+	needsRehash, err := argon2idHasher.Check(password, bcryptHash)
+	require.NoError(t, err)
+	require.True(t, needsRehash)
+
+	_, err = argon2idHasher.Check(RandomString(6), bcryptHash)
+	require.ErrorIs(t, err, ErrPasswordMismatch)
+
+	argon2idHash, err := argon2idHasher.Hash(password)
+	require.NoError(t, err)
+
+	needsRehash, err = argon2idHasher.Check(password, argon2idHash)
+	require.NoError(t, err)
+	require.False(t, needsRehash)
+
+	// other parameters make the hash outdated
+	stronger := argon2idHasher
+	stronger.Params.Iterations = 2
+	needsRehash, err = stronger.Check(password, argon2idHash)
+	require.NoError(t, err)
+	require.True(t, needsRehash)
+}
+
+func TestCheckPasswordInvalidHash(t *testing.T) {
+	for _, hashedPassword := range []string{"", "plain", "$argon2id$v=19$m=1,t=1$salt$key", "$argon2id$v=18$m=1024,t=1,p=1$c2FsdA$a2V5"} {
+		_, err := CheckPassword(RandomString(6), hashedPassword)
+		require.ErrorIs(t, err, ErrUnknownPasswordHash)
+	}
+}
+
+func TestNewPasswordHasher(t *testing.T) {
+	hasher, err := NewPasswordHasher(Config{})
+	require.NoError(t, err)
+	require.Equal(t, Argon2idHasher{Params: DefaultArgon2idParams}, hasher)
+
+	hasher, err = NewPasswordHasher(Config{PasswordHashAlgorithm: PasswordHashArgon2id, Argon2Memory: 19 * 1024, Argon2Iterations: 2, Argon2Parallelism: 1})
+	require.NoError(t, err)
+	require.Equal(t, uint32(19*1024), hasher.(Argon2idHasher).Params.Memory)
+	require.Equal(t, DefaultArgon2idParams.KeyLength, hasher.(Argon2idHasher).Params.KeyLength)
+
+	hasher, err = NewPasswordHasher(Config{PasswordHashAlgorithm: PasswordHashBcrypt})
+	require.NoError(t, err)
+	require.Equal(t, BcryptHasher{Cost: bcrypt.DefaultCost}, hasher)
+
+	_, err = NewPasswordHasher(Config{PasswordHashAlgorithm: PasswordHashBcrypt, BcryptCost: 50})
+	require.Error(t, err)
+
+	_, err = NewPasswordHasher(Config{PasswordHashAlgorithm: "md5"})
+	require.Error(t, err)
+}
+
+func TestCheckPasswordOfUnknownUser(t *testing.T) {
+	err := CheckPasswordOfUnknownUser(RandomString(6))
+	require.Error(t, err)
+}
@@ -51,3 +51,8 @@ func RandomCurrency() string {
 func RandomEmail() string {
 	return fmt.Sprintf("%s@email.com", RandomString(6))
 }
+
+// RandomPassword generates a random password mixing lowercase and uppercase letters, digits and a symbol
+func RandomPassword() string {
+	return fmt.Sprintf("%s%s%d!", strings.ToUpper(RandomString(4)), RandomString(4), RandomInt(100, 999))
+}
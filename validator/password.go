@@ -0,0 +1,206 @@
+package validator
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"bitbucket.org/jessyw/go_simplebank/util"
+)
+
+const (
+	// DefaultPasswordMinLength is the minimum length of a password when the configuration doesn't set one
+	DefaultPasswordMinLength = 8
+	// PasswordMaxLength is the maximum length of a password
+	PasswordMaxLength = 100
+)
+
+// rangePrefixLength is the number of hex characters of the hash prefix the breached passwords are indexed by
+const rangePrefixLength = 5
+
+//go:embed breached_passwords.txt
+var bundledBreachedPasswords []byte
+
+// PasswordPolicy is what a new password must follow, existing passwords are only checked at login
+type PasswordPolicy struct {
+	MinLength int
+	MaxLength int
+	// MinCharacterClasses is how many of lowercase letters, uppercase letters, digits and symbols a password must mix
+	MinCharacterClasses int
+	// Breached are the passwords known from data breaches, nil skips the check
+	Breached *BreachedPasswords
+}
+
+// NewPasswordPolicy creates the password policy set by the configuration.
+// The breached passwords are read from BreachedPasswordsFile, or from the list bundled with the binary.
+func NewPasswordPolicy(config util.Config) (PasswordPolicy, error) {
+	policy := PasswordPolicy{
+		MinLength:           config.PasswordMinLength,
+		MaxLength:           PasswordMaxLength,
+		MinCharacterClasses: config.PasswordMinCharacterClasses,
+	}
+	if policy.MinLength <= 0 {
+		policy.MinLength = DefaultPasswordMinLength
+	}
+	if policy.MinLength > policy.MaxLength {
+		return policy, fmt.Errorf("password min length must be at most %d", policy.MaxLength)
+	}
+	if policy.MinCharacterClasses > 4 {
+		return policy, fmt.Errorf("password min character classes must be at most 4")
+	}
+
+	var err error
+	if config.BreachedPasswordsFile == "" {
+		policy.Breached, err = LoadBreachedPasswords(bytes.NewReader(bundledBreachedPasswords))
+	} else {
+		policy.Breached, err = LoadBreachedPasswordsFile(config.BreachedPasswordsFile)
+	}
+	if err != nil {
+		return policy, err
+	}
+
+	return policy, nil
+}
+
+// PasswordError is returned when a password doesn't follow the policy
+type PasswordError struct {
+	Reason string
+}
+
+func (err *PasswordError) Error() string {
+	return err.Reason
+}
+
+// Validate checks that the new password of the user with this username and email follows the policy
+func (policy PasswordPolicy) Validate(password string, username string, email string) error {
+	n := len(password)
+	if n < policy.MinLength || n > policy.MaxLength {
+		return &PasswordError{fmt.Sprintf("must contain from %d-%d characters", policy.MinLength, policy.MaxLength)}
+	}
+
+	if characterClasses(password) < policy.MinCharacterClasses {
+		return &PasswordError{fmt.Sprintf("must mix at least %d of lowercase letters, uppercase letters, digits and symbols",
+			policy.MinCharacterClasses)}
+	}
+
+	lower := strings.ToLower(password)
+	if containsIdentifier(lower, username) {
+		return &PasswordError{"must not contain the username"}
+	}
+	localPart, _, _ := strings.Cut(email, "@")
+	if containsIdentifier(lower, localPart) {
+		return &PasswordError{"must not contain the email"}
+	}
+
+	if policy.Breached != nil && policy.Breached.Contains(password) {
+		return &PasswordError{"has appeared in a data breach, choose another one"}
+	}
+
+	return nil
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	return lower + upper + digit + symbol
+}
+
+// containsIdentifier tells whether the lowercase password contains the identifier,
+// identifiers too short to be guessed from are ignored
+func containsIdentifier(password string, identifier string) bool {
+	if len(identifier) < 3 {
+		return false
+	}
+	return strings.Contains(password, strings.ToLower(identifier))
+}
+
+// BreachedPasswords is a list of passwords known from data breaches, kept as their SHA-1 hashes.
+// Like the k-anonymity range API of Pwned Passwords, hashes are grouped by their first 5 hex characters,
+// a password is looked up among the hashes of its range only.
+type BreachedPasswords struct {
+	ranges map[string]map[string]struct{}
+}
+
+// LoadBreachedPasswords reads a list in the format of the Pwned Passwords downloads:
+// one uppercase hex SHA-1 hash per line, optionally followed by a colon and a count, which is ignored.
+// Empty lines and lines starting with # are skipped.
+func LoadBreachedPasswords(r io.Reader) (*BreachedPasswords, error) {
+	list := &BreachedPasswords{ranges: make(map[string]map[string]struct{})}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		hash, _, _ := strings.Cut(text, ":")
+		if len(hash) != 2*sha1.Size {
+			return nil, fmt.Errorf("invalid hash on line %d of breached passwords", line)
+		}
+		if _, err := hex.DecodeString(hash); err != nil {
+			return nil, fmt.Errorf("invalid hash on line %d of breached passwords: %w", line, err)
+		}
+		list.add(strings.ToUpper(hash))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read breached passwords: %w", err)
+	}
+
+	return list, nil
+}
+
+// LoadBreachedPasswordsFile reads a list of breached passwords from a file
+func LoadBreachedPasswordsFile(path string) (*BreachedPasswords, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open breached passwords: %w", err)
+	}
+	defer file.Close()
+
+	return LoadBreachedPasswords(file)
+}
+
+func (list *BreachedPasswords) add(hash string) {
+	prefix, suffix := hash[:rangePrefixLength], hash[rangePrefixLength:]
+	if list.ranges[prefix] == nil {
+		list.ranges[prefix] = make(map[string]struct{})
+	}
+	list.ranges[prefix][suffix] = struct{}{}
+}
+
+// Contains tells whether the password is in the list
+func (list *BreachedPasswords) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	_, ok := list.ranges[hash[:rangePrefixLength]][hash[rangePrefixLength:]]
+	return ok
+}
+
+// Len returns the number of hashes in the list
+func (list *BreachedPasswords) Len() int {
+	n := 0
+	for _, suffixes := range list.ranges {
+		n += len(suffixes)
+	}
+	return n
+}
@@ -0,0 +1,78 @@
+package validator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bitbucket.org/jessyw/go_simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	policy, err := NewPasswordPolicy(util.Config{PasswordMinLength: 10, PasswordMinCharacterClasses: 3})
+	require.NoError(t, err)
+	require.Positive(t, policy.Breached.Len())
+
+	testCases := []struct {
+		name     string
+		password string
+		reason   string
+	}{
+		{name: "OK", password: "Correct-Horse-42"},
+		{name: "TooShort", password: "Ab1!", reason: "must contain from 10-100 characters"},
+		{name: "TooLong", password: "Ab1!" + strings.Repeat("x", 100), reason: "must contain from 10-100 characters"},
+		{name: "TwoClasses", password: "correcthorse42", reason: "must mix at least 3 of"},
+		{name: "ContainsUsername", password: "My-JSmith-2024", reason: "must not contain the username"},
+		{name: "ContainsEmail", password: "Hello-Jane.Doe1", reason: "must not contain the email"},
+		{name: "Breached", password: "Password123!", reason: "has appeared in a data breach"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := policy.Validate(tc.password, "jsmith", "jane.doe@example.com")
+			if tc.reason == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			var passwordErr *PasswordError
+			require.ErrorAs(t, err, &passwordErr)
+			require.Contains(t, err.Error(), tc.reason)
+		})
+	}
+}
+
+func TestNewPasswordPolicyDefaults(t *testing.T) {
+	policy, err := NewPasswordPolicy(util.Config{})
+	require.NoError(t, err)
+	require.Equal(t, DefaultPasswordMinLength, policy.MinLength)
+	require.Equal(t, PasswordMaxLength, policy.MaxLength)
+	require.Zero(t, policy.MinCharacterClasses)
+
+	_, err = NewPasswordPolicy(util.Config{PasswordMinCharacterClasses: 5})
+	require.Error(t, err)
+
+	_, err = NewPasswordPolicy(util.Config{PasswordMinLength: 101})
+	require.Error(t, err)
+}
+
+func TestLoadBreachedPasswordsFile(t *testing.T) {
+	// SHA-1 of "hunter2"
+	content := "# comment\n\nF3BBBD66A63D4BF1747940578EC3D0103530E21D:17043\n"
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	policy, err := NewPasswordPolicy(util.Config{BreachedPasswordsFile: path})
+	require.NoError(t, err)
+	require.Equal(t, 1, policy.Breached.Len())
+	require.True(t, policy.Breached.Contains("hunter2"))
+	require.False(t, policy.Breached.Contains("Password123!"))
+
+	_, err = LoadBreachedPasswords(strings.NewReader("not-a-hash\n"))
+	require.Error(t, err)
+
+	_, err = NewPasswordPolicy(util.Config{BreachedPasswordsFile: filepath.Join(t.TempDir(), "missing.txt")})
+	require.Error(t, err)
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"net/mail"
 	"regexp"
+
+	"bitbucket.org/jessyw/go_simplebank/util"
 )
 
 var (
@@ -39,6 +41,7 @@ func ValidateFullName(value string) error {
 	return nil
 }
 
+// ValidatePassword checks the length of a password given to log in, new passwords must follow a PasswordPolicy
 func ValidatePassword(value string) error {
 	return ValidateString(value, 6, 100)
 }
@@ -63,3 +66,24 @@ func ValidateEmailId(value int64) error {
 func ValidateSecretCode(value string) error {
 	return ValidateString(value, 32, 128)
 }
+
+func ValidateAccountID(value int64) error {
+	if value <= 0 {
+		return fmt.Errorf("must be a positive integer")
+	}
+	return nil
+}
+
+func ValidateAmount(value int64) error {
+	if value <= 0 {
+		return fmt.Errorf("must be a positive integer")
+	}
+	return nil
+}
+
+func ValidateCurrency(value string) error {
+	if !util.IsSupportedCurrency(value) {
+		return fmt.Errorf("is not a supported currency")
+	}
+	return nil
+}